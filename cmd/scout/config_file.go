@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the optional scout.yaml/scout.json configuration file.
+// Zero values mean "not set"; environment variables override any field.
+type fileConfig struct {
+	DatabaseURL              string           `yaml:"database_url" json:"database_url"`
+	DBStatementTimeout       duration         `yaml:"db_statement_timeout" json:"db_statement_timeout"`
+	CollectionsDir           string           `yaml:"collections_dir" json:"collections_dir"`
+	NewmanScriptPath         string           `yaml:"newman_script_path" json:"newman_script_path"`
+	ExecutorBackend          string           `yaml:"executor_backend" json:"executor_backend"`
+	ExecutorDockerImage      string           `yaml:"executor_docker_image" json:"executor_docker_image"`
+	ExecutorDockerNetwork    string           `yaml:"executor_docker_network" json:"executor_docker_network"`
+	Interval                 duration         `yaml:"interval" json:"interval"`
+	Port                     int              `yaml:"port" json:"port"`
+	BindAddress              string           `yaml:"bind_address" json:"bind_address"`
+	Concurrency              int              `yaml:"concurrency" json:"concurrency"`
+	GlobalRPS                float64          `yaml:"global_rps" json:"global_rps"`
+	HostGroupConcurrency     int              `yaml:"host_group_concurrency" json:"host_group_concurrency"`
+	StallIntervals           int              `yaml:"stall_intervals" json:"stall_intervals"`
+	Retention                duration         `yaml:"retention" json:"retention"`
+	RetentionMinKeep         int              `yaml:"retention_min_keep" json:"retention_min_keep"`
+	MultiReplica             bool             `yaml:"multi_replica" json:"multi_replica"`
+	ReplicaName              string           `yaml:"replica_name" json:"replica_name"`
+	HostGroupLimits          []string         `yaml:"host_group_limits" json:"host_group_limits"`
+	RunOnStart               *bool            `yaml:"run_on_start" json:"run_on_start"`
+	StartJitter              duration         `yaml:"start_jitter" json:"start_jitter"`
+	CollectionJitter         duration         `yaml:"collection_jitter" json:"collection_jitter"`
+	MaxResultsPerExecution   int              `yaml:"max_results_per_execution" json:"max_results_per_execution"`
+	MaxErrorLength           int              `yaml:"max_error_length" json:"max_error_length"`
+	StartupTimeout           duration         `yaml:"startup_timeout" json:"startup_timeout"`
+	DBWriteConcurrency       int              `yaml:"db_write_concurrency" json:"db_write_concurrency"`
+	DurationTrendWindow      int              `yaml:"duration_trend_window" json:"duration_trend_window"`
+	DurationRegressionFactor float64          `yaml:"duration_regression_factor" json:"duration_regression_factor"`
+	RemoteCacheDir           string           `yaml:"remote_cache_dir" json:"remote_cache_dir"`
+	RemoteCacheTTL           duration         `yaml:"remote_cache_ttl" json:"remote_cache_ttl"`
+	RemoteFetchTimeout       duration         `yaml:"remote_fetch_timeout" json:"remote_fetch_timeout"`
+	RemoteFetchConcurrency   int              `yaml:"remote_fetch_concurrency" json:"remote_fetch_concurrency"`
+	APIKeys                  []string         `yaml:"api_keys" json:"api_keys"`
+	BasicAuthUser            string           `yaml:"basic_auth_user" json:"basic_auth_user"`
+	BasicAuthPass            string           `yaml:"basic_auth_pass" json:"basic_auth_pass"`
+	MetricsPublic            bool             `yaml:"metrics_public" json:"metrics_public"`
+	SlackWebhookURL          string           `yaml:"slack_webhook_url" json:"slack_webhook_url"`
+	WebhookURL               string           `yaml:"webhook_url" json:"webhook_url"`
+	KeyFields                []string         `yaml:"key_fields" json:"key_fields"`
+	MaxRequestBodyBytes      int              `yaml:"max_request_body_bytes" json:"max_request_body_bytes"`
+	MaxImportBytes           int              `yaml:"max_import_bytes" json:"max_import_bytes"`
+	SecretCacheTTL           duration         `yaml:"secret_cache_ttl" json:"secret_cache_ttl"`
+	EnvDecryptCommand        []string         `yaml:"env_decrypt_command" json:"env_decrypt_command"`
+	NotifyWarnings           bool             `yaml:"notify_warnings" json:"notify_warnings"`
+	NotifyNoTests            *bool            `yaml:"notify_no_tests" json:"notify_no_tests"`
+	NotifyMode               string           `yaml:"notify_mode" json:"notify_mode"`
+	NotifyThrottle           duration         `yaml:"notify_throttle" json:"notify_throttle"`
+	NewCollectionGrace       duration         `yaml:"new_collection_grace" json:"new_collection_grace"`
+	UnhealthyFailureRatio    float64          `yaml:"unhealthy_failure_ratio" json:"unhealthy_failure_ratio"`
+	ResultsCacheTTL          duration         `yaml:"results_cache_ttl" json:"results_cache_ttl"`
+	SyncRunTimeout           duration         `yaml:"sync_run_timeout" json:"sync_run_timeout"`
+	EnablePprof              bool             `yaml:"enable_pprof" json:"enable_pprof"`
+	MetricsNamespace         string           `yaml:"metrics_namespace" json:"metrics_namespace"`
+	MetricsConstLabels       []string         `yaml:"metrics_const_labels" json:"metrics_const_labels"`
+	MetricsTestedVersions    int              `yaml:"metrics_tested_versions" json:"metrics_tested_versions"`
+	MetricsRequestTimings    bool             `yaml:"metrics_request_timings" json:"metrics_request_timings"`
+	PushgatewayURL           string           `yaml:"pushgateway_url" json:"pushgateway_url"`
+	PushgatewayJob           string           `yaml:"pushgateway_job" json:"pushgateway_job"`
+	PushgatewayInstance      string           `yaml:"pushgateway_instance" json:"pushgateway_instance"`
+	PushgatewayDelete        bool             `yaml:"pushgateway_delete_on_shutdown" json:"pushgateway_delete_on_shutdown"`
+	ReportStore              string           `yaml:"report_store" json:"report_store"`
+	ReportDir                string           `yaml:"report_dir" json:"report_dir"`
+	ReportS3Bucket           string           `yaml:"report_s3_bucket" json:"report_s3_bucket"`
+	ReportS3Prefix           string           `yaml:"report_s3_prefix" json:"report_s3_prefix"`
+	ReportS3Region           string           `yaml:"report_s3_region" json:"report_s3_region"`
+	ReportS3Endpoint         string           `yaml:"report_s3_endpoint" json:"report_s3_endpoint"`
+	Timezone                 string           `yaml:"timezone" json:"timezone"`
+	Proxy                    *fileProxyConfig `yaml:"proxy" json:"proxy"`
+}
+
+// fileProxyConfig holds global proxy settings from the config file
+type fileProxyConfig struct {
+	HTTP    string `yaml:"http" json:"http"`
+	HTTPS   string `yaml:"https" json:"https"`
+	NoProxy string `yaml:"no_proxy" json:"no_proxy"`
+}
+
+// duration is a time.Duration that unmarshals from strings like "60s"
+type duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"60s\": %w", err)
+	}
+	return d.set(value)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (d *duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.set(node.Value)
+}
+
+func (d *duration) set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// loadConfigFile reads the config file at path, choosing the format by extension.
+// Unknown keys are rejected so typos fail fast.
+func loadConfigFile(path string) (fileConfig, error) {
+	var config fileConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return config, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return config, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return config, fmt.Errorf("unsupported config file extension %q (expected .yaml, .yml or .json)", filepath.Ext(path))
+	}
+
+	return config, nil
+}
+
+// orDefault returns value if set, otherwise defaultValue
+func orDefault[T comparable](value, defaultValue T) T {
+	var zero T
+	if value == zero {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/josepht96/scout/internal/scheduler"
+	"github.com/josepht96/scout/internal/storage"
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+// runMaintenance dispatches the maintenance subcommands
+func runMaintenance(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: scout maintenance recompute-keys [-apply]")
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "recompute-keys":
+		runRecomputeKeys(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown maintenance command %q (expected recompute-keys)\n", args[0])
+		os.Exit(2)
+	}
+}
+
+// runRecomputeKeys recomputes every collection's composite key under the
+// configured KEY_FIELDS strategy, merging collections that end up sharing a
+// key and rekeying the rest, and checks the result against the collections
+// on disk. Without -apply it only prints what would change.
+func runRecomputeKeys(args []string) {
+	flags := flag.NewFlagSet("recompute-keys", flag.ExitOnError)
+	apply := flags.Bool("apply", false, "merge and rekey collections instead of only printing the plan")
+	flags.Parse(args)
+
+	config := loadConfig()
+	log.Printf("Recomputing composite keys with fields: %v", config.KeyStrategy)
+
+	onDisk, err := scanCollectionKeys(config)
+	if err != nil {
+		log.Fatalf("Failed to scan collections: %v", err)
+	}
+
+	// Merging updates every collection in one pass, so it runs unbounded
+	store, err := storage.NewStorage(config.DatabaseURL, 0)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer store.Close()
+
+	// With -apply the schema is migrated under the instance lock, in the
+	// same transaction as the plan; a dry run writes nothing
+	rekey := storedCollectionKey(config.KeyStrategy)
+	plan, err := store.RecomputeKeys(rekey, *apply)
+	if errors.Is(err, storage.ErrScoutRunning) || errors.Is(err, storage.ErrSchemaBehind) {
+		log.Fatalf("Refusing to recompute keys: %v", err)
+	}
+	if err != nil {
+		log.Fatalf("Recomputing keys failed: %v", err)
+	}
+
+	for _, merge := range plan.Merges {
+		fmt.Printf("merge\t%v -> %d\t%s\t%d execution(s)\n", merge.MergedIDs, merge.KeptID, merge.Key, merge.Executions)
+	}
+	for _, change := range plan.Changes {
+		fmt.Printf("rekey\t%d\t%s -> %s\n", change.CollectionID, change.OldKey, change.NewKey)
+	}
+
+	// Collections without a file keep their history until retention prunes
+	// them; files without a collection get one on their next run
+	collections, err := store.GetAllCollections()
+	if err != nil {
+		log.Fatalf("Failed to list collections: %v", err)
+	}
+	stored := make(map[string]bool, len(collections))
+	for _, c := range collections {
+		key := rekey(c)
+		stored[key] = true
+		if !onDisk[key] {
+			fmt.Printf("missing\t%d\t%s\tno collection file on disk\n", c.ID, key)
+		}
+	}
+	for key := range onDisk {
+		if !stored[key] {
+			fmt.Printf("new\t-\t%s\tnot run yet\n", key)
+		}
+	}
+
+	switch {
+	case plan.Empty():
+		log.Printf("All composite keys are up to date")
+	case *apply:
+		log.Printf("Merged %d group(s) of collections and rekeyed %d collection(s)", len(plan.Merges), len(plan.Changes))
+	default:
+		log.Printf("%d group(s) of collections would be merged and %d collection(s) rekeyed; rerun with -apply to write them",
+			len(plan.Merges), len(plan.Changes))
+	}
+}
+
+// scanCollectionKeys rescans the collections directory and returns the
+// composite key of every collection on disk, as the scheduler computes it.
+// Remote sources aren't fetched, so their collections are left out.
+func scanCollectionKeys(config Config) (map[string]bool, error) {
+	watch := watcher.NewCollectionWatcher(config.CollectionsDir)
+	if watcher.IsArchive(config.CollectionsDir) {
+		var err error
+		if watch, err = watcher.NewArchiveWatcher(config.CollectionsDir); err != nil {
+			return nil, err
+		}
+	}
+	defer watch.Close()
+
+	groups, err := watch.ScanGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for _, group := range groups {
+		var environment *string
+		if group.Environment != nil {
+			name := watcher.EnvironmentBaseName(group.Environment.FileName)
+			environment = &name
+		}
+		for _, col := range group.Collections {
+			key, _, _, _ := scheduler.GenerateCompositeKey(config.KeyStrategy, group.Directory, environment, col.FullPath, col.DataFile)
+			keys[key] = true
+		}
+	}
+	return keys, nil
+}
+
+// storedCollectionKey recomputes a stored collection's composite key under
+// strategy from the fields it was stored with
+func storedCollectionKey(strategy scheduler.KeyStrategy) func(storage.Collection) string {
+	return func(c storage.Collection) string {
+		var environment *string
+		if c.EnvironmentName != "env" {
+			environment = &c.EnvironmentName
+		}
+		dataFile := ""
+		if c.DataFile != nil {
+			dataFile = *c.DataFile
+		}
+		key, _, _, _ := scheduler.GenerateCompositeKey(strategy, c.DirectoryName, environment, c.FilePath, dataFile)
+		return key
+	}
+}
@@ -7,6 +7,7 @@ toolchain go1.24.12
 require (
 	github.com/lib/pq v1.10.9
 	github.com/prometheus/client_golang v1.23.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
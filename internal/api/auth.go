@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AuthConfig holds credentials accepted by the API. Authentication is
+// disabled when no API keys and no basic auth user are configured.
+type AuthConfig struct {
+	APIKeys       []string
+	BasicAuthUser string
+	BasicAuthPass string
+	// PublicMetrics leaves /metrics open so Prometheus can scrape without credentials
+	PublicMetrics bool
+}
+
+// enabled reports whether any credentials are configured
+func (c AuthConfig) enabled() bool {
+	return len(c.APIKeys) > 0 || c.BasicAuthUser != ""
+}
+
+// authMiddleware requires a valid API key or basic auth credentials on every
+// request except public paths; see isPublic
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.auth.enabled() || s.auth.isPublic(r.URL.Path) || s.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.auth.BasicAuthUser != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="scout", charset="UTF-8"`)
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// authorized reports whether the request carries a valid API key or valid
+// basic auth credentials
+func (s *Server) authorized(r *http.Request) bool {
+	if key := apiKey(r); key != "" {
+		for _, valid := range s.auth.APIKeys {
+			if secureEqual(key, valid) {
+				return true
+			}
+		}
+	}
+
+	if s.auth.BasicAuthUser != "" {
+		if user, pass, ok := r.BasicAuth(); ok {
+			// Evaluate both comparisons so timing doesn't reveal which one failed
+			userOK := secureEqual(user, s.auth.BasicAuthUser)
+			passOK := secureEqual(pass, s.auth.BasicAuthPass)
+			return userOK && passOK
+		}
+	}
+
+	return false
+}
+
+// apiKey extracts an API key from the X-API-Key header or a bearer token
+func apiKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// secureEqual compares two secrets in constant time
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// isPublic reports whether a path stays open without credentials: health
+// checks, so orchestrators can probe, the version, which exposes nothing
+// sensitive, and /metrics when PublicMetrics is set
+func (c AuthConfig) isPublic(path string) bool {
+	if c.PublicMetrics && path == "/metrics" {
+		return true
+	}
+	return path == "/health" || strings.HasPrefix(path, "/health/") || path == "/api/version"
+}
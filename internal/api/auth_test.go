@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthMiddlewarePublicPaths(t *testing.T) {
+	tests := []struct {
+		name          string
+		publicMetrics bool
+		path          string
+		wantStatus    int
+	}{
+		{name: "health", path: "/health", wantStatus: http.StatusOK},
+		{name: "readiness", path: "/health/ready", wantStatus: http.StatusOK},
+		{name: "version", path: "/api/version", wantStatus: http.StatusOK},
+		{name: "results", path: "/api/results", wantStatus: http.StatusUnauthorized},
+		{name: "metrics", path: "/metrics", wantStatus: http.StatusUnauthorized},
+		{name: "public metrics", publicMetrics: true, path: "/metrics", wantStatus: http.StatusOK},
+		{name: "public metrics keeps the API private", publicMetrics: true, path: "/api/metrics.json", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{auth: AuthConfig{APIKeys: []string{"secret"}, PublicMetrics: tt.publicMetrics}}
+			handler := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s without credentials = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+
+			// Credentials always work
+			rec = httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("GET %s with an API key = %d, want 200", tt.path, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// decodeJSONBody decodes a size-limited JSON request body into dst. It writes
+// a 413 when the body exceeds the configured limit and a 400 when the body is
+// empty or malformed, and reports whether decoding succeeded.
+func (s *Server) decodeJSONBody(w http.ResponseWriter, r *http.Request, dst any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBodyBytes)
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(dst)
+	if err == nil && decoder.More() {
+		err = errors.New("unexpected data after JSON value")
+	}
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		http.Error(w, fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, io.EOF):
+		http.Error(w, "Request body is empty", http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+func TestDecodeJSONBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantOK     bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "valid", body: `{"name":"api"}`, wantOK: true, wantStatus: http.StatusOK},
+		{name: "oversized", body: `{"name":"` + strings.Repeat("x", 64) + `"}`, wantStatus: http.StatusRequestEntityTooLarge, wantBody: "Request body exceeds 32 bytes"},
+		{name: "malformed", body: `{"name":`, wantStatus: http.StatusBadRequest, wantBody: "Invalid request body"},
+		{name: "wrong type", body: `{"name":42}`, wantStatus: http.StatusBadRequest, wantBody: "Invalid request body"},
+		{name: "empty", body: "", wantStatus: http.StatusBadRequest, wantBody: "Request body is empty"},
+		{name: "trailing data", body: `{"name":"a"}{"name":"b"}`, wantStatus: http.StatusBadRequest, wantBody: "unexpected data after JSON value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{maxBodyBytes: 32}
+			req := httptest.NewRequest(http.MethodPost, "/api/test", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			var dst struct {
+				Name string `json:"name"`
+			}
+			ok := s.decodeJSONBody(rec, req, &dst)
+
+			if ok != tt.wantOK {
+				t.Fatalf("decodeJSONBody() = %v, want %v", ok, tt.wantOK)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantOK && dst.Name != "api" {
+				t.Errorf("decoded name = %q, want %q", dst.Name, "api")
+			}
+		})
+	}
+}
+
+func TestApplyConfigPatchInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		wantErr  bool
+	}{
+		{interval: `"5m"`},
+		{interval: `"1m"`},
+		{interval: `"30s"`, wantErr: true},
+		{interval: `"-5m"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			override := &storage.CollectionOverride{}
+			err := applyConfigPatch(override, map[string]json.RawMessage{"interval": json.RawMessage(tt.interval)}, time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyConfigPatch(%s) error = %v, want error %v", tt.interval, err, tt.wantErr)
+			}
+			if tt.wantErr && override.Interval != nil {
+				t.Errorf("applyConfigPatch(%s) set interval %v despite rejecting it", tt.interval, *override.Interval)
+			}
+		})
+	}
+}
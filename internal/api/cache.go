@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// resultsCache holds the assembled, unfiltered /api/results groups for a
+// short TTL, so polling dashboards don't rescan the collections directory
+// and query the database on every request. An entry is also discarded once
+// the scheduler stores an execution or the API changes state.
+type resultsCache struct {
+	ttl        time.Duration
+	generation atomic.Uint64 // Bumped by invalidate
+	mu         sync.Mutex    // Held while building, so concurrent misses build once
+	entry      *cachedResults
+}
+
+// cachedResults is one assembled copy of the results
+type cachedResults struct {
+	groups     []storage.EnvironmentGroup
+	updatedAt  time.Time
+	builtAt    time.Time
+	generation uint64
+	version    uint64 // Scheduler results version when built
+}
+
+// newResultsCache creates a cache keeping results for ttl; 0 disables it
+func newResultsCache(ttl time.Duration) *resultsCache {
+	return &resultsCache{ttl: ttl}
+}
+
+// get returns fresh cached results, building them with build on a miss.
+// version is the scheduler's current results version. hit reports whether
+// the results came from the cache.
+func (c *resultsCache) get(version uint64, build func() ([]storage.EnvironmentGroup, time.Time, error)) (entry *cachedResults, hit bool, err error) {
+	if c.ttl <= 0 {
+		groups, updatedAt, err := build()
+		if err != nil {
+			return nil, false, err
+		}
+		return &cachedResults{groups: groups, updatedAt: updatedAt, builtAt: time.Now()}, false, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	generation := c.generation.Load()
+	if e := c.entry; e != nil && e.generation == generation && e.version == version && time.Since(e.builtAt) < c.ttl {
+		return e, true, nil
+	}
+
+	groups, updatedAt, err := build()
+	if err != nil {
+		return nil, false, err
+	}
+	c.entry = &cachedResults{
+		groups:     groups,
+		updatedAt:  updatedAt,
+		builtAt:    time.Now(),
+		generation: generation,
+		version:    version,
+	}
+	return c.entry, false, nil
+}
+
+// invalidate discards the cached results without waiting for a build in progress
+func (c *resultsCache) invalidate() {
+	c.generation.Add(1)
+}
+
+// setHeaders describes the entry's freshness. Clients must revalidate, since
+// the cache is dropped as soon as a run completes.
+func (e *cachedResults) setHeaders(w http.ResponseWriter, hit bool) {
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(e.builtAt).Seconds())))
+	if hit {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+}
+
+// cacheInvalidationMiddleware drops the results cache after any API request
+// that may change state, such as acknowledging a failure or overriding a
+// collection's config, so the change shows on the next poll
+func (s *Server) cacheInvalidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			s.results.invalidate()
+		}
+	})
+}
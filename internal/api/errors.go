@@ -0,0 +1,19 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// storageError writes a failed storage read as a 504 when the query hit the
+// database statement timeout, so clients can tell a slow query from a broken
+// one, and as a 500 otherwise
+func storageError(w http.ResponseWriter, message string, err error) {
+	if storage.IsTimeout(err) {
+		http.Error(w, message+": database query timed out", http.StatusGatewayTimeout)
+		return
+	}
+	http.Error(w, fmt.Sprintf("%s: %v", message, err), http.StatusInternalServerError)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// gzipMinSize is the smallest response body worth compressing
+const gzipMinSize = 1024
+
+// gzipMiddleware compresses responses for clients that accept gzip.
+// Bodies smaller than gzipMinSize and event streams are sent uncompressed.
+func (s *Server) gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Event streams must be flushed message by message, so never buffer them
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer gw.finish()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(encoding), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response until it knows whether
+// the body is large enough to compress
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte
+	gz      *gzip.Writer
+	decided bool
+}
+
+// WriteHeader records the status code until the encoding is decided
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// Write buffers output until gzipMinSize is reached, then streams it
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < gzipMinSize {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends any buffered output to the client
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(len(w.buf) >= gzipMinSize)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// decide writes the headers and buffered body, compressing if requested and
+// the handler has not already encoded the response itself
+func (w *gzipResponseWriter) decide(compress bool) error {
+	w.decided = true
+
+	header := w.ResponseWriter.Header()
+	if compress && header.Get("Content-Encoding") == "" {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.ResponseWriter.WriteHeader(w.status)
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf)
+		w.buf = nil
+		return err
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// finish flushes a small buffered response uncompressed and closes the gzip stream
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
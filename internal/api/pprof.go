@@ -0,0 +1,35 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"net/http/pprof"
+)
+
+// registerPprof mounts the net/http/pprof handlers under /debug/pprof/.
+// Profiles expose memory contents and command lines, so they are served only
+// when API authentication is configured.
+func (s *Server) registerPprof(mux *http.ServeMux) {
+	if !s.auth.enabled() {
+		log.Printf("Warning: ENABLE_PPROF is set but API authentication is not configured; /debug/pprof/ will refuse every request")
+	}
+
+	mux.Handle("/debug/pprof/", s.requireAuth(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", s.requireAuth(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", s.requireAuth(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", s.requireAuth(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", s.requireAuth(http.HandlerFunc(pprof.Trace)))
+	log.Printf("Profiling enabled at /debug/pprof/")
+}
+
+// requireAuth refuses requests when API authentication isn't configured; the
+// auth middleware checks the credentials themselves
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.auth.enabled() {
+			http.Error(w, "Profiling requires API authentication to be configured", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
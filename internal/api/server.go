@@ -1,26 +1,60 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/josepht96/scout/internal/executor"
+	"github.com/josepht96/scout/internal/metrics"
+	"github.com/josepht96/scout/internal/notifier"
+	"github.com/josepht96/scout/internal/remote"
+	"github.com/josepht96/scout/internal/reports"
 	"github.com/josepht96/scout/internal/scheduler"
 	"github.com/josepht96/scout/internal/storage"
+	"github.com/josepht96/scout/internal/version"
 	"github.com/josepht96/scout/internal/watcher"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Server handles HTTP requests
 type Server struct {
-	storage   *storage.Storage
-	scheduler *scheduler.Scheduler
-	watcher   *watcher.CollectionWatcher
-	port      int
+	storage      *storage.Storage
+	scheduler    *scheduler.Scheduler
+	watcher      *watcher.CollectionWatcher
+	sources      *remote.Fetcher
+	notifier     *notifier.Dispatcher
+	reports      reports.ReportStore
+	metrics      *metrics.PrometheusExporter
+	port         int
+	bindAddress  string
+	timezone     *time.Location
+	trend        TrendConfig
+	auth         AuthConfig
+	maxBodyBytes int64
+	// maxImportBytes caps /api/import bodies, which are far larger than any JSON request
+	maxImportBytes int64
+	grace          time.Duration
+	unhealthy      float64
+	syncTimeout    time.Duration
+	pprof          bool
+	results        *resultsCache
+	ready          atomic.Bool
 }
 
 // Config contains server configuration
@@ -28,16 +62,62 @@ type Config struct {
 	Storage   *storage.Storage
 	Scheduler *scheduler.Scheduler
 	Watcher   *watcher.CollectionWatcher
+	Sources   *remote.Fetcher
+	Notifier  *notifier.Dispatcher
+	Reports   reports.ReportStore
+	Metrics   *metrics.PrometheusExporter
 	Port      int
+	Timezone  *time.Location
+	Trend     TrendConfig
+	Auth      AuthConfig
+	// MaxBodyBytes caps JSON request bodies
+	MaxBodyBytes int64
+	// MaxImportBytes caps /api/import request bodies
+	MaxImportBytes int64
+	// NewCollectionGrace is how long new collections are reported as in their grace period
+	NewCollectionGrace time.Duration
+	// UnhealthyFailureRatio is the fraction of failing collections above which
+	// /health/collections reports unhealthy
+	UnhealthyFailureRatio float64
+	// ResultsCacheTTL is how long assembled /api/results are reused (0 = never)
+	ResultsCacheTTL time.Duration
+	// BindAddress restricts the listener to one interface, e.g. 127.0.0.1
+	// behind a sidecar proxy (empty = all interfaces)
+	BindAddress string
+	// SyncRunTimeout bounds how long POST /api/run/sync waits for its run
+	SyncRunTimeout time.Duration
+	// EnablePprof mounts the pprof profiling handlers under /debug/pprof/
+	EnablePprof bool
+}
+
+// TrendConfig holds defaults for duration trend requests
+type TrendConfig struct {
+	Window           int
+	RegressionFactor float64
 }
 
 // NewServer creates a new HTTP server
 func NewServer(config Config) *Server {
 	return &Server{
-		storage:   config.Storage,
-		scheduler: config.Scheduler,
-		watcher:   config.Watcher,
-		port:      config.Port,
+		storage:        config.Storage,
+		scheduler:      config.Scheduler,
+		watcher:        config.Watcher,
+		sources:        config.Sources,
+		notifier:       config.Notifier,
+		reports:        config.Reports,
+		metrics:        config.Metrics,
+		port:           config.Port,
+		bindAddress:    config.BindAddress,
+		timezone:       config.Timezone,
+		trend:          config.Trend,
+		auth:           config.Auth,
+		maxBodyBytes:   config.MaxBodyBytes,
+		maxImportBytes: config.MaxImportBytes,
+		grace:          config.NewCollectionGrace,
+		unhealthy:      config.UnhealthyFailureRatio,
+		syncTimeout:    config.SyncRunTimeout,
+		pprof:          config.EnablePprof,
+		results:        newResultsCache(config.ResultsCacheTTL),
 	}
 }
 
@@ -51,21 +131,59 @@ func (s *Server) Start() error {
 
 	// API endpoints
 	mux.HandleFunc("/api/results", s.handleResults)
+	mux.HandleFunc("/api/results/{key}", s.handleCollectionResult)
 	mux.HandleFunc("/api/history", s.handleHistory)
+	mux.HandleFunc("/api/tests/history", s.handleTestHistory)
+	mux.HandleFunc("/api/assertions/stats", s.handleAssertionStats)
+	mux.HandleFunc("/api/executions/{id}", s.handleExecution)
+	mux.HandleFunc("/api/executions/{id}/report", s.handleReport)
+	mux.HandleFunc("/api/executions/{id}/replay", s.handleReplay)
+	mux.HandleFunc("/api/diff", s.handleDiff)
+	mux.HandleFunc("/api/matrix", s.handleMatrix)
+	mux.HandleFunc("/api/search", s.handleSearch)
+	mux.HandleFunc("/api/uptime", s.handleUptime)
 	mux.HandleFunc("/api/collections", s.handleCollections)
+	mux.HandleFunc("/api/collections/{id}/ack", s.handleAck)
+	mux.HandleFunc("/api/collections/{id}/trend", s.handleTrend)
+	mux.HandleFunc("/api/collections/{id}/tests", s.handleCollectionTests)
+	mux.HandleFunc("/api/collections/{id}/file", s.handleCollectionFile)
+	mux.HandleFunc("/api/collections/{id}/baseline", s.handleBaseline)
+	mux.HandleFunc("/api/collections/{id}/config", s.handleCollectionConfig)
+	mux.HandleFunc("/api/collections/{id}/cancel", s.handleCancel)
 	mux.HandleFunc("/api/run", s.handleRun)
+	mux.HandleFunc("/api/run/sync", s.handleRunSync)
 	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/metrics.json", s.handleMetricsSnapshot)
+	mux.HandleFunc("/api/grafana-dashboard.json", s.handleGrafanaDashboard)
+	mux.HandleFunc("/api/queue", s.handleQueue)
+	mux.HandleFunc("/api/sources", s.handleSources)
+	mux.HandleFunc("/api/notifiers/test", s.handleNotifierTest)
+	mux.HandleFunc("/api/maintenance", s.handleMaintenance)
+	mux.HandleFunc("/api/export", s.handleExport)
+	mux.HandleFunc("/api/import", s.handleImport)
+	mux.HandleFunc("/api/version", s.handleVersion)
 
 	// Health check
 	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/health/ready", s.handleReady)
+	mux.HandleFunc("/health/collections", s.handleCollectionsHealth)
 
 	// Prometheus metrics
 	mux.Handle("/metrics", promhttp.Handler())
 
-	addr := fmt.Sprintf(":%d", s.port)
+	// Profiling, off unless ENABLE_PPROF is set
+	if s.pprof {
+		s.registerPprof(mux)
+	}
+
+	addr := net.JoinHostPort(s.bindAddress, strconv.Itoa(s.port))
 	log.Printf("Starting HTTP server on %s", addr)
 
-	return http.ListenAndServe(addr, s.loggingMiddleware(mux))
+	if s.auth.enabled() {
+		log.Printf("API authentication enabled (%d API key(s), basic auth: %t)", len(s.auth.APIKeys), s.auth.BasicAuthUser != "")
+	}
+
+	return http.ListenAndServe(addr, s.loggingMiddleware(s.authMiddleware(s.readinessMiddleware(s.cacheInvalidationMiddleware(s.gzipMiddleware(mux))))))
 }
 
 // loggingMiddleware logs all HTTP requests
@@ -122,24 +240,77 @@ func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Optional comma-separated status and directory filters
+	query := r.URL.Query()
+	filter := storage.ResultFilter{
+		Statuses:    splitList(query.Get("status")),
+		Directories: splitList(query.Get("directory")),
+	}
+	for _, status := range filter.Statuses {
+		if !storage.ValidStatus(status) {
+			http.Error(w, fmt.Sprintf("Invalid status %q (expected passing, warning, failing, no_tests, cancelled, never_run or disabled)", status), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Summaries leave out each collection's test results
+	summary := false
+	if summaryStr := query.Get("summary"); summaryStr != "" {
+		var err error
+		summary, err = strconv.ParseBool(summaryStr)
+		if err != nil {
+			http.Error(w, "Invalid summary", http.StatusBadRequest)
+			return
+		}
+	}
+
+	cached, hit, err := s.results.get(s.scheduler.ResultsVersion(), func() ([]storage.EnvironmentGroup, time.Time, error) {
+		return s.buildResults(r.Context())
+	})
+	if err != nil {
+		storageError(w, "Error fetching results", err)
+		return
+	}
+
+	groups := storage.FilterEnvironmentGroups(cached.groups, filter)
+	envelope := resultsEnvelope{
+		UpdatedAt: cached.updatedAt,
+		Timezone:  s.timezoneName(),
+	}
+	if maintenance := s.scheduler.GetMaintenance(); maintenance.Enabled {
+		envelope.Maintenance = &maintenance
+	}
+
+	cached.setHeaders(w, hit)
+	writeResults(w, groups, envelope, summary)
+}
+
+// buildResults assembles the latest results of every collection on disk,
+// grouped by environment with rollups, before any filtering
+func (s *Server) buildResults(ctx context.Context) ([]storage.EnvironmentGroup, time.Time, error) {
 	// Get collection groups from watcher
 	groups, err := s.watcher.ScanGroups()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error scanning groups: %v", err), http.StatusInternalServerError)
-		return
+		return nil, time.Time{}, fmt.Errorf("scanning groups: %w", err)
 	}
 
 	// Get results from storage (as ungrouped)
-	storageResults, err := s.storage.GetLatestResults()
+	storageResults, err := s.storage.GetLatestResultsContext(ctx)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching results: %v", err), http.StatusInternalServerError)
-		return
+		return nil, time.Time{}, err
+	}
+
+	overrides, err := s.storage.GetCollectionOverrides()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("fetching collection overrides: %w", err)
 	}
 
 	// Build a map of composite key to collection result for easy lookup
+	now := time.Now()
 	resultsByCompositeKey := make(map[string]storage.CollectionResult)
 	for _, envGroup := range storageResults.EnvironmentGroups {
 		for _, cr := range envGroup.Collections {
+			cr.InGracePeriod = cr.Collection.InGracePeriod(s.grace, now)
 			resultsByCompositeKey[cr.Collection.CompositeKey] = cr
 		}
 	}
@@ -149,6 +320,7 @@ func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
 	for _, group := range groups {
 		envGroup := storage.EnvironmentGroup{
 			Directory:   group.Directory,
+			DisplayName: group.DisplayName,
 			Collections: []storage.CollectionResult{},
 		}
 
@@ -168,9 +340,12 @@ func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
 			if group.Environment != nil {
 				envName = &group.Environment.Name
 			}
-			compositeKey, dir, env, collName := scheduler.GenerateCompositeKey(group.Directory, envName, filepath.Base(col.FullPath))
+			compositeKey, dir, env, collName := s.scheduler.CompositeKey(group.Directory, envName, col.FullPath, col.DataFile)
 
 			if result, found := resultsByCompositeKey[compositeKey]; found {
+				config := s.scheduler.EffectiveConfig(group.Config, overrides[result.Collection.ID])
+				result.Config = &config
+				result.Disabled = col.Disabled
 				envGroup.Collections = append(envGroup.Collections, result)
 			} else {
 				// Collection file exists but no execution yet
@@ -183,25 +358,81 @@ func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
 						DirectoryName:   dir,
 						EnvironmentName: env,
 						CollectionName:  collName,
+						Annotations:     group.Config.Annotations,
 					},
 					Execution:            nil,
 					LastSuccessExecution: nil,
 					Results:              []storage.TestResult{},
+					Disabled:             col.Disabled,
 				}
+				if col.DataFile != "" {
+					cr.Collection.DataFile = &col.DataFile
+				}
+				config := s.scheduler.EffectiveConfig(group.Config, nil)
+				cr.Config = &config
 				envGroup.Collections = append(envGroup.Collections, cr)
 			}
 		}
 
 		environmentGroups = append(environmentGroups, envGroup)
 	}
+	storage.SortEnvironmentGroups(environmentGroups)
+	storage.QualifyEnvironmentNames(environmentGroups)
+	storage.ComputeRollups(environmentGroups)
+	return environmentGroups, storageResults.UpdatedAt, nil
+}
+
+// splitList splits a comma-separated query value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// maxCompositeKeyLength matches the composite_key column
+const maxCompositeKeyLength = 512
+
+// validCompositeKey reports whether key could be a composite key: non-empty,
+// valid UTF-8 within the column length, without control characters
+func validCompositeKey(key string) bool {
+	if key == "" || len(key) > maxCompositeKeyLength || !utf8.ValidString(key) {
+		return false
+	}
+	return !strings.ContainsFunc(key, unicode.IsControl)
+}
+
+// handleCollectionResult returns one collection's latest execution and
+// results, looked up by composite key so links survive database reseeds
+func (s *Server) handleCollectionResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// PathValue is already URL-decoded, so keys may be sent percent-encoded
+	compositeKey := r.PathValue("key")
+	if !validCompositeKey(compositeKey) {
+		http.Error(w, "Invalid composite key", http.StatusBadRequest)
+		return
+	}
 
-	response := &storage.LatestResults{
-		EnvironmentGroups: environmentGroups,
-		UpdatedAt:         storageResults.UpdatedAt,
+	result, err := s.storage.GetLatestResultByCompositeKeyContext(r.Context(), compositeKey)
+	if err != nil {
+		storageError(w, "Error fetching results", err)
+		return
 	}
+	if result == nil {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	result.InGracePeriod = result.Collection.InGracePeriod(s.grace, time.Now())
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(result)
 }
 
 // handleHistory returns historical execution data for a collection
@@ -235,9 +466,49 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	history, err := s.storage.GetExecutionHistory(collectionID, limit)
+	history, err := s.storage.GetExecutionHistoryContext(r.Context(), collectionID, limit)
+	if err != nil {
+		storageError(w, "Error fetching history", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONArray(w, history)
+	io.WriteString(w, "\n")
+}
+
+// handleTestHistory returns a single test's pass/fail and latency over recent executions
+func (s *Server) handleTestHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	collectionID, err := strconv.Atoi(query.Get("collection_id"))
+	if err != nil {
+		http.Error(w, "collection_id parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	testName := query.Get("test_name")
+	if testName == "" {
+		http.Error(w, "test_name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get limit (default 50, max 200)
+	limit := 50
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = min(l, 200)
+		}
+	}
+
+	history, err := s.storage.GetTestHistoryContext(r.Context(), collectionID, testName, limit)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching history: %v", err), http.StatusInternalServerError)
+		storageError(w, "Error fetching test history", err)
 		return
 	}
 
@@ -245,56 +516,1337 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(history)
 }
 
-// handleCollections returns all collections
-func (s *Server) handleCollections(w http.ResponseWriter, r *http.Request) {
+// maxAssertionBuckets caps the buckets /api/assertions/stats returns
+const maxAssertionBuckets = 1000
+
+// handleAssertionStats returns an assertion's pass rate over a time window,
+// bucketed by execution start
+func (s *Server) handleAssertionStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	collections, err := s.storage.GetAllCollections()
+	query := r.URL.Query()
+	collectionID, err := strconv.Atoi(query.Get("collection_id"))
+	if err != nil {
+		http.Error(w, "Invalid or missing collection_id", http.StatusBadRequest)
+		return
+	}
+
+	name := query.Get("name")
+	if name == "" {
+		http.Error(w, "name parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get window (default 7 days) and bucket (default 1 hour)
+	window := 7 * 24 * time.Hour
+	if windowStr := query.Get("window"); windowStr != "" {
+		window, err = time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+	}
+	bucket := time.Hour
+	if bucketStr := query.Get("bucket"); bucketStr != "" {
+		bucket, err = time.ParseDuration(bucketStr)
+		if err != nil || bucket < time.Second || bucket%time.Second != 0 {
+			http.Error(w, "Invalid bucket: must be a whole number of seconds", http.StatusBadRequest)
+			return
+		}
+	}
+	if window/bucket >= maxAssertionBuckets {
+		http.Error(w, fmt.Sprintf("Window spans too many buckets: at most %d", maxAssertionBuckets), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.storage.GetAssertionStatsContext(r.Context(), collectionID, name, time.Now().Add(-window), bucket)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching collections: %v", err), http.StatusInternalServerError)
+		storageError(w, "Error fetching assertion stats", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(collections)
+	json.NewEncoder(w).Encode(stats)
 }
 
-// handleRun triggers an immediate test run
-func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// handleExecution returns a single execution with its test results
+func (s *Server) handleExecution(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	s.scheduler.RunNow()
+	executionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid execution id", http.StatusBadRequest)
+		return
+	}
+
+	order, err := storage.ParseResultOrder(r.URL.Query().Get("sort"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	execution, err := s.storage.GetExecutionWithResultsContext(r.Context(), executionID, order)
+	if err != nil {
+		storageError(w, "Error fetching execution", err)
+		return
+	}
+	if execution == nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "ok",
-		"message": "Test execution triggered",
-	})
+	json.NewEncoder(w).Encode(execution)
 }
 
-// handleStats returns scheduler statistics
-func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+// handleReport streams an execution's raw Newman report from the report store
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	stats := s.scheduler.GetStats()
+	executionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid execution id", http.StatusBadRequest)
+		return
+	}
+
+	if s.reports == nil {
+		http.Error(w, "No report store configured", http.StatusNotFound)
+		return
+	}
+
+	execution, err := s.storage.GetExecutionByIDContext(r.Context(), executionID)
+	if err != nil {
+		storageError(w, "Error fetching execution", err)
+		return
+	}
+	if execution == nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	if execution.ReportKey == nil {
+		http.Error(w, "No report stored for this execution", http.StatusNotFound)
+		return
+	}
+
+	report, err := s.reports.Get(r.Context(), *execution.ReportKey)
+	if err != nil {
+		if errors.Is(err, reports.ErrNotFound) {
+			http.Error(w, "Report no longer exists in the report store", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error fetching report: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer report.Close()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
+	if _, err := io.Copy(w, report); err != nil {
+		log.Printf("Error streaming report for execution %d: %v", executionID, err)
+	}
 }
 
-// handleHealth returns health status
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+// handleDiff compares two executions of the same collection test by test
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fromID, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid or missing from execution id", http.StatusBadRequest)
+		return
+	}
+	toID, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid or missing to execution id", http.StatusBadRequest)
+		return
+	}
+
+	from, err := s.storage.GetExecutionWithResultsContext(r.Context(), fromID, storage.ResultOrderSequence)
+	if err != nil {
+		storageError(w, "Error fetching execution", err)
+		return
+	}
+	to, err := s.storage.GetExecutionWithResultsContext(r.Context(), toID, storage.ResultOrderSequence)
+	if err != nil {
+		storageError(w, "Error fetching execution", err)
+		return
+	}
+	if from == nil || to == nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	if from.Execution.CollectionID != to.Execution.CollectionID {
+		http.Error(w, "Executions belong to different collections", http.StatusBadRequest)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "healthy",
+	json.NewEncoder(w).Encode(storage.DiffExecutions(*from, *to))
+}
+
+// handleMatrix returns the latest status of each collection across environments
+func (s *Server) handleMatrix(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	matrix, err := s.storage.GetMatrixContext(r.Context())
+	if err != nil {
+		storageError(w, "Error fetching matrix", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(matrix)
+}
+
+// handleSearch searches test results in the latest execution of every collection
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := storage.SearchFilter{
+		TestName:    query.Get("test_name"),
+		URLContains: query.Get("url_contains"),
+		Limit:       50,
+	}
+
+	switch status := query.Get("status"); status {
+	case "":
+	case "passed", "failed":
+		passed := status == "passed"
+		filter.Passed = &passed
+	default:
+		http.Error(w, "Invalid status (expected passed or failed)", http.StatusBadRequest)
+		return
+	}
+
+	// Get limit (default 50, max 200) and offset
+	if limitStr := query.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(l, 200)
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		o, err := strconv.Atoi(offsetStr)
+		if err != nil || o < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = o
+	}
+
+	// Fetch one extra row to tell whether another page exists
+	limit := filter.Limit
+	filter.Limit++
+	results, err := s.storage.SearchTestResultsContext(r.Context(), filter)
+	if err != nil {
+		storageError(w, "Error searching results", err)
+		return
+	}
+
+	hasMore := len(results) > limit
+	if hasMore {
+		results = results[:limit]
+	}
+	if results == nil {
+		results = []storage.SearchResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results":  results,
+		"limit":    limit,
+		"offset":   filter.Offset,
+		"has_more": hasMore,
 	})
 }
+
+// handleUptime returns a collection's success rate over a time window
+func (s *Server) handleUptime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	collectionID, err := strconv.Atoi(query.Get("collection_id"))
+	if err != nil {
+		http.Error(w, "Invalid or missing collection_id", http.StatusBadRequest)
+		return
+	}
+
+	// Get window (default 24h)
+	window := 24 * time.Hour
+	if windowStr := query.Get("window"); windowStr != "" {
+		window, err = time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Manual and API runs are excluded unless include_all=true
+	includeAll := false
+	if includeStr := query.Get("include_all"); includeStr != "" {
+		includeAll, err = strconv.ParseBool(includeStr)
+		if err != nil {
+			http.Error(w, "Invalid include_all", http.StatusBadRequest)
+			return
+		}
+	}
+
+	uptime, err := s.storage.GetUptimeContext(r.Context(), collectionID, time.Now().Add(-window), includeAll)
+	if err != nil {
+		storageError(w, "Error fetching uptime", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(uptime)
+}
+
+// handleCollections returns all collections
+func (s *Server) handleCollections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	collections, err := s.storage.GetAllCollectionsContext(r.Context())
+	if err != nil {
+		storageError(w, "Error fetching collections", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collections)
+}
+
+// ackRequest is the body of POST /api/collections/{id}/ack
+type ackRequest struct {
+	User   string `json:"user"`
+	Reason string `json:"reason"`
+}
+
+// handleAck acknowledges a collection's current failure (POST) or removes the
+// acknowledgment (DELETE). Acknowledged failures keep running but don't notify.
+func (s *Server) handleAck(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := s.storage.GetCollectionByID(collectionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching collection: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if collection == nil {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var req ackRequest
+		if !s.decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.User == "" {
+			http.Error(w, "user is required", http.StatusBadRequest)
+			return
+		}
+
+		latest, err := s.storage.GetExecutionHistoryContext(r.Context(), collectionID, 1)
+		if err != nil {
+			storageError(w, "Error fetching latest execution", err)
+			return
+		}
+		if len(latest) == 0 || latest[0].Status() != storage.StatusFailing {
+			http.Error(w, "Collection is not failing", http.StatusConflict)
+			return
+		}
+
+		ack := &storage.Acknowledgment{
+			CollectionID:   collectionID,
+			User:           req.User,
+			Reason:         req.Reason,
+			AcknowledgedAt: time.Now(),
+		}
+		if err := s.storage.AckCollection(ack); err != nil {
+			http.Error(w, fmt.Sprintf("Error acknowledging collection: %v", err), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Collection %s failure acknowledged by %s", collection.CompositeKey, ack.User)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ack)
+	case http.MethodDelete:
+		if _, err := s.storage.ClearAck(collectionID); err != nil {
+			http.Error(w, fmt.Sprintf("Error clearing acknowledgment: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTrend returns a collection's duration trend and regression flag
+func (s *Server) handleTrend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	collectionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	// Get window (default from config, max 500)
+	window := s.trend.Window
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		parsed, err := strconv.Atoi(windowStr)
+		if err != nil || parsed < 2 {
+			http.Error(w, "window must be an integer of at least 2", http.StatusBadRequest)
+			return
+		}
+		window = min(parsed, 500)
+	}
+
+	trend, err := s.storage.GetDurationTrendContext(r.Context(), collectionID, window, s.trend.RegressionFactor)
+	if err != nil {
+		storageError(w, "Error fetching duration trend", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trend)
+}
+
+// handleCollectionTests lists the distinct tests of a collection's recent
+// executions, flagging those missing from the latest one
+func (s *Server) handleCollectionTests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	collectionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	// Get window (default 50, max 500)
+	window := 50
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		parsed, err := strconv.Atoi(windowStr)
+		if err != nil || parsed < 1 {
+			http.Error(w, "window must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		window = min(parsed, 500)
+	}
+
+	collection, err := s.storage.GetCollectionByID(collectionID)
+	if err != nil {
+		storageError(w, "Error fetching collection", err)
+		return
+	}
+	if collection == nil {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+
+	tests, err := s.storage.GetCollectionTestsContext(r.Context(), collectionID, window)
+	if err != nil {
+		storageError(w, "Error fetching collection tests", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tests)
+}
+
+// handleReplay re-runs a stored execution's collection with the variables
+// recorded for it, plus any var=key=value overrides
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	executionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid execution id", http.StatusBadRequest)
+		return
+	}
+
+	var overrides []executor.EnvVar
+	for _, raw := range r.URL.Query()["var"] {
+		v, err := executor.ParseEnvVar(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		overrides = append(overrides, v)
+	}
+
+	replay, err := s.scheduler.ReplayExecution(executionID, overrides)
+	if err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrExecutionNotFound), errors.Is(err, scheduler.ErrCollectionNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, scheduler.ErrMaintenance), errors.Is(err, scheduler.ErrCollectionDisabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, scheduler.ErrReplayIncomplete):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, fmt.Sprintf("Error triggering replay: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":  "ok",
+		"message": fmt.Sprintf("Replay of execution %d triggered", executionID),
+		"replay":  replay,
+	})
+}
+
+// handleRun triggers an immediate test run, either of every collection or of
+// a single collection when collection_id is given
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	source, version, ok := runSourceAndVersion(w, query)
+	if !ok {
+		return
+	}
+
+	collectionIDStr := query.Get("collection_id")
+	if collectionIDStr == "" {
+		if len(query["var"]) > 0 {
+			http.Error(w, "var overrides require collection_id", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.scheduler.RunNow(source, version); err != nil {
+			if errors.Is(err, scheduler.ErrCycleRunning) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			http.Error(w, fmt.Sprintf("Error triggering run: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "ok",
+			"message": "Test execution triggered",
+		})
+		return
+	}
+
+	collectionID, err := strconv.Atoi(collectionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid collection_id", http.StatusBadRequest)
+		return
+	}
+	overrides, ok := runOverrides(w, query)
+	if !ok {
+		return
+	}
+
+	if err := s.scheduler.RunCollection(collectionID, overrides, source, version); err != nil {
+		if errors.Is(err, scheduler.ErrCollectionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, scheduler.ErrMaintenance) || errors.Is(err, scheduler.ErrCollectionDisabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error triggering run: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "ok",
+		"message": fmt.Sprintf("Test execution triggered for collection %d", collectionID),
+	})
+}
+
+// handleRunSync runs a single collection and responds once it has finished
+// with its execution and results: 200 when it passed, 422 when it didn't, or
+// 504 when it outlasts the sync run timeout, in which case it still finishes
+// and is recorded in the background
+func (s *Server) handleRunSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	source, version, ok := runSourceAndVersion(w, query)
+	if !ok {
+		return
+	}
+	collectionID, err := strconv.Atoi(query.Get("collection_id"))
+	if err != nil {
+		http.Error(w, "Invalid collection_id", http.StatusBadRequest)
+		return
+	}
+	overrides, ok := runOverrides(w, query)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.syncTimeout)
+	defer cancel()
+	executionID, err := s.scheduler.RunCollectionSync(ctx, collectionID, overrides, source, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrCollectionNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, scheduler.ErrMaintenance), errors.Is(err, scheduler.ErrCollectionDisabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, fmt.Sprintf("Run of collection %d did not finish within %v; it is still recorded when it does", collectionID, s.syncTimeout), http.StatusGatewayTimeout)
+		case errors.Is(err, context.Canceled):
+			// The client went away; the run carries on regardless
+		default:
+			http.Error(w, fmt.Sprintf("Error running collection: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	execution, err := s.storage.GetExecutionWithResultsContext(r.Context(), executionID, storage.ResultOrderSequence)
+	if err != nil {
+		storageError(w, "Error fetching execution", err)
+		return
+	}
+	if execution == nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch execution.Execution.Status() {
+	case storage.StatusPassing, storage.StatusWarning:
+	default:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(execution)
+}
+
+// runSourceAndVersion parses a run request's trigger source and tested
+// version, writing a 400 and returning false when either is invalid
+func runSourceAndVersion(w http.ResponseWriter, query url.Values) (scheduler.TriggerSource, string, bool) {
+	// The dashboard identifies itself with source=manual; other callers default to api
+	source := scheduler.SourceAPI
+	if sourceStr := query.Get("source"); sourceStr != "" {
+		parsed, err := scheduler.ParseTriggerSource(sourceStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return "", "", false
+		}
+		source = parsed
+	}
+
+	// Label the run with the version or commit under test, e.g. from a deploy pipeline
+	version := query.Get("version")
+	if version == "" {
+		version = query.Get("commit")
+	}
+	if version != "" {
+		parsed, err := scheduler.ParseTestedVersion(version)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return "", "", false
+		}
+		version = parsed
+	}
+	return source, version, true
+}
+
+// runOverrides parses a run request's repeatable var=key=value overrides,
+// which apply to that run only, writing a 400 and returning false when one
+// is invalid
+func runOverrides(w http.ResponseWriter, query url.Values) ([]executor.EnvVar, bool) {
+	var overrides []executor.EnvVar
+	for _, raw := range query["var"] {
+		v, err := executor.ParseEnvVar(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		overrides = append(overrides, v)
+	}
+	return overrides, true
+}
+
+// handleCancel stops a collection's in-flight execution, which is recorded as
+// cancelled once its Newman process exits
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	collectionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.scheduler.CancelCollection(collectionID); err != nil {
+		if errors.Is(err, scheduler.ErrCollectionNotFound) || errors.Is(err, scheduler.ErrNotRunning) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error cancelling execution: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "ok",
+		"message": fmt.Sprintf("Execution of collection %d cancelled", collectionID),
+	})
+}
+
+// handleStats returns scheduler statistics
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats := s.scheduler.GetStats()
+	stats["timezone"] = s.timezoneName()
+	stats["maintenance"] = s.scheduler.GetMaintenance()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleMetricsSnapshot returns the data behind the Prometheus metrics as
+// JSON, for pipelines that pull rather than scrape
+func (s *Server) handleMetricsSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.metrics.Snapshot())
+}
+
+// handleGrafanaDashboard returns a Grafana dashboard for Scout's metrics,
+// built for the configured namespace and constant labels
+func (s *Server) handleGrafanaDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="scout-dashboard.json"`)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false) // Keep PromQL comparisons readable
+	encoder.Encode(s.metrics.GrafanaDashboard())
+}
+
+// handleSources returns the fetch status of remote collection sources
+func (s *Server) handleSources(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	statuses := []remote.SourceStatus{}
+	if s.sources != nil {
+		statuses = s.sources.Statuses()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// handleNotifierTest sends a test notification through every configured
+// notifier and reports each outcome. Because it sends outbound messages it is
+// only available when API authentication is enabled.
+func (s *Server) handleNotifierTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.auth.enabled() {
+		http.Error(w, "Notifier test requires API authentication to be configured", http.StatusForbidden)
+		return
+	}
+
+	if s.notifier == nil || !s.notifier.Enabled() {
+		http.Error(w, "No notifiers configured", http.StatusNotFound)
+		return
+	}
+
+	results := s.notifier.Send(notifier.Notification{
+		Event:     notifier.EventTest,
+		Timestamp: time.Now(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleBaseline pins an execution as a collection's baseline (POST with
+// execution_id) or clears it (DELETE)
+func (s *Server) handleBaseline(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := s.storage.GetCollectionByID(collectionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching collection: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if collection == nil {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		executionID, err := strconv.Atoi(r.URL.Query().Get("execution_id"))
+		if err != nil {
+			http.Error(w, "Invalid or missing execution_id", http.StatusBadRequest)
+			return
+		}
+		execution, err := s.storage.GetExecutionByIDContext(r.Context(), executionID)
+		if err != nil {
+			storageError(w, "Error fetching execution", err)
+			return
+		}
+		if execution == nil {
+			http.Error(w, "Execution not found", http.StatusNotFound)
+			return
+		}
+		if execution.CollectionID != collectionID {
+			http.Error(w, "Execution belongs to a different collection", http.StatusBadRequest)
+			return
+		}
+		if err := s.storage.SetBaseline(collectionID, &executionID); err != nil {
+			http.Error(w, fmt.Sprintf("Error setting baseline: %v", err), http.StatusInternalServerError)
+			return
+		}
+		collection.BaselineExecutionID = &executionID
+		log.Printf("Collection %s baseline set to execution %d", collection.CompositeKey, executionID)
+
+	case http.MethodDelete:
+		if err := s.storage.SetBaseline(collectionID, nil); err != nil {
+			http.Error(w, fmt.Sprintf("Error clearing baseline: %v", err), http.StatusInternalServerError)
+			return
+		}
+		collection.BaselineExecutionID = nil
+		log.Printf("Collection %s baseline cleared", collection.CompositeKey)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+// handleCollectionConfig returns a collection's effective config (GET) or
+// updates its API overrides (PATCH). PATCH fields that are present replace the
+// override; null removes it, falling back to scout.yaml.
+func (s *Server) handleCollectionConfig(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPatch:
+		var patch map[string]json.RawMessage
+		if !s.decodeJSONBody(w, r, &patch) {
+			return
+		}
+
+		override, err := s.storage.GetCollectionOverride(collectionID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error fetching collection override: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if override == nil {
+			override = &storage.CollectionOverride{CollectionID: collectionID}
+		}
+		if err := applyConfigPatch(override, patch, s.scheduler.Interval()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Check the collection still exists before persisting
+		if _, err := s.scheduler.CollectionConfig(collectionID); err != nil {
+			if errors.Is(err, scheduler.ErrCollectionNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, fmt.Sprintf("Error fetching collection config: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		override.UpdatedAt = time.Now()
+		if err := s.storage.SaveCollectionOverride(override); err != nil {
+			http.Error(w, fmt.Sprintf("Error saving collection override: %v", err), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Collection %d config overridden via API: %s", collectionID, strings.Join(sortedKeys(patch), ", "))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	config, err := s.scheduler.CollectionConfig(collectionID)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrCollectionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error fetching collection config: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
+}
+
+// applyConfigPatch applies a PATCH body to an override, rejecting unknown
+// fields and invalid values, including intervals shorter than minInterval
+// that cycles couldn't honour
+func applyConfigPatch(override *storage.CollectionOverride, patch map[string]json.RawMessage, minInterval time.Duration) error {
+	for field, raw := range patch {
+		clear := string(raw) == "null"
+		switch field {
+		case "interval":
+			if clear {
+				override.Interval = nil
+				continue
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return fmt.Errorf("interval must be a duration string like \"5m\"")
+			}
+			interval, err := time.ParseDuration(value)
+			if err != nil || interval <= 0 {
+				return fmt.Errorf("interval must be a positive duration like \"5m\"")
+			}
+			if interval < minInterval {
+				return fmt.Errorf("interval must be at least the global INTERVAL (%v), since collections can't run more often than cycles", minInterval)
+			}
+			override.Interval = &interval
+		case "paused":
+			if clear {
+				override.Paused = nil
+				continue
+			}
+			var paused bool
+			if err := json.Unmarshal(raw, &paused); err != nil {
+				return fmt.Errorf("paused must be a boolean")
+			}
+			override.Paused = &paused
+		case "pass_threshold":
+			if clear {
+				override.PassThreshold = nil
+				continue
+			}
+			var threshold float64
+			if err := json.Unmarshal(raw, &threshold); err != nil || threshold < 0 || threshold > 100 {
+				return fmt.Errorf("pass_threshold must be a number between 0 and 100")
+			}
+			override.PassThreshold = &threshold
+		default:
+			return fmt.Errorf("unknown field %q (expected interval, paused or pass_threshold)", field)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns a map's keys in order, for stable log output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// handleCollectionFile returns the collection file Scout executes, as it is on disk now
+func (s *Server) handleCollectionFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Collections may embed credentials, so never serve them unauthenticated
+	if !s.auth.enabled() {
+		http.Error(w, "Collection files require API authentication to be configured", http.StatusForbidden)
+		return
+	}
+
+	collectionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := s.storage.GetCollectionByID(collectionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching collection: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if collection == nil {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+
+	path, err := filepath.EvalSymlinks(collection.FilePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.Error(w, "Collection file no longer exists on disk", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error resolving collection file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if path, err = filepath.Abs(path); err != nil {
+		http.Error(w, fmt.Sprintf("Error resolving collection file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	roots := []string{s.watcher.GetDirectory()}
+	if s.sources != nil {
+		roots = append(roots, s.sources.CacheDir())
+	}
+	if !withinRoots(path, roots) {
+		log.Printf("Refusing to serve collection %d: %s is outside the collection directories", collectionID, path)
+		http.Error(w, "Collection file is outside the collection directories", http.StatusForbidden)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.Error(w, "Collection file no longer exists on disk", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error reading collection file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// withinRoots reports whether path, absolute and with symlinks already
+// resolved, lies inside any of roots
+func withinRoots(path string, roots []string) bool {
+	for _, root := range roots {
+		resolved, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+		if resolved, err = filepath.Abs(resolved); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(resolved, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleExport streams every collection, execution and result as NDJSON
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.auth.enabled() {
+		http.Error(w, "Export requires API authentication to be configured", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="scout-export-%s.ndjson"`, time.Now().UTC().Format("20060102T150405Z")))
+
+	// Errors after the first record can't change the status code, so they
+	// are logged and the truncated stream fails to import cleanly
+	encoder := json.NewEncoder(w)
+	if err := s.storage.Export(func(record storage.ExportRecord) error {
+		return encoder.Encode(record)
+	}); err != nil {
+		log.Printf("Error exporting data: %v", err)
+	}
+}
+
+// handleImport ingests an NDJSON export in a single transaction
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.auth.enabled() {
+		http.Error(w, "Import requires API authentication to be configured", http.StatusForbidden)
+		return
+	}
+
+	summary, err := s.storage.Import(http.MaxBytesReader(w, r.Body, s.maxImportBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("Import body exceeds %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error importing data: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Imported %d collection(s), %d execution(s), %d result(s), %d request(s), %d variable(s)", summary.Collections, summary.Executions, summary.Results, summary.Requests, summary.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// maintenanceRequest is the body of POST /api/maintenance
+type maintenanceRequest struct {
+	Enabled bool       `json:"enabled"`
+	Reason  string     `json:"reason"`
+	Until   *time.Time `json:"until"`
+}
+
+// handleMaintenance reads or sets maintenance mode, which pauses all executions
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req maintenanceRequest
+		if !s.decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.Enabled && req.Until != nil && !req.Until.After(time.Now()) {
+			http.Error(w, "until must be in the future", http.StatusBadRequest)
+			return
+		}
+
+		err := s.scheduler.SetMaintenance(storage.MaintenanceState{
+			Enabled: req.Enabled,
+			Reason:  req.Reason,
+			Until:   req.Until,
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error setting maintenance mode: %v", err), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.scheduler.GetMaintenance())
+}
+
+// handleQueue returns pending and in-flight executions
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.scheduler.GetQueue())
+}
+
+// handleHealth returns health status
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "healthy",
+		"version": version.Version,
+		"commit":  version.Commit,
+	})
+}
+
+// handleVersion returns build information and which optional features are
+// enabled, so clients can adapt to the backend's configuration
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"version":    version.Version,
+		"commit":     version.Commit,
+		"build_time": version.BuildTime,
+		"go_version": version.GoVersion(),
+		"features": map[string]bool{
+			"authentication":       s.auth.enabled(),
+			"notifications":        s.notifier != nil && s.notifier.Enabled(),
+			"remote_sources":       s.sources != nil,
+			"export_import":        s.auth.enabled(),
+			"collection_files":     s.auth.enabled(),
+			"new_collection_grace": s.grace > 0,
+			"report_store":         s.reports != nil,
+		},
+	})
+}
+
+// SetReady marks the server ready once startup checks have passed
+func (s *Server) SetReady() {
+	s.ready.Store(true)
+}
+
+// readinessMiddleware answers 503 until SetReady, so nothing reaches the
+// database before migrations and the self-check have finished. Probes, the
+// version and the metrics endpoint don't need the database and stay available.
+func (s *Server) readinessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.ready.Load() || availableWhileStarting(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "Scout is starting", http.StatusServiceUnavailable)
+	})
+}
+
+// availableWhileStarting reports whether a path is served before SetReady
+func availableWhileStarting(path string) bool {
+	return path == "/health" || path == "/health/ready" || path == "/api/version" || path == "/metrics"
+}
+
+// handleReady reports whether startup checks have passed; 503 until then
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !s.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "starting"})
+		return
+	}
+	if s.scheduler.Stalled() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "stalled"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+}
+
+// handleCollectionsHealth reports unhealthy (503) when more than the
+// configured ratio of collections are failing, signalling a broad outage
+// rather than an isolated failure. Collections in their grace period don't count.
+func (s *Server) handleCollectionsHealth(w http.ResponseWriter, r *http.Request) {
+	collections, err := s.storage.GetAllCollectionsContext(r.Context())
+	if err != nil {
+		storageError(w, "Error fetching collections", err)
+		return
+	}
+	executions, err := s.storage.GetLatestExecutionsContext(r.Context())
+	if err != nil {
+		storageError(w, "Error fetching latest executions", err)
+		return
+	}
+
+	byID := make(map[int]storage.Collection, len(collections))
+	for _, c := range collections {
+		byID[c.ID] = c
+	}
+
+	total, failing := 0, 0
+	for i := range executions {
+		exec := &executions[i]
+		if c, ok := byID[exec.CollectionID]; ok && c.InGracePeriod(s.grace, exec.StartedAt) {
+			continue
+		}
+		total++
+		if exec.Status() == storage.StatusFailing {
+			failing++
+		}
+	}
+
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(failing) / float64(total)
+	}
+	status := "healthy"
+	if ratio > s.unhealthy {
+		status = "unhealthy"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status != "healthy" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":        status,
+		"collections":   total,
+		"failing":       failing,
+		"failure_ratio": ratio,
+		"threshold":     s.unhealthy,
+	})
+}
+
+// timezoneName returns the configured display timezone; timestamps are always
+// serialized in UTC and clients use this to render them locally
+func (s *Server) timezoneName() string {
+	if s.timezone == nil {
+		return "UTC"
+	}
+	return s.timezone.String()
+}
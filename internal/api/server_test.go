@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadinessMiddleware(t *testing.T) {
+	paths := map[string]bool{
+		"/health":             true,
+		"/health/ready":       true,
+		"/api/version":        true,
+		"/metrics":            true,
+		"/":                   false,
+		"/api/results":        false,
+		"/health/collections": false,
+		"/api/import":         false,
+	}
+
+	s := &Server{}
+	handler := s.readinessMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	for path, available := range paths {
+		rec := get(path)
+		if available && rec.Code != http.StatusOK {
+			t.Errorf("GET %s while starting = %d, want 200", path, rec.Code)
+		}
+		if !available && (rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "") {
+			t.Errorf("GET %s while starting = %d (Retry-After %q), want 503 with Retry-After", path, rec.Code, rec.Header().Get("Retry-After"))
+		}
+	}
+
+	s.SetReady()
+	for path := range paths {
+		if rec := get(path); rec.Code != http.StatusOK {
+			t.Errorf("GET %s once ready = %d, want 200", path, rec.Code)
+		}
+	}
+}
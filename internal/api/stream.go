@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// writeJSONArray writes items as a JSON array one element at a time, so a
+// large response is never held in memory fully encoded
+func writeJSONArray[T any](w io.Writer, items []T) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for i := range items {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(items[i]); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+// writeJSONObject writes rest, which must encode as a JSON object, with field
+// added first and holding items written by writeJSONArray
+func writeJSONObject[T any](w io.Writer, field string, items []T, rest any) error {
+	tail, err := json.Marshal(rest)
+	if err != nil {
+		return err
+	}
+	if len(tail) < 2 || tail[0] != '{' {
+		return fmt.Errorf("%T does not encode as a JSON object", rest)
+	}
+	name, err := json.Marshal(field)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "{%s:", name); err != nil {
+		return err
+	}
+	if err := writeJSONArray(w, items); err != nil {
+		return err
+	}
+	if len(tail) > 2 {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+	if _, err := w.Write(tail[1:]); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// resultsEnvelope is everything in /api/results besides its environment
+// groups, which are streamed ahead of it
+type resultsEnvelope struct {
+	UpdatedAt   time.Time                 `json:"updated_at"`
+	Timezone    string                    `json:"timezone,omitempty"`
+	Maintenance *storage.MaintenanceState `json:"maintenance,omitempty"`
+}
+
+// collectionSummary is a collection result without its test results, for
+// /api/results?summary=true; the execution still carries the test counts
+type collectionSummary struct {
+	storage.CollectionResult
+	// Results shadows the embedded test results, leaving them out
+	Results []storage.TestResult `json:"results,omitempty"`
+}
+
+// groupSummary is an environment group of collection summaries
+type groupSummary struct {
+	storage.EnvironmentGroup
+	Collections []collectionSummary `json:"collections"`
+}
+
+// writeResults writes an /api/results response, streaming the groups one at
+// a time rather than encoding them as a whole. Summaries leave out each
+// collection's test results.
+func writeResults(w http.ResponseWriter, groups []storage.EnvironmentGroup, envelope resultsEnvelope, summary bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if summary {
+		writeJSONObject(w, "environment_groups", summarizeGroups(groups), envelope)
+		return
+	}
+	writeJSONObject(w, "environment_groups", groups, envelope)
+}
+
+// summarizeGroups strips the test results from every collection of groups
+func summarizeGroups(groups []storage.EnvironmentGroup) []groupSummary {
+	summaries := make([]groupSummary, len(groups))
+	for i, group := range groups {
+		summaries[i] = groupSummary{EnvironmentGroup: group, Collections: make([]collectionSummary, len(group.Collections))}
+		for j, cr := range group.Collections {
+			summaries[i].Collections[j] = collectionSummary{CollectionResult: cr}
+		}
+	}
+	return summaries
+}
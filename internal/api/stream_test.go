@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// resultsFixture is one group with a collection that has test results
+func resultsFixture() []storage.EnvironmentGroup {
+	return []storage.EnvironmentGroup{{
+		Directory: "shop",
+		Collections: []storage.CollectionResult{{
+			Collection: storage.Collection{ID: 1, CompositeKey: "shop_env_orders", CollectionName: "orders"},
+			Execution:  &storage.TestExecution{ID: 7, TotalTests: 2, PassedTests: 1, FailedTests: 1},
+			Results: []storage.TestResult{
+				{ID: 1, ExecutionID: 7, TestName: "status is 200", Passed: true},
+				{ID: 2, ExecutionID: 7, TestName: "has items", Passed: false},
+			},
+		}},
+	}}
+}
+
+// decodeResults runs writeResults through a recorder and decodes the body
+func decodeResults(t *testing.T, summary bool) map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	envelope := resultsEnvelope{UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Timezone: "UTC"}
+	writeResults(rec, resultsFixture(), envelope, summary)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v\n%s", err, rec.Body.String())
+	}
+	if body["updated_at"] != "2026-01-02T03:04:05Z" || body["timezone"] != "UTC" {
+		t.Errorf("envelope fields missing from %v", body)
+	}
+	return body
+}
+
+// firstCollection returns the first collection of the first group
+func firstCollection(t *testing.T, body map[string]any) map[string]any {
+	t.Helper()
+	groups, ok := body["environment_groups"].([]any)
+	if !ok || len(groups) != 1 {
+		t.Fatalf("environment_groups = %v, want one group", body["environment_groups"])
+	}
+	collections, ok := groups[0].(map[string]any)["collections"].([]any)
+	if !ok || len(collections) != 1 {
+		t.Fatalf("collections = %v, want one collection", groups[0])
+	}
+	return collections[0].(map[string]any)
+}
+
+func TestWriteResultsSummaryOmitsResults(t *testing.T) {
+	collection := firstCollection(t, decodeResults(t, true))
+
+	if _, ok := collection["results"]; ok {
+		t.Errorf("summary collection has results: %v", collection["results"])
+	}
+	execution, ok := collection["execution"].(map[string]any)
+	if !ok {
+		t.Fatalf("summary collection has no execution: %v", collection)
+	}
+	if execution["total_tests"] != 2.0 || execution["failed_tests"] != 1.0 {
+		t.Errorf("summary execution lost its test counts: %v", execution)
+	}
+	if key := collection["collection"].(map[string]any)["composite_key"]; key != "shop_env_orders" {
+		t.Errorf("composite_key = %v, want shop_env_orders", key)
+	}
+}
+
+func TestWriteResultsFull(t *testing.T) {
+	collection := firstCollection(t, decodeResults(t, false))
+
+	results, ok := collection["results"].([]any)
+	if !ok || len(results) != 2 {
+		t.Errorf("results = %v, want both test results", collection["results"])
+	}
+}
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/josepht96/scout/internal/secrets"
+)
+
+// authEnvVar passes injected auth to the Newman script, keeping credentials
+// off the command line where other processes could read them
+const authEnvVar = "SCOUT_AUTH"
+
+// Supported injected auth types
+const (
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+)
+
+// AuthConfig is collection-level auth applied by the Newman script in place
+// of any auth saved in the collection, its folders or its requests. Token,
+// Username and Password may contain secret references.
+type AuthConfig struct {
+	Type     string `json:"type"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// resolveAuth expands secret references in the auth credentials, returning
+// the environment entry for the Newman process and the credentials to mask
+func (e *NewmanExecutor) resolveAuth(auth AuthConfig) (string, []string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	expand := func(field, value string) (string, error) {
+		if !secrets.HasReference(value) {
+			return value, nil
+		}
+		if e.secrets == nil {
+			return "", fmt.Errorf("%s contains a secret reference but no secret resolver is configured", field)
+		}
+		expanded, _, err := e.secrets.Expand(ctx, value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", field, err)
+		}
+		return expanded, nil
+	}
+
+	var err error
+	if auth.Token, err = expand("token", auth.Token); err != nil {
+		return "", nil, err
+	}
+	if auth.Username, err = expand("username", auth.Username); err != nil {
+		return "", nil, err
+	}
+	if auth.Password, err = expand("password", auth.Password); err != nil {
+		return "", nil, err
+	}
+
+	var masked []string
+	switch auth.Type {
+	case AuthBearer:
+		masked = append(masked, auth.Token)
+	case AuthBasic:
+		masked = append(masked, auth.Username, auth.Password)
+	default:
+		return "", nil, fmt.Errorf("unsupported auth type %q", auth.Type)
+	}
+
+	encoded, err := json.Marshal(auth)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode auth: %w", err)
+	}
+	return authEnvVar + "=" + string(encoded), masked, nil
+}
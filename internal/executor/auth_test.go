@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveAuthMasksCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		auth       AuthConfig
+		wantMasked []string
+	}{
+		{name: "bearer", auth: AuthConfig{Type: AuthBearer, Token: "tok-123456"}, wantMasked: []string{"tok-123456"}},
+		{name: "basic", auth: AuthConfig{Type: AuthBasic, Username: "monitor-bot", Password: "hunter22"}, wantMasked: []string{"monitor-bot", "hunter22"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, masked, err := (&NewmanExecutor{}).resolveAuth(tt.auth)
+			if err != nil {
+				t.Fatalf("resolveAuth() error = %v", err)
+			}
+			if !strings.HasPrefix(entry, authEnvVar+"=") {
+				t.Errorf("entry = %q, want it passed as %s", entry, authEnvVar)
+			}
+			if !reflect.DeepEqual(masked, tt.wantMasked) {
+				t.Errorf("masked = %q, want %q", masked, tt.wantMasked)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package executor
+
+import "strings"
+
+// ErrorCategory is a coarse classification of why a collection failed
+type ErrorCategory string
+
+const (
+	CategoryNone              ErrorCategory = ""
+	CategoryConnectionRefused ErrorCategory = "connection_refused"
+	CategoryConnectionReset   ErrorCategory = "connection_reset"
+	CategoryDNS               ErrorCategory = "dns"
+	CategoryTLS               ErrorCategory = "tls"
+	CategoryTimeout           ErrorCategory = "timeout"
+	CategoryServerError       ErrorCategory = "http_server_error"
+	CategoryClientError       ErrorCategory = "http_client_error"
+	CategoryAssertion         ErrorCategory = "assertion_failure"
+	CategoryExecution         ErrorCategory = "execution_error"
+)
+
+// errorPatterns maps lowercase substrings of Node/Newman error messages to categories.
+// Order matters: the first match wins.
+var errorPatterns = []struct {
+	pattern  string
+	category ErrorCategory
+}{
+	{"econnrefused", CategoryConnectionRefused},
+	{"connection refused", CategoryConnectionRefused},
+	{"enotfound", CategoryDNS},
+	{"eai_again", CategoryDNS},
+	{"getaddrinfo", CategoryDNS},
+	{"etimedout", CategoryTimeout},
+	{"esockettimedout", CategoryTimeout},
+	{"timeout", CategoryTimeout},
+	{"timed out", CategoryTimeout},
+	{"econnreset", CategoryConnectionReset},
+	{"socket hang up", CategoryConnectionReset},
+	{"certificate", CategoryTLS},
+	{"cert_", CategoryTLS},
+	{"ssl", CategoryTLS},
+	{"tls", CategoryTLS},
+	{"self signed", CategoryTLS},
+}
+
+// classifyMessage returns the category for an error message, or CategoryNone
+func classifyMessage(message string) ErrorCategory {
+	lower := strings.ToLower(message)
+	for _, p := range errorPatterns {
+		if strings.Contains(lower, p.pattern) {
+			return p.category
+		}
+	}
+	return CategoryNone
+}
+
+// Classify determines why a collection run failed. Transport errors take
+// precedence over HTTP statuses, which take precedence over failed assertions.
+func (r *NewmanResult) Classify() ErrorCategory {
+	for _, exec := range r.Executions {
+		if exec.Error != nil {
+			if category := classifyMessage(*exec.Error); category != CategoryNone {
+				return category
+			}
+		}
+	}
+
+	if r.Error != nil {
+		if category := classifyMessage(*r.Error); category != CategoryNone {
+			return category
+		}
+		return CategoryExecution
+	}
+
+	for _, exec := range r.Executions {
+		if exec.Error != nil {
+			return CategoryExecution
+		}
+	}
+
+	if r.Summary.Failed == 0 {
+		return CategoryNone
+	}
+
+	category := CategoryAssertion
+	for _, exec := range r.Executions {
+		if exec.StatusCode == nil {
+			continue
+		}
+		if *exec.StatusCode >= 500 {
+			return CategoryServerError
+		}
+		if *exec.StatusCode >= 400 {
+			category = CategoryClientError
+		}
+	}
+	return category
+}
@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/josepht96/scout/internal/secrets"
+)
+
+// decryptTimeout bounds how long decrypting an environment file may take
+const decryptTimeout = 30 * time.Second
+
+// SetDecryptor enables encrypted environment files (*.postman_environment.json.enc,
+// or recognized encrypted contents), decrypted before each execution
+func (e *NewmanExecutor) SetDecryptor(decryptor secrets.Decryptor) {
+	e.decryptor = decryptor
+}
+
+// decryptEnvironment decrypts an encrypted environment file into a temporary
+// file readable only by Scout, returning its path and a func that deletes it.
+// Plain files are returned unchanged with a no-op cleanup.
+func (e *NewmanExecutor) decryptEnvironment(ctx context.Context, environmentPath string) (string, func(), error) {
+	plaintext, encrypted, err := e.readEnvironment(ctx, environmentPath)
+	if err != nil {
+		return "", nil, err
+	}
+	if !encrypted {
+		return environmentPath, func() {}, nil
+	}
+	return writeTempEnvironment(plaintext)
+}
+
+// readEnvironment returns an environment file's plaintext, decrypting it when
+// encrypted, and whether it was encrypted
+func (e *NewmanExecutor) readEnvironment(ctx context.Context, environmentPath string) ([]byte, bool, error) {
+	data, err := os.ReadFile(environmentPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read environment file: %w", err)
+	}
+	if !secrets.IsEncrypted(environmentPath, data) {
+		return data, false, nil
+	}
+	if e.decryptor == nil {
+		return nil, true, fmt.Errorf("environment file %s is encrypted but no decryptor is configured (ENV_DECRYPT_COMMAND)", filepath.Base(environmentPath))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, decryptTimeout)
+	defer cancel()
+	plaintext, err := e.decryptor.Decrypt(ctx, data)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to decrypt %s: %w", filepath.Base(environmentPath), err)
+	}
+	return plaintext, true, nil
+}
+
+// writeTempEnvironment writes environment contents to a temporary file
+// readable only by Scout, returning its path and a func that deletes it
+func writeTempEnvironment(data []byte) (string, func(), error) {
+	// CreateTemp opens the file 0600, so only Scout's user can read it
+	f, err := os.CreateTemp("", "scout-env-*.json")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary environment file: %w", err)
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write temporary environment file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write temporary environment file: %w", err)
+	}
+	return f.Name(), cleanup, nil
+}
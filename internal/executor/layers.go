@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// EnvironmentLayer is a set of variables merged beneath an execution's
+// environment. Later layers override earlier ones, and the environment file
+// itself overrides every layer.
+type EnvironmentLayer struct {
+	Name   string            // Recorded on the execution
+	Path   string            // Environment file, possibly encrypted
+	Values map[string]string // Inline variables, used when Path is empty
+}
+
+// layeredEnvironmentName names the merged environment when only shared
+// layers are present
+const layeredEnvironmentName = "shared"
+
+// layerEnvironment merges the layers and then the environment file, if any,
+// into a temporary environment file readable only by Scout. It returns the
+// file's path, the names of the merged layers lowest first, and a func that
+// deletes the file. Disabled values don't mask those of lower layers.
+func (e *NewmanExecutor) layerEnvironment(ctx context.Context, layers []EnvironmentLayer, environmentPath string) (string, []string, func(), error) {
+	document := map[string]json.RawMessage{}
+	var values []map[string]any
+	index := make(map[string]int)
+	merge := func(entries []map[string]any) {
+		for _, entry := range entries {
+			key, _ := entry["key"].(string)
+			if key == "" || entry["enabled"] == false {
+				continue
+			}
+			if i, ok := index[key]; ok {
+				values[i] = entry
+				continue
+			}
+			index[key] = len(values)
+			values = append(values, entry)
+		}
+	}
+
+	names := make([]string, 0, len(layers)+1)
+	for _, layer := range layers {
+		if layer.Path == "" {
+			merge(inlineValues(layer.Values))
+		} else {
+			_, entries, err := e.readEnvironmentValues(ctx, layer.Path)
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("shared environment %s: %w", layer.Name, err)
+			}
+			merge(entries)
+		}
+		names = append(names, layer.Name)
+	}
+
+	if environmentPath != "" {
+		fields, entries, err := e.readEnvironmentValues(ctx, environmentPath)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		document = fields // Keep the environment's own name and ID
+		merge(entries)
+		names = append(names, filepath.Base(environmentPath))
+	} else {
+		document["name"], _ = json.Marshal(layeredEnvironmentName)
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to encode layered environment: %w", err)
+	}
+	document["values"] = encoded
+	data, err := json.Marshal(document)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to encode layered environment: %w", err)
+	}
+
+	path, cleanup, err := writeTempEnvironment(data)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return path, names, cleanup, nil
+}
+
+// readEnvironmentValues reads an environment file, decrypting it if needed,
+// returning its top-level fields and its values
+func (e *NewmanExecutor) readEnvironmentValues(ctx context.Context, path string) (map[string]json.RawMessage, []map[string]any, error) {
+	plaintext, _, err := e.readEnvironment(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(plaintext, &fields); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	var values []map[string]any
+	if raw, ok := fields["values"]; ok {
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse values of %s: %w", filepath.Base(path), err)
+		}
+	}
+	return fields, values, nil
+}
+
+// inlineValues converts inline variables to environment values, sorted by key
+func inlineValues(vars map[string]string) []map[string]any {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]map[string]any, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, map[string]any{
+			"key":     key,
+			"value":   vars[key],
+			"type":    "default",
+			"enabled": true,
+		})
+	}
+	return values
+}
@@ -2,24 +2,42 @@ package executor
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"log"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/josepht96/scout/internal/secrets"
 )
 
 // NewmanExecutor executes Postman collections using Newman
 type NewmanExecutor struct {
-	nodeExecutable string
-	scriptPath     string
+	runner      Runner
+	scriptPath  string
+	proxy       ProxyConfig
+	secrets     *secrets.Resolver
+	decryptor   secrets.Decryptor
+	versionsMu  sync.Mutex
+	versions    ToolchainVersions
+	versionsKey string
+}
+
+// ToolchainVersions holds the Node.js and Newman versions used for executions
+type ToolchainVersions struct {
+	Node   string `json:"node"`
+	Newman string `json:"newman"`
 }
 
 // NewNewmanExecutor creates a new Newman executor
 func NewNewmanExecutor(scriptPath string) *NewmanExecutor {
 	return &NewmanExecutor{
-		nodeExecutable: "node",
-		scriptPath:     scriptPath,
+		runner:     LocalNodeRunner{Node: "node"},
+		scriptPath: scriptPath,
 	}
 }
 
@@ -36,33 +54,171 @@ type TestInfo struct {
 	Passed        bool    `json:"passed"`
 	Error         *string `json:"error"`
 	ExecutionName string  `json:"executionName"`
+	Sequence      *int    `json:"sequence"`
+	// Detail is structured failure detail, set for failed assertions that
+	// report expected/actual values or JSON Schema violations
+	Detail *AssertionDetail `json:"detail"`
+	// ExpectedFailure marks a test expected to fail, whose Passed has been
+	// inverted so it reports whether the test behaved as expected
+	ExpectedFailure bool `json:"expectedFailure"`
+}
+
+// AssertionDetail is structured failure detail reported by the Newman script
+type AssertionDetail struct {
+	Path         string        `json:"path"`
+	Expected     *string       `json:"expected"`
+	Actual       *string       `json:"actual"`
+	SchemaErrors []SchemaError `json:"schemaErrors"`
+}
+
+// SchemaError is a single JSON Schema violation reported by the Newman script
+type SchemaError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
 }
 
 // ExecutionInfo contains HTTP request execution information
 type ExecutionInfo struct {
-	Name         string  `json:"name"`
-	URL          string  `json:"url"`
-	Method       string  `json:"method"`
-	Status       string  `json:"status"`
-	StatusCode   *int    `json:"statusCode"`
-	ResponseTime *int    `json:"responseTime"`
-	Error        *string `json:"error"`
+	Name         string           `json:"name"`
+	URL          string           `json:"url"`
+	Method       string           `json:"method"`
+	Status       string           `json:"status"`
+	StatusCode   *int             `json:"statusCode"`
+	ResponseTime *int             `json:"responseTime"`
+	Error        *string          `json:"error"`
+	Headers      []ResponseHeader `json:"headers"`
+	Redirects    []Redirect       `json:"redirects"`
+	// GraphQLErrors are the messages of a GraphQL response's errors array
+	GraphQLErrors []string `json:"graphqlErrors"`
+	// Snapshot is set for requests flagged for snapshotting that got a response
+	Snapshot *ResponseSnapshot `json:"snapshot"`
+	// Timings is set for requests that got a response
+	Timings *RequestTimings `json:"timings"`
+}
+
+// RequestTimings is the phase breakdown of a request's response time in
+// milliseconds; phases that didn't happen, such as DNS on a reused
+// connection, are nil
+type RequestTimings struct {
+	DNS       *float64 `json:"dns"`
+	Connect   *float64 `json:"connect"`
+	TLS       *float64 `json:"tls"`
+	FirstByte *float64 `json:"firstByte"`
+	Download  *float64 `json:"download"`
+}
+
+// ResponseSnapshot is the hash of a response body with ignored fields
+// removed, and the normalized body itself when bodies are stored
+type ResponseSnapshot struct {
+	Hash string  `json:"hash"`
+	Body *string `json:"body"`
+}
+
+// Redirect is one redirect followed while sending a request
+type Redirect struct {
+	StatusCode int    `json:"statusCode"`
+	Location   string `json:"location"`
+}
+
+// ResponseHeader is a response header captured because it was on the allowlist
+type ResponseHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CollectionVariable is a collection variable as resolved at the start or end
+// of a run, masked by the Newman script when it looks sensitive
+type CollectionVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Overridden is set when the environment defined the same variable, so
+	// requests used the environment's value instead
+	Overridden bool `json:"overridden"`
 }
 
 // NewmanResult contains the result from Newman execution
 type NewmanResult struct {
-	CollectionName  string           `json:"collectionName"`
-	CollectionPath  string           `json:"collectionPath"`
-	Timestamp       string           `json:"timestamp"`
-	Summary         ExecutionSummary `json:"summary"`
-	Tests           []TestInfo       `json:"tests"`
-	Executions      []ExecutionInfo  `json:"executions"`
-	TotalDurationMs int              `json:"totalDurationMs"`
-	Error           *string          `json:"error"`
-}
-
-// Execute runs a Postman collection using Newman with an optional environment file
-func (e *NewmanExecutor) Execute(collectionPath string, environmentPath *string, directoryName string, environmentName *string) (*NewmanResult, error) {
+	CollectionName   string            `json:"collectionName"`
+	CollectionPath   string            `json:"collectionPath"`
+	Timestamp        string            `json:"timestamp"`
+	Summary          ExecutionSummary  `json:"summary"`
+	Tests            []TestInfo        `json:"tests"`
+	Executions       []ExecutionInfo   `json:"executions"`
+	TotalDurationMs  int               `json:"totalDurationMs"`
+	RequestsTotal    *int              `json:"requestsTotal"`
+	TransferredBytes *int64            `json:"transferredBytes"`
+	TestedVersion    *string           `json:"testedVersion"`
+	Error            *string           `json:"error"`
+	ProxyUsed        bool              `json:"-"`
+	Versions         ToolchainVersions `json:"-"`
+	// EnvironmentLayers names the shared layers and environment file merged
+	// for the run, lowest first; empty when no shared layers applied
+	EnvironmentLayers []string `json:"-"`
+	// CollectionVariables are reported separately from the environment so a
+	// collection's built-in values can be told apart from overrides
+	CollectionVariables []CollectionVariable `json:"collectionVariables"`
+	// InitialVariables are the collection's variables as the run started,
+	// before any script changed them
+	InitialVariables []CollectionVariable `json:"initialVariables"`
+}
+
+// ExecuteOptions contains per-execution settings layered over the executor defaults
+type ExecuteOptions struct {
+	Proxy          ProxyConfig
+	EnvVars        []EnvVar
+	CaptureHeaders []string
+	// CollectionVars replace the collection's own variables, for a replay
+	// starting from a recorded run's inputs
+	CollectionVars []EnvVar
+	// DelayRequest is the pause Newman makes between requests (0 = none)
+	DelayRequest time.Duration
+	// SharedLayers are merged beneath the environment file, lowest first
+	SharedLayers []EnvironmentLayer
+	// Auth replaces the collection's own auth when set
+	Auth *AuthConfig
+	// GraphQLPaths are URL paths of GraphQL endpoints, whose responses are
+	// checked for errors along with those detected by content type
+	GraphQLPaths []string
+	// Snapshots selects requests whose response bodies are hashed
+	Snapshots *SnapshotConfig
+	// DataFile is a JSON iteration data file, run once per row when set
+	DataFile string
+	// NewmanArgs are extra Newman run flags, already checked against the
+	// allowlist; the Newman script applies only the flags it knows
+	NewmanArgs []string
+}
+
+// SnapshotConfig selects requests, by name glob, whose response bodies the
+// Newman script hashes, with JSON paths of dynamic fields left out of the
+// hash. StoreBody also reports the normalized body.
+type SnapshotConfig struct {
+	Requests  []string
+	Ignore    []string
+	StoreBody bool
+}
+
+// EnvVar is an environment variable override passed to Newman as --env-var
+type EnvVar struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ParseEnvVar parses a key=value override, rejecting malformed input
+func ParseEnvVar(raw string) (EnvVar, error) {
+	key, value, found := strings.Cut(raw, "=")
+	if !found {
+		return EnvVar{}, fmt.Errorf("invalid variable %q: expected key=value", raw)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t\r\n") {
+		return EnvVar{}, fmt.Errorf("invalid variable %q: key must be non-empty and contain no whitespace", raw)
+	}
+	return EnvVar{Key: key, Value: value}, nil
+}
+
+// Execute runs a Postman collection using Newman with an optional environment
+// file. Cancelling ctx stops the Node process; the returned error then wraps ctx.Err().
+func (e *NewmanExecutor) Execute(ctx context.Context, collectionPath string, environmentPath *string, directoryName string, environmentName *string, opts ExecuteOptions) (*NewmanResult, error) {
 	// Resolve absolute path to the script
 	scriptPath, err := filepath.Abs(e.scriptPath)
 	if err != nil {
@@ -75,16 +231,44 @@ func (e *NewmanExecutor) Execute(collectionPath string, environmentPath *string,
 		return nil, fmt.Errorf("failed to resolve collection path: %w", err)
 	}
 
-	// Prepare command arguments
+	// Prepare command arguments, noting the files the script reads
 	args := []string{scriptPath, absCollectionPath}
+	files := []string{absCollectionPath}
 
-	// Add environment path if provided (or empty string if not)
-	if environmentPath != nil && *environmentPath != "" {
+	// Add environment path if provided (or empty string if not). Encrypted
+	// environments are decrypted, and shared layers merged beneath the
+	// environment, into a temporary file removed after the run.
+	var layers []string
+	if len(opts.SharedLayers) > 0 {
+		absEnvironmentPath := ""
+		if environmentPath != nil && *environmentPath != "" {
+			absEnvironmentPath, err = filepath.Abs(*environmentPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve environment path: %w", err)
+			}
+		}
+		layeredPath, names, cleanup, err := e.layerEnvironment(ctx, opts.SharedLayers, absEnvironmentPath)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		environmentPath = &layeredPath
+		layers = names
+		args = append(args, layeredPath)
+		files = append(files, layeredPath)
+	} else if environmentPath != nil && *environmentPath != "" {
 		absEnvironmentPath, err := filepath.Abs(*environmentPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve environment path: %w", err)
 		}
-		args = append(args, absEnvironmentPath)
+		decryptedPath, cleanup, err := e.decryptEnvironment(ctx, absEnvironmentPath)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		environmentPath = &decryptedPath
+		args = append(args, decryptedPath)
+		files = append(files, decryptedPath)
 	} else {
 		args = append(args, "")
 	}
@@ -99,26 +283,112 @@ func (e *NewmanExecutor) Execute(collectionPath string, environmentPath *string,
 		args = append(args, "")
 	}
 
-	// Prepare command
-	cmd := exec.Command(e.nodeExecutable, args...)
+	// Add per-execution variable overrides
+	for _, v := range opts.EnvVars {
+		args = append(args, "--env-var", v.Key+"="+v.Value)
+	}
+	for _, v := range opts.CollectionVars {
+		args = append(args, "--collection-var", v.Key+"="+v.Value)
+	}
+
+	// Add response headers to capture
+	for _, name := range opts.CaptureHeaders {
+		args = append(args, "--capture-header", name)
+	}
+
+	// Add GraphQL endpoint paths
+	for _, path := range opts.GraphQLPaths {
+		args = append(args, "--graphql-path", path)
+	}
 
+	// Add requests to snapshot and the fields their snapshots ignore
+	if opts.Snapshots != nil {
+		for _, request := range opts.Snapshots.Requests {
+			args = append(args, "--snapshot-request", request)
+		}
+		for _, path := range opts.Snapshots.Ignore {
+			args = append(args, "--snapshot-ignore", path)
+		}
+		if opts.Snapshots.StoreBody {
+			args = append(args, "--snapshot-body")
+		}
+	}
+
+	// Space requests to stay within the global request budget
+	if opts.DelayRequest > 0 {
+		args = append(args, "--delay-request", strconv.FormatInt(opts.DelayRequest.Milliseconds(), 10))
+	}
+
+	// Iterate over the rows of the collection's data file
+	if opts.DataFile != "" {
+		absDataFile, err := filepath.Abs(opts.DataFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve data file path: %w", err)
+		}
+		args = append(args, "--iteration-data", absDataFile)
+		files = append(files, absDataFile)
+	}
+
+	// Pass extra Newman flags through one argument at a time
+	for _, arg := range opts.NewmanArgs {
+		args = append(args, "--newman-arg", arg)
+	}
+
+	// Pass proxy settings through the environment so Newman honors them
+	var env []string
+	proxy := e.proxy.Merge(opts.Proxy)
+	if proxy.Enabled() {
+		log.Printf("Using proxy for %s: %s", filepath.Base(collectionPath), proxy)
+		env = append(env, proxy.environ()...)
+	}
+
+	// Resolved secrets reach Newman only through its environment
+	var resolvedSecrets []string
+	if e.secrets != nil && environmentName != nil && *environmentName != "" {
+		environ, values, err := e.resolveSecrets(environmentPath, directoryName, *environmentName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+		}
+		env = append(env, environ...)
+		resolvedSecrets = values
+	}
+
+	// Injected auth is passed the same way and masked like any other secret
+	if opts.Auth != nil {
+		entry, values, err := e.resolveAuth(*opts.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve auth: %w", err)
+		}
+		env = append(env, entry)
+		resolvedSecrets = append(resolvedSecrets, values...)
+	}
+
+	cmd := e.runner.Command(ctx, args, env, files)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	// Execute command
 	err = cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("newman execution stopped: %w", ctx.Err())
+	}
 
 	// Newman may return non-zero exit code if tests fail, but still produce valid output
 	// So we'll try to parse the output regardless of exit code
 
 	// Parse the JSON output
 	var result NewmanResult
-	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+	if err := parseOutput(stdout.Bytes(), &result); err != nil {
 		// If we can't parse the output, return the error along with stderr
+		masker := secretMasker(resolvedSecrets)
 		return nil, fmt.Errorf("failed to parse newman output: %w\nStderr: %s\nStdout: %s",
-			err, stderr.String(), stdout.String())
+			err, masker.Replace(stderr.String()), masker.Replace(stdout.String()))
 	}
+	result.ProxyUsed = proxy.usesProxy()
+	result.EnvironmentLayers = layers
+	result.Versions = e.GetToolchainVersions()
+	result.maskSecrets(resolvedSecrets)
 
 	// If there was an execution error but we got valid JSON, the error will be in result.Error
 	if result.Error != nil && err != nil {
@@ -128,20 +398,31 @@ func (e *NewmanExecutor) Execute(collectionPath string, environmentPath *string,
 	return &result, nil
 }
 
-// SetNodeExecutable allows customizing the node executable path
+// SetNodeExecutable allows customizing the node executable path, running
+// the script locally
 func (e *NewmanExecutor) SetNodeExecutable(path string) {
-	e.nodeExecutable = path
+	e.runner = LocalNodeRunner{Node: path}
+}
+
+// SetRunner sets how the Newman script is run, such as in a container
+func (e *NewmanExecutor) SetRunner(runner Runner) {
+	e.runner = runner
+}
+
+// SetProxy sets the default proxy used for all executions
+func (e *NewmanExecutor) SetProxy(proxy ProxyConfig) {
+	e.proxy = proxy
 }
 
-// IsAvailable checks if Node.js is available
+// IsAvailable checks if Node.js is available to the runner
 func (e *NewmanExecutor) IsAvailable() bool {
-	cmd := exec.Command(e.nodeExecutable, "--version")
+	cmd := e.runner.Command(context.Background(), []string{"--version"}, nil, nil)
 	return cmd.Run() == nil
 }
 
 // GetVersion returns the Node.js version
 func (e *NewmanExecutor) GetVersion() (string, error) {
-	cmd := exec.Command(e.nodeExecutable, "--version")
+	cmd := e.runner.Command(context.Background(), []string{"--version"}, nil, nil)
 	output, err := cmd.Output()
 	if err != nil {
 		return "", err
@@ -149,6 +430,45 @@ func (e *NewmanExecutor) GetVersion() (string, error) {
 	return string(bytes.TrimSpace(output)), nil
 }
 
+// GetToolchainVersions returns the Node.js and Newman versions, re-querying
+// them only when the runner or script path has changed
+func (e *NewmanExecutor) GetToolchainVersions() ToolchainVersions {
+	e.versionsMu.Lock()
+	defer e.versionsMu.Unlock()
+
+	key := e.runner.String() + "|" + e.scriptPath
+	if key == e.versionsKey {
+		return e.versions
+	}
+
+	var versions ToolchainVersions
+	if nodeVersion, err := e.GetVersion(); err == nil {
+		versions.Node = nodeVersion
+	} else {
+		log.Printf("Failed to get Node.js version: %v", err)
+	}
+
+	scriptPath, err := filepath.Abs(e.scriptPath)
+	if err != nil {
+		scriptPath = e.scriptPath
+	}
+	output, err := e.runner.Command(context.Background(), []string{scriptPath, "--version"}, nil, nil).Output()
+	if err == nil {
+		var reported ToolchainVersions
+		if err := json.Unmarshal(bytes.TrimSpace(output), &reported); err == nil {
+			versions.Newman = reported.Newman
+		} else {
+			log.Printf("Failed to parse Newman version: %v", err)
+		}
+	} else {
+		log.Printf("Failed to get Newman version: %v", err)
+	}
+
+	e.versions = versions
+	e.versionsKey = key
+	return versions
+}
+
 // Helper function to convert NewmanResult to storage-compatible format
 func (r *NewmanResult) ToStorageFormat() (map[string]interface{}, error) {
 	// Parse timestamp
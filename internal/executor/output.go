@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+)
+
+// Markers the Newman script prints around its JSON result
+var (
+	resultBegin = []byte("---SCOUT-RESULT-BEGIN---")
+	resultEnd   = []byte("---SCOUT-RESULT-END---")
+)
+
+// parseOutput extracts the result from the Newman script's stdout, tolerating
+// log lines printed before or after it. It prefers the text between the result
+// markers, then the whole output, then the first JSON object that starts a line,
+// for custom scripts that don't print markers.
+func parseOutput(stdout []byte, result *NewmanResult) error {
+	if start := bytes.Index(stdout, resultBegin); start >= 0 {
+		payload := stdout[start+len(resultBegin):]
+		if end := bytes.Index(payload, resultEnd); end >= 0 {
+			payload = payload[:end]
+		}
+		return json.Unmarshal(payload, result)
+	}
+
+	err := json.Unmarshal(stdout, result)
+	if err == nil {
+		return nil
+	}
+
+	for offset := 0; offset < len(stdout); {
+		line := stdout[offset:]
+		if bytes.HasPrefix(bytes.TrimLeft(line, " \t"), []byte("{")) {
+			// The decoder stops after one value, so trailing noise is ignored
+			var candidate NewmanResult
+			if json.NewDecoder(bytes.NewReader(line)).Decode(&candidate) == nil {
+				log.Printf("Ignored non-JSON output around the Newman result")
+				*result = candidate
+				return nil
+			}
+		}
+		next := bytes.IndexByte(line, '\n')
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+
+	if len(bytes.TrimSpace(stdout)) == 0 {
+		return errors.New("no output")
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOutput(t *testing.T) {
+	const result = `{"collectionName":"smoke","totalDurationMs":42}`
+
+	tests := []struct {
+		name    string
+		stdout  string
+		wantErr string
+	}{
+		{name: "plain JSON", stdout: result},
+		{name: "markers", stdout: "starting run\n---SCOUT-RESULT-BEGIN---" + result + "---SCOUT-RESULT-END---\ndone\n"},
+		{name: "markers without end", stdout: "---SCOUT-RESULT-BEGIN---\n" + result + "\n"},
+		{name: "prefixed noise", stdout: "npm WARN deprecated package\nloading collection\n" + result + "\n"},
+		{name: "suffixed noise", stdout: result + "\nnewman exited cleanly\n"},
+		{name: "noise on both sides", stdout: "loading\n" + result + "\nexit 0\n"},
+		{name: "braces inside prefixed noise", stdout: "progress {50%} of {requests}\n" + result + "\n"},
+		{name: "line starting with a non-JSON brace", stdout: "{progress: 50%}\n" + result + "\n"},
+		{name: "braces after the result on its line", stdout: "  " + result + " done {ok}\n"},
+		{name: "malformed marker payload", stdout: "---SCOUT-RESULT-BEGIN---{\"collectionName\":---SCOUT-RESULT-END---", wantErr: "unexpected end of JSON input"},
+		{name: "no JSON", stdout: "newman: command not found\n", wantErr: "invalid character"},
+		{name: "only braces that are not JSON", stdout: "{oops}\n{still not json\n", wantErr: "invalid character"},
+		{name: "empty", stdout: "", wantErr: "no output"},
+		{name: "whitespace", stdout: " \n\t\n", wantErr: "no output"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got NewmanResult
+			err := parseOutput([]byte(tt.stdout), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseOutput() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseOutput() error = %v", err)
+			}
+			if got.CollectionName != "smoke" || got.TotalDurationMs != 42 {
+				t.Errorf("parseOutput() = {CollectionName: %q, TotalDurationMs: %d}, want {smoke, 42}", got.CollectionName, got.TotalDurationMs)
+			}
+		})
+	}
+}
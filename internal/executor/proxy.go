@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"net/url"
+)
+
+// ProxyConfig holds the proxy settings passed to the Newman process environment
+type ProxyConfig struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+// Enabled reports whether any proxy setting is configured, including a
+// NO_PROXY list on its own, which still has to reach Newman's environment
+func (p ProxyConfig) Enabled() bool {
+	return p.usesProxy() || p.NoProxy != ""
+}
+
+// usesProxy reports whether requests may be sent through a proxy
+func (p ProxyConfig) usesProxy() bool {
+	return p.HTTPProxy != "" || p.HTTPSProxy != ""
+}
+
+// Merge returns a copy of p with any non-empty fields from override applied
+func (p ProxyConfig) Merge(override ProxyConfig) ProxyConfig {
+	if override.HTTPProxy != "" {
+		p.HTTPProxy = override.HTTPProxy
+	}
+	if override.HTTPSProxy != "" {
+		p.HTTPSProxy = override.HTTPSProxy
+	}
+	if override.NoProxy != "" {
+		p.NoProxy = override.NoProxy
+	}
+	return p
+}
+
+// String returns a log-safe description of the proxy settings
+func (p ProxyConfig) String() string {
+	return "http=" + MaskProxyURL(p.HTTPProxy) + " https=" + MaskProxyURL(p.HTTPSProxy) + " no_proxy=" + p.NoProxy
+}
+
+// environ returns the proxy variables in both cases, since Node tooling checks either
+func (p ProxyConfig) environ() []string {
+	var env []string
+	if p.HTTPProxy != "" {
+		env = append(env, "HTTP_PROXY="+p.HTTPProxy, "http_proxy="+p.HTTPProxy)
+	}
+	if p.HTTPSProxy != "" {
+		env = append(env, "HTTPS_PROXY="+p.HTTPSProxy, "https_proxy="+p.HTTPSProxy)
+	}
+	if p.NoProxy != "" {
+		env = append(env, "NO_PROXY="+p.NoProxy, "no_proxy="+p.NoProxy)
+	}
+	return env
+}
+
+// MaskProxyURL hides any credentials embedded in a proxy URL for logging
+func MaskProxyURL(proxyURL string) string {
+	if proxyURL == "" {
+		return "[NOT SET]"
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return "[INVALID]"
+	}
+	if u.User != nil {
+		u.User = url.User("****")
+	}
+	return u.String()
+}
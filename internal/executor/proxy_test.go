@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProxyConfigMerge(t *testing.T) {
+	global := ProxyConfig{HTTPProxy: "http://proxy:3128", HTTPSProxy: "http://proxy:3128"}
+
+	tests := []struct {
+		name        string
+		base        ProxyConfig
+		override    ProxyConfig
+		wantEnabled bool
+		wantProxy   bool
+		wantEnv     []string
+	}{
+		{name: "nothing configured", wantEnv: nil},
+		{
+			name:        "global proxy",
+			base:        global,
+			wantEnabled: true,
+			wantProxy:   true,
+			wantEnv:     []string{"HTTP_PROXY=http://proxy:3128", "http_proxy=http://proxy:3128", "HTTPS_PROXY=http://proxy:3128", "https_proxy=http://proxy:3128"},
+		},
+		{
+			name:        "no proxy override only",
+			override:    ProxyConfig{NoProxy: "internal.example.com"},
+			wantEnabled: true,
+			wantEnv:     []string{"NO_PROXY=internal.example.com", "no_proxy=internal.example.com"},
+		},
+		{
+			name:        "no proxy override on a global proxy",
+			base:        global,
+			override:    ProxyConfig{NoProxy: "internal.example.com"},
+			wantEnabled: true,
+			wantProxy:   true,
+			wantEnv: []string{
+				"HTTP_PROXY=http://proxy:3128", "http_proxy=http://proxy:3128",
+				"HTTPS_PROXY=http://proxy:3128", "https_proxy=http://proxy:3128",
+				"NO_PROXY=internal.example.com", "no_proxy=internal.example.com",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := tt.base.Merge(tt.override)
+			if got := proxy.Enabled(); got != tt.wantEnabled {
+				t.Errorf("Enabled() = %v, want %v", got, tt.wantEnabled)
+			}
+			if got := proxy.usesProxy(); got != tt.wantProxy {
+				t.Errorf("usesProxy() = %v, want %v", got, tt.wantProxy)
+			}
+			if got := proxy.environ(); !reflect.DeepEqual(got, tt.wantEnv) {
+				t.Errorf("environ() = %q, want %q", got, tt.wantEnv)
+			}
+		})
+	}
+}
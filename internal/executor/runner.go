@@ -0,0 +1,135 @@
+package executor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Runner starts the Node process running the Newman script
+type Runner interface {
+	// Command prepares node with args, led by the script's absolute path
+	// when running it. env holds the NAME=value variables added for this
+	// run, such as proxies and resolved secrets; files lists the absolute
+	// host paths the script reads.
+	Command(ctx context.Context, args, env, files []string) *exec.Cmd
+	// String describes the runner for logs
+	String() string
+}
+
+// LocalNodeRunner runs the Newman script with a local Node.js, which sees
+// the host's files, network and environment
+type LocalNodeRunner struct {
+	// Node is the node executable
+	Node string
+}
+
+// Command implements Runner
+func (r LocalNodeRunner) Command(ctx context.Context, args, env, files []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, r.Node, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// String implements Runner
+func (r LocalNodeRunner) String() string {
+	return r.Node
+}
+
+// DefaultDockerImage is the image DockerRunner runs the Newman script in.
+// Its global newman package is found through NODE_PATH, so the script's own
+// node_modules are optional.
+const DefaultDockerImage = "postman/newman:6-alpine"
+
+// dockerNodePath is where DefaultDockerImage installs newman globally
+const dockerNodePath = "/usr/local/lib/node_modules"
+
+// DockerRunner runs the Newman script in a throwaway container, so a
+// collection's scripts can't touch the host. The container gets a read-only
+// root filesystem, no capabilities, only the files of the run mounted
+// read-only at their host paths, and only the run's added variables.
+type DockerRunner struct {
+	// Docker is the docker executable
+	Docker string
+	// Image must provide node and newman
+	Image string
+	// Network is the network the container joins; empty uses Docker's
+	// default bridge, and "none" cuts the container off entirely
+	Network string
+}
+
+// NewDockerRunner creates a Docker runner for image, defaulting to
+// DefaultDockerImage
+func NewDockerRunner(image, network string) DockerRunner {
+	if image == "" {
+		image = DefaultDockerImage
+	}
+	return DockerRunner{Docker: "docker", Image: image, Network: network}
+}
+
+// Command implements Runner. Variable values are passed in the docker
+// process's environment and only named on its command line, so secrets
+// don't show up in process listings.
+func (r DockerRunner) Command(ctx context.Context, args, env, files []string) *exec.Cmd {
+	name := "scout-newman-" + randomSuffix()
+	dockerArgs := []string{
+		"run", "--rm", "--name", name,
+		"--read-only", "--tmpfs", "/tmp",
+		"--cap-drop", "ALL", "--security-opt", "no-new-privileges",
+		"--entrypoint", "node",
+		"-e", "NODE_PATH=" + dockerNodePath,
+	}
+	if r.Network != "" {
+		dockerArgs = append(dockerArgs, "--network", r.Network)
+	}
+	for _, entry := range env {
+		key, _, _ := strings.Cut(entry, "=")
+		dockerArgs = append(dockerArgs, "-e", key)
+	}
+
+	// The script's directory is mounted whole for its node_modules, if any
+	mounted := make(map[string]bool)
+	mounts := files
+	if len(args) > 0 && filepath.IsAbs(args[0]) {
+		mounts = append([]string{filepath.Dir(args[0])}, files...)
+	}
+	for _, path := range mounts {
+		if path == "" || mounted[path] {
+			continue
+		}
+		mounted[path] = true
+		dockerArgs = append(dockerArgs, "-v", path+":"+path+":ro")
+	}
+
+	dockerArgs = append(dockerArgs, r.Image)
+	dockerArgs = append(dockerArgs, args...)
+
+	cmd := exec.CommandContext(ctx, r.Docker, dockerArgs...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	// Killing the docker client leaves the container running, so remove it
+	cmd.Cancel = func() error {
+		exec.Command(r.Docker, "rm", "-f", name).Run()
+		return cmd.Process.Kill()
+	}
+	return cmd
+}
+
+// String implements Runner
+func (r DockerRunner) String() string {
+	return "docker:" + r.Image
+}
+
+// randomSuffix returns a short random hex string for container names
+func randomSuffix() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
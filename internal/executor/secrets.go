@@ -0,0 +1,154 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/josepht96/scout/internal/secrets"
+)
+
+// secretResolveTimeout bounds how long resolving an execution's secrets may take
+const secretResolveTimeout = 30 * time.Second
+
+// minMaskedSecretLength keeps very short secrets from masking unrelated text
+const minMaskedSecretLength = 4
+
+// maskedSecret replaces resolved secret values in execution output
+const maskedSecret = "****"
+
+// SetSecretResolver enables {{scheme:ref}} secret references in environment
+// files and {directory}_{environment}_ variables
+func (e *NewmanExecutor) SetSecretResolver(resolver *secrets.Resolver) {
+	e.secrets = resolver
+}
+
+// resolveSecrets expands secret references in the environment file and the
+// directory's prefixed variables. Resolved values are returned as prefixed
+// variables for the Newman process only, so they are never written to disk,
+// along with the secrets to mask in the result.
+func (e *NewmanExecutor) resolveSecrets(environmentPath *string, directoryName, environmentName string) ([]string, []string, error) {
+	prefix := directoryName + "_" + environmentName + "_"
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	var environ, resolved []string
+	overridden := make(map[string]bool)
+
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		overridden[strings.TrimPrefix(key, prefix)] = true
+		if !secrets.HasReference(value) {
+			continue
+		}
+		expanded, values, err := e.secrets.Expand(ctx, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("variable %s: %w", key, err)
+		}
+		environ = append(environ, key+"="+expanded)
+		resolved = append(resolved, values...)
+	}
+
+	if environmentPath != nil && *environmentPath != "" {
+		data, err := os.ReadFile(*environmentPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read environment file: %w", err)
+		}
+		var env struct {
+			Values []struct {
+				Key   string `json:"key"`
+				Value any    `json:"value"`
+			} `json:"values"`
+		}
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse environment file: %w", err)
+		}
+
+		for _, v := range env.Values {
+			value, ok := v.Value.(string)
+			if !ok || overridden[v.Key] || !secrets.HasReference(value) {
+				continue // Prefixed variables win over the file, as in the Newman script
+			}
+			expanded, values, err := e.secrets.Expand(ctx, value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("environment value %s: %w", v.Key, err)
+			}
+			environ = append(environ, prefix+v.Key+"="+expanded)
+			resolved = append(resolved, values...)
+		}
+	}
+
+	return environ, resolved, nil
+}
+
+// secretMasker returns a replacer that masks the given secret values
+func secretMasker(values []string) *strings.Replacer {
+	var pairs []string
+	for _, value := range values {
+		if len(value) >= minMaskedSecretLength {
+			pairs = append(pairs, value, maskedSecret)
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// maskSecrets replaces resolved secret values wherever Newman may have echoed
+// them, such as request URLs and assertion errors, before the result is stored
+func (r *NewmanResult) maskSecrets(values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	replacer := secretMasker(values)
+	mask := func(s *string) {
+		if s != nil {
+			*s = replacer.Replace(*s)
+		}
+	}
+
+	mask(r.Error)
+	for i := range r.Tests {
+		mask(&r.Tests[i].Name)
+		mask(&r.Tests[i].ExecutionName)
+		mask(r.Tests[i].Error)
+		if detail := r.Tests[i].Detail; detail != nil {
+			mask(&detail.Path)
+			mask(detail.Expected)
+			mask(detail.Actual)
+			for j := range detail.SchemaErrors {
+				mask(&detail.SchemaErrors[j].Path)
+				mask(&detail.SchemaErrors[j].Message)
+			}
+		}
+	}
+	for i := range r.Executions {
+		exec := &r.Executions[i]
+		mask(&exec.Name)
+		mask(&exec.URL)
+		mask(exec.Error)
+		for j := range exec.Headers {
+			mask(&exec.Headers[j].Value)
+		}
+		for j := range exec.Redirects {
+			mask(&exec.Redirects[j].Location)
+		}
+		for j := range exec.GraphQLErrors {
+			mask(&exec.GraphQLErrors[j])
+		}
+		if exec.Snapshot != nil {
+			mask(exec.Snapshot.Body)
+		}
+	}
+	for i := range r.CollectionVariables {
+		mask(&r.CollectionVariables[i].Value)
+	}
+	for i := range r.InitialVariables {
+		mask(&r.InitialVariables[i].Value)
+	}
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectionState is what the exporter last exported for a collection
+type collectionState struct {
+	result   storage.CollectionResult
+	snapshot CollectionSnapshot
+	series   []series
+}
+
+// series is one exported gauge value with its label values
+type series struct {
+	vec    *prometheus.GaugeVec
+	labels []string
+	value  float64
+}
+
+// seriesKey identifies a series across updates
+type seriesKey struct {
+	vec    *prometheus.GaugeVec
+	labels string
+}
+
+// key returns the series' identity
+func (s series) key() seriesKey {
+	return seriesKey{vec: s.vec, labels: strings.Join(s.labels, "\x00")}
+}
+
+// deleteSeries removes the series of previous that current doesn't have
+func deleteSeries(previous, current []series) {
+	keep := make(map[seriesKey]bool, len(current))
+	for _, s := range current {
+		keep[s.key()] = true
+	}
+	for _, s := range previous {
+		if !keep[s.key()] {
+			s.vec.DeleteLabelValues(s.labels...)
+		}
+	}
+}
+
+// stalenessCollector reports how long ago each collection last ran and last
+// succeeded as of each scrape, so staleness keeps growing between updates
+type stalenessCollector struct {
+	exporter         *PrometheusExporter
+	sinceLastRun     *prometheus.Desc
+	sinceLastSuccess *prometheus.Desc
+}
+
+// newStalenessCollector describes the staleness gauges of exporter
+func newStalenessCollector(config Config, exporter *PrometheusExporter) *stalenessCollector {
+	labels := []string{"collection", "directory", "environment"}
+	return &stalenessCollector{
+		exporter: exporter,
+		sinceLastRun: prometheus.NewDesc(config.fqName("collection_seconds_since_last_run"),
+			"Seconds since the last run of each collection", labels, config.ConstLabels),
+		sinceLastSuccess: prometheus.NewDesc(config.fqName(metricSinceLastSuccess),
+			"Seconds since the last successful run of each collection (absent if it has never succeeded)", labels, config.ConstLabels),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *stalenessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sinceLastRun
+	ch <- c.sinceLastSuccess
+}
+
+// Collect implements prometheus.Collector
+func (c *stalenessCollector) Collect(ch chan<- prometheus.Metric) {
+	c.exporter.mu.RLock()
+	defer c.exporter.mu.RUnlock()
+
+	now := time.Now()
+	for _, state := range c.exporter.collections {
+		cs := state.snapshot
+		if cs.LastRun == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.sinceLastRun, prometheus.GaugeValue,
+			now.Sub(*cs.LastRun).Seconds(), cs.Collection, cs.Directory, cs.Environment)
+		if cs.LastSuccess != nil {
+			ch <- prometheus.MustNewConstMetric(c.sinceLastSuccess, prometheus.GaugeValue,
+				now.Sub(*cs.LastSuccess).Seconds(), cs.Collection, cs.Directory, cs.Environment)
+		}
+	}
+}
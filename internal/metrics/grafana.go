@@ -0,0 +1,229 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric names shared by the exporter and the Grafana dashboard, so panels
+// follow any rename
+const (
+	metricTestStatus       = "test_status"
+	metricTestsTotal       = "collection_tests_total"
+	metricSinceLastSuccess = "collection_seconds_since_last_success"
+	metricDuration         = "collection_duration_ms"
+	metricResponseP95      = "collection_response_time_p95_ms"
+	metricSchedulerStalled = "scheduler_stalled"
+)
+
+// collectionLegend labels a series by its collection
+const collectionLegend = "{{directory}} / {{environment}} / {{collection}}"
+
+// fqName returns the full name of a metric under the configured namespace
+func (c Config) fqName(name string) string {
+	return prometheus.BuildFQName(c.Namespace, "", name)
+}
+
+// series builds a series selector for a metric matching the instance's
+// constant labels, so several instances can share a Prometheus, and any
+// further matchers such as status="failed"
+func (c Config) series(name string, extra ...string) string {
+	names := make([]string, 0, len(c.ConstLabels))
+	for label := range c.ConstLabels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+
+	var matchers []string
+	for _, label := range names {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", label, c.ConstLabels[label]))
+	}
+	matchers = append(matchers, extra...)
+	if len(matchers) == 0 {
+		return c.fqName(name)
+	}
+	return c.fqName(name) + "{" + strings.Join(matchers, ", ") + "}"
+}
+
+// selector is series narrowed to the dashboard's directory and environment
+func (c Config) selector(name string, extra ...string) string {
+	return c.series(name, append([]string{`directory=~"$directory"`, `environment=~"$environment"`}, extra...)...)
+}
+
+// GrafanaDashboard returns a ready-to-import Grafana dashboard for the
+// exporter's metrics: collection status, failure rate, latency, staleness
+// and scheduler health
+func (e *PrometheusExporter) GrafanaDashboard() map[string]any {
+	c := e.config
+	byCollection := "sum by (directory, environment, collection) "
+
+	failedTests := c.selector(metricTestsTotal, `status="failed"`)
+	totalTests := c.selector(metricTestsTotal, `status="total"`)
+
+	panels := []map[string]any{
+		statPanel(1, "Failing collections", gridPos(0, 0, 6, 4),
+			fmt.Sprintf("count(%s > 0) or vector(0)", failedTests), "none",
+			thresholds("green", 1, "red")),
+		statPanel(2, "Collections monitored", gridPos(6, 0, 6, 4),
+			fmt.Sprintf("count(%s) or vector(0)", totalTests), "none",
+			thresholds("blue")),
+		statPanel(3, "Scheduler stalled", gridPos(12, 0, 6, 4),
+			fmt.Sprintf("max(%s) or vector(0)", c.series(metricSchedulerStalled)), "none",
+			thresholds("green", 1, "red")),
+		statPanel(4, "Slowest collection (ms)", gridPos(18, 0, 6, 4),
+			fmt.Sprintf("max(%s)", c.selector(metricDuration)), "ms",
+			thresholds("green")),
+		{
+			"id":         5,
+			"type":       "table",
+			"title":      "Collection status",
+			"datasource": datasource,
+			"gridPos":    gridPos(0, 4, 24, 8),
+			"targets": []map[string]any{
+				tableTarget(target("A", fmt.Sprintf("min by (directory, environment, collection) (%s)", c.selector(metricTestStatus)), "", true)),
+			},
+			"fieldConfig": map[string]any{
+				"defaults": map[string]any{
+					"mappings": []map[string]any{{
+						"type": "value",
+						"options": map[string]any{
+							"0": map[string]any{"text": "FAILING", "color": "red"},
+							"1": map[string]any{"text": "PASSING", "color": "green"},
+						},
+					}},
+					"custom": map[string]any{"cellOptions": map[string]any{"type": "color-background"}},
+				},
+			},
+			"transformations": []map[string]any{{
+				"id":      "organize",
+				"options": map[string]any{"excludeByName": map[string]any{"Time": true}, "renameByName": map[string]any{"Value": "Status"}},
+			}},
+		},
+		timeseriesPanel(6, "Failure rate", gridPos(0, 12, 12, 8),
+			fmt.Sprintf("%s(%s) / %s(%s)", byCollection, failedTests, byCollection, totalTests), "percentunit"),
+		timeseriesPanel(7, "Response time p95", gridPos(12, 12, 12, 8),
+			c.selector(metricResponseP95), "ms"),
+		timeseriesPanel(8, "Collection duration", gridPos(0, 20, 12, 8),
+			c.selector(metricDuration), "ms"),
+		{
+			"id":         9,
+			"type":       "bargauge",
+			"title":      "Time since last success",
+			"datasource": datasource,
+			"gridPos":    gridPos(12, 20, 12, 8),
+			"targets": []map[string]any{
+				target("A", fmt.Sprintf("sort_desc(%s)", c.selector(metricSinceLastSuccess)), collectionLegend, true),
+			},
+			"options": map[string]any{"orientation": "horizontal", "displayMode": "gradient"},
+			"fieldConfig": map[string]any{
+				"defaults": map[string]any{"unit": "s", "thresholds": thresholds("green", 3600, "orange", 86400, "red")},
+			},
+		},
+	}
+
+	return map[string]any{
+		"uid":           "scout",
+		"title":         "Scout",
+		"tags":          []string{"scout", "postman"},
+		"schemaVersion": 39,
+		"editable":      true,
+		"refresh":       "1m",
+		"time":          map[string]any{"from": "now-6h", "to": "now"},
+		"templating": map[string]any{
+			"list": []map[string]any{
+				{"name": "datasource", "label": "Data source", "type": "datasource", "query": "prometheus"},
+				labelVariable("directory", c.series(metricTestsTotal, `status="total"`)),
+				labelVariable("environment", c.series(metricTestsTotal, `status="total"`, `directory=~"$directory"`)),
+			},
+		},
+		"panels": panels,
+	}
+}
+
+// datasource points panels at the dashboard's data source variable
+var datasource = map[string]any{"type": "prometheus", "uid": "${datasource}"}
+
+// labelVariable is a multi-select dashboard variable over a label's values
+func labelVariable(label, selector string) map[string]any {
+	query := fmt.Sprintf("label_values(%s, %s)", selector, label)
+	return map[string]any{
+		"name":       label,
+		"label":      strings.ToUpper(label[:1]) + label[1:],
+		"type":       "query",
+		"datasource": datasource,
+		"query":      map[string]any{"query": query, "refId": "PrometheusVariableQueryEditor-VariableQuery"},
+		"definition": query,
+		"refresh":    2,
+		"multi":      true,
+		"includeAll": true,
+		"allValue":   ".*",
+		"current":    map[string]any{"text": "All", "value": "$__all"},
+	}
+}
+
+// target is a Prometheus query of a panel
+func target(refID, expr, legend string, instant bool) map[string]any {
+	t := map[string]any{"refId": refID, "expr": expr, "datasource": datasource}
+	if legend != "" {
+		t["legendFormat"] = legend
+	}
+	if instant {
+		t["instant"] = true
+	}
+	return t
+}
+
+// tableTarget returns a query as rows, one per series, for table panels
+func tableTarget(t map[string]any) map[string]any {
+	t["format"] = "table"
+	return t
+}
+
+// statPanel shows a single value
+func statPanel(id int, title string, pos map[string]any, expr, unit string, steps map[string]any) map[string]any {
+	return map[string]any{
+		"id":         id,
+		"type":       "stat",
+		"title":      title,
+		"datasource": datasource,
+		"gridPos":    pos,
+		"targets":    []map[string]any{target("A", expr, "", false)},
+		"options":    map[string]any{"colorMode": "background", "reduceOptions": map[string]any{"calcs": []string{"lastNotNull"}}},
+		"fieldConfig": map[string]any{
+			"defaults": map[string]any{"unit": unit, "thresholds": steps},
+		},
+	}
+}
+
+// timeseriesPanel graphs a query per collection over time
+func timeseriesPanel(id int, title string, pos map[string]any, expr, unit string) map[string]any {
+	return map[string]any{
+		"id":         id,
+		"type":       "timeseries",
+		"title":      title,
+		"datasource": datasource,
+		"gridPos":    pos,
+		"targets":    []map[string]any{target("A", expr, collectionLegend, false)},
+		"fieldConfig": map[string]any{
+			"defaults": map[string]any{"unit": unit},
+		},
+	}
+}
+
+// gridPos places a panel on the dashboard's 24-column grid
+func gridPos(x, y, w, h int) map[string]any {
+	return map[string]any{"x": x, "y": y, "w": w, "h": h}
+}
+
+// thresholds builds absolute threshold steps from a base color followed by
+// value, color pairs
+func thresholds(base string, steps ...any) map[string]any {
+	list := []map[string]any{{"color": base, "value": nil}}
+	for i := 0; i+1 < len(steps); i += 2 {
+		list = append(list, map[string]any{"value": steps[i], "color": steps[i+1]})
+	}
+	return map[string]any{"mode": "absolute", "steps": list}
+}
@@ -1,153 +1,440 @@
 package metrics
 
 import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/josepht96/scout/internal/storage"
+	"github.com/josepht96/scout/internal/version"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 // PrometheusExporter exports Scout metrics to Prometheus
 type PrometheusExporter struct {
-	testStatus             *prometheus.GaugeVec
-	testLatency            *prometheus.GaugeVec
-	collectionLastRun      *prometheus.GaugeVec
-	collectionLastSuccess  *prometheus.GaugeVec
-	collectionDuration     *prometheus.GaugeVec
-	collectionTestTotal    *prometheus.GaugeVec
-	mu                     sync.RWMutex
+	testStatus              *prometheus.GaugeVec
+	testLatency             *prometheus.GaugeVec
+	collectionLastRun       *prometheus.GaugeVec
+	collectionLastSuccess   *prometheus.GaugeVec
+	collectionDuration      *prometheus.GaugeVec
+	collectionTestTotal     *prometheus.GaugeVec
+	collectionErrorCategory *prometheus.GaugeVec
+	collectionRegression    *prometheus.GaugeVec
+	collectionWarning       *prometheus.GaugeVec
+	collectionNoTests       *prometheus.GaugeVec
+	collectionGraphQLErrors *prometheus.GaugeVec
+	collectionBodyChanges   *prometheus.GaugeVec
+	collectionP95Exceeded   *prometheus.GaugeVec
+	collectionRequests      *prometheus.GaugeVec
+	collectionTransferred   *prometheus.GaugeVec
+	collectionP50           *prometheus.GaugeVec
+	collectionP95           *prometheus.GaugeVec
+	collectionP99           *prometheus.GaugeVec
+	collectionTestedVersion *prometheus.GaugeVec
+	collectionRequestPhase  *prometheus.GaugeVec
+	assertionPassRate       *prometheus.GaugeVec
+	schedulerStalled        prometheus.Gauge
+	testedVersionLimit      int
+	config                  Config
+	pusher                  *pusher
+	collections             map[int]*collectionState // By collection ID
+	testedVersions          map[string]bool          // Exported since the latest reconcile
+	mu                      sync.RWMutex
+}
+
+// DefaultNamespace is the metric name prefix used when none is configured
+const DefaultNamespace = "scout"
+
+// Config controls how metrics are named and labeled
+type Config struct {
+	// Namespace prefixes every metric name, e.g. "scout" gives scout_up
+	Namespace string
+	// ConstLabels are added to every series, so several instances can share a Prometheus
+	ConstLabels prometheus.Labels
+	// TestedVersionLimit caps the distinct tested_version label values exported
+	// per refresh; further versions are reported as "other" and 0 disables the metric
+	TestedVersionLimit int
+	// RequestTimings exports the mean of each request timing phase per
+	// collection; per-request phases are left out to bound cardinality
+	RequestTimings bool
+	// Push sends the metrics to a Pushgateway after every update
+	Push PushConfig
+}
+
+// otherTestedVersion is the label value for versions beyond TestedVersionLimit
+const otherTestedVersion = "other"
+
+var (
+	namespacePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// reservedLabels are the variable labels used by Scout's own metrics, which
+// constant labels can't reuse
+var reservedLabels = map[string]bool{
+	"collection": true, "test_name": true, "url": true, "method": true,
+	"directory": true, "environment": true, "status": true, "category": true,
+	"version": true, "commit": true, "go_version": true, "tested_version": true,
+	"phase": true,
+}
+
+// ValidateNamespace checks that namespace is a legal metric name prefix
+func ValidateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid metrics namespace %q: must match %s", namespace, namespacePattern)
+	}
+	return nil
+}
+
+// ParseConstLabels parses name=value pairs into constant labels
+func ParseConstLabels(pairs []string) (prometheus.Labels, error) {
+	labels := prometheus.Labels{}
+	for _, pair := range pairs {
+		name, value, found := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		switch {
+		case !found:
+			return nil, fmt.Errorf("invalid constant label %q: expected name=value", pair)
+		case !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__"):
+			return nil, fmt.Errorf("invalid constant label name %q", name)
+		case reservedLabels[name]:
+			return nil, fmt.Errorf("constant label %q conflicts with a Scout metric label", name)
+		}
+		if _, ok := labels[name]; ok {
+			return nil, fmt.Errorf("duplicate constant label %q", name)
+		}
+		labels[name] = value
+	}
+	return labels, nil
+}
+
+// gaugeOpts builds options for a gauge named namespace_name
+func (c Config) gaugeOpts(name, help string) prometheus.GaugeOpts {
+	return prometheus.GaugeOpts{
+		Namespace:   c.Namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: c.ConstLabels,
+	}
 }
 
 // NewPrometheusExporter creates a new Prometheus exporter
-func NewPrometheusExporter() *PrometheusExporter {
-	return &PrometheusExporter{
+func NewPrometheusExporter(config Config) *PrometheusExporter {
+	// Static series: build information and a liveness signal for the exporter
+	promauto.NewGaugeVec(
+		config.gaugeOpts("build_info", "Build information for the running Scout binary (always 1)"),
+		[]string{"version", "commit", "go_version"},
+	).WithLabelValues(version.Version, version.Commit, version.GoVersion()).Set(1)
+
+	promauto.NewGauge(
+		config.gaugeOpts("up", "Whether the Scout exporter is running (always 1)"),
+	).Set(1)
+
+	e := &PrometheusExporter{
 		testStatus: promauto.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "scout_test_status",
-				Help: "Test status (1 for pass, 0 for fail)",
-			},
-			[]string{"collection", "test_name", "url", "method"},
+			config.gaugeOpts(metricTestStatus, "Test status (1 for pass, 0 for fail; an expected failure passes when it fails)"),
+			[]string{"collection", "test_name", "url", "method", "directory", "environment"},
 		),
 		testLatency: promauto.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "scout_test_latency_ms",
-				Help: "Test response time in milliseconds",
-			},
-			[]string{"collection", "test_name", "url", "method"},
+			config.gaugeOpts("test_latency_ms", "Test response time in milliseconds"),
+			[]string{"collection", "test_name", "url", "method", "directory", "environment"},
 		),
 		collectionLastRun: promauto.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "scout_collection_last_run_timestamp",
-				Help: "Timestamp of the last run for each collection",
-			},
-			[]string{"collection"},
+			config.gaugeOpts("collection_last_run_timestamp", "Timestamp of the last run for each collection"),
+			[]string{"collection", "directory", "environment"},
 		),
 		collectionLastSuccess: promauto.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "scout_collection_last_success_timestamp",
-				Help: "Timestamp of the last successful run (all tests passed) for each collection",
-			},
-			[]string{"collection"},
+			config.gaugeOpts("collection_last_success_timestamp", "Timestamp of the last successful run (all tests passed) for each collection"),
+			[]string{"collection", "directory", "environment"},
 		),
 		collectionDuration: promauto.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "scout_collection_duration_ms",
-				Help: "Duration of collection execution in milliseconds",
-			},
-			[]string{"collection"},
+			config.gaugeOpts(metricDuration, "Duration of collection execution in milliseconds"),
+			[]string{"collection", "directory", "environment"},
 		),
 		collectionTestTotal: promauto.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "scout_collection_tests_total",
-				Help: "Total number of tests in collection",
-			},
-			[]string{"collection", "status"},
+			config.gaugeOpts(metricTestsTotal, "Total number of tests in collection"),
+			[]string{"collection", "status", "directory", "environment"},
+		),
+		collectionErrorCategory: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_error_category", "Failure category of the latest run (1 for the current category; absent when passing)"),
+			[]string{"collection", "category", "directory", "environment"},
+		),
+		collectionRegression: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_duration_regression", "Whether the latest run exceeded the collection's rolling average duration by the regression factor (1 for regression, 0 otherwise)"),
+			[]string{"collection", "directory", "environment"},
 		),
+		collectionWarning: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_warning", "Whether the latest run passed but exceeded the collection's warn_response_time_ms (1 for warning, 0 otherwise)"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionNoTests: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_no_tests", "Whether the latest run made no assertions, usually a collection missing test scripts (1 for no tests, 0 otherwise)"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionGraphQLErrors: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_graphql_errors", "Number of GraphQL responses in the latest run that carried errors"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionBodyChanges: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_snapshot_changes", "Number of snapshotted requests whose response body changed since their previous snapshot in the latest run"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionP95Exceeded: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_p95_exceeded", "Whether the collection's rolling p95 response time is over its p95_threshold_ms (1 for exceeded, 0 otherwise)"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionRequests: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_requests_total", "Number of HTTP requests made by the latest run"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionTransferred: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_transferred_bytes", "Response bytes received by the latest run"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionP50: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_response_time_p50_ms", "Median response time across the tests of the latest run in milliseconds"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionP95: promauto.NewGaugeVec(
+			config.gaugeOpts(metricResponseP95, "95th percentile response time across the tests of the latest run in milliseconds"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionP99: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_response_time_p99_ms", "99th percentile response time across the tests of the latest run in milliseconds"),
+			[]string{"collection", "directory", "environment"},
+		),
+		collectionTestedVersion: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_tested_version", "Application version the latest run was labeled with (always 1)"),
+			[]string{"collection", "directory", "environment", "tested_version"},
+		),
+		collectionRequestPhase: promauto.NewGaugeVec(
+			config.gaugeOpts("collection_request_phase_ms", "Mean time of a request timing phase (dns, connect, tls, first_byte, download) across the requests of the latest run in milliseconds"),
+			[]string{"collection", "directory", "environment", "phase"},
+		),
+		assertionPassRate: promauto.NewGaugeVec(
+			config.gaugeOpts("assertion_pass_rate", "Fraction of the collection's recent executions in which the test passed (0 to 1)"),
+			[]string{"collection", "test_name", "directory", "environment"},
+		),
+		schedulerStalled: promauto.NewGauge(
+			config.gaugeOpts(metricSchedulerStalled, "Whether no execution cycle has completed within STALL_INTERVALS intervals (1 for stalled, 0 otherwise)"),
+		),
+		testedVersionLimit: config.TestedVersionLimit,
+		config:             config,
+		pusher:             newPusher(config.Push),
+		collections:        make(map[int]*collectionState),
+		testedVersions:     make(map[string]bool),
 	}
+	prometheus.MustRegister(newStalenessCollector(config, e))
+	return e
 }
 
-// UpdateMetrics updates Prometheus metrics with the latest results
+// UpdateMetrics reconciles every series with the latest results: each
+// collection's series are updated in place and those of collections missing
+// from results are removed. Collections are updated one at a time, so a
+// scrape never sees a collection without its series.
 func (e *PrometheusExporter) UpdateMetrics(results *storage.LatestResults) {
+	// Deferred first so the push runs after the lock is released
+	defer e.Push()
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Reset all metrics before updating
-	e.testStatus.Reset()
-	e.testLatency.Reset()
-	e.collectionLastRun.Reset()
-	e.collectionLastSuccess.Reset()
-	e.collectionDuration.Reset()
-	e.collectionTestTotal.Reset()
-
-	// Update metrics for each collection across all groups
-	for _, group := range results.EnvironmentGroups {
-		for _, cr := range group.Collections {
-			collectionName := cr.Collection.Name
-
-			// If there's no execution yet, skip
-			if cr.Execution == nil {
-				continue
+	// Tested versions are counted afresh against the limit each reconcile
+	e.testedVersions = make(map[string]bool)
+
+	seen := make(map[int]bool)
+	if results != nil {
+		for _, group := range results.EnvironmentGroups {
+			for _, cr := range group.Collections {
+				seen[cr.Collection.ID] = true
+				e.updateCollection(cr)
 			}
+		}
+	}
 
-			// Update collection-level metrics
-			e.collectionLastRun.WithLabelValues(collectionName).Set(
-				float64(cr.Execution.StartedAt.Unix()),
-			)
+	for id, state := range e.collections {
+		if !seen[id] {
+			deleteSeries(state.series, nil)
+			delete(e.collections, id)
+		}
+	}
+}
 
-			// Update last success timestamp only if all tests passed
-			if cr.Execution.FailedTests == 0 && cr.Execution.TotalTests > 0 {
-				e.collectionLastSuccess.WithLabelValues(collectionName).Set(
-					float64(cr.Execution.StartedAt.Unix()),
-				)
-			}
+// UpdateCollection updates a single collection's series from its latest
+// result, as soon as one of its executions finishes
+func (e *PrometheusExporter) UpdateCollection(cr storage.CollectionResult) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.updateCollection(cr)
+}
+
+// updateCollection sets a collection's series and removes those it no longer
+// has, such as a renamed test; e.mu must be held
+func (e *PrometheusExporter) updateCollection(cr storage.CollectionResult) {
+	cs := newCollectionSnapshot(cr, time.Now())
+	series := e.collectionSeries(cs)
+	for _, s := range series {
+		s.vec.WithLabelValues(s.labels...).Set(s.value)
+	}
 
-			e.collectionDuration.WithLabelValues(collectionName).Set(
-				float64(cr.Execution.DurationMs),
-			)
+	if previous, ok := e.collections[cr.Collection.ID]; ok {
+		deleteSeries(previous.series, series)
+	}
+	e.collections[cr.Collection.ID] = &collectionState{result: cr, snapshot: cs, series: series}
+}
 
-			e.collectionTestTotal.WithLabelValues(collectionName, "total").Set(
-				float64(cr.Execution.TotalTests),
-			)
+// collectionSeries lists the series exported for a collection's latest run;
+// collections that have never run have none
+func (e *PrometheusExporter) collectionSeries(cs CollectionSnapshot) []series {
+	if cs.LastRun == nil {
+		return nil
+	}
 
-			e.collectionTestTotal.WithLabelValues(collectionName, "passed").Set(
-				float64(cr.Execution.PassedTests),
-			)
+	var list []series
+	add := func(vec *prometheus.GaugeVec, value float64, labels ...string) {
+		list = append(list, series{vec: vec, labels: labels, value: value})
+	}
+	collectionName := cs.Collection
+	directory := cs.Directory
+	environment := cs.Environment
 
-			e.collectionTestTotal.WithLabelValues(collectionName, "failed").Set(
-				float64(cr.Execution.FailedTests),
-			)
+	add(e.collectionLastRun, float64(cs.LastRun.Unix()), collectionName, directory, environment)
 
-			// Update test-level metrics
-			for _, result := range cr.Results {
-			// Get labels
-			testName := result.TestName
-			url := ""
-			method := ""
+	// Update last success timestamp only if all tests passed
+	if cs.Succeeded {
+		add(e.collectionLastSuccess, float64(cs.LastRun.Unix()), collectionName, directory, environment)
+	}
 
-			if result.URL != nil {
-				url = *result.URL
-			}
-			if result.Method != nil {
-				method = *result.Method
-			}
+	add(e.collectionDuration, float64(cs.DurationMs), collectionName, directory, environment)
 
-			// Update test status
-			statusValue := 0.0
-			if result.Passed {
-				statusValue = 1.0
-			}
-			e.testStatus.WithLabelValues(collectionName, testName, url, method).Set(statusValue)
-
-				// Update test latency if available
-				if result.ResponseTimeMs != nil {
-					e.testLatency.WithLabelValues(collectionName, testName, url, method).Set(
-						float64(*result.ResponseTimeMs),
-					)
-				}
+	add(e.collectionTestTotal, float64(cs.Tests.Total), collectionName, "total", directory, environment)
+	add(e.collectionTestTotal, float64(cs.Tests.Passed), collectionName, "passed", directory, environment)
+	add(e.collectionTestTotal, float64(cs.Tests.Failed), collectionName, "failed", directory, environment)
+
+	if cs.ErrorCategory != nil {
+		add(e.collectionErrorCategory, 1, collectionName, *cs.ErrorCategory, directory, environment)
+	}
+
+	add(e.collectionWarning, boolValue(cs.Warning), collectionName, directory, environment)
+	add(e.collectionNoTests, boolValue(cs.NoTests), collectionName, directory, environment)
+	add(e.collectionGraphQLErrors, float64(cs.GraphQLErrors), collectionName, directory, environment)
+	add(e.collectionBodyChanges, float64(cs.SnapshotChanges), collectionName, directory, environment)
+	add(e.collectionP95Exceeded, boolValue(cs.P95Exceeded), collectionName, directory, environment)
+
+	// Versions are unbounded, so only the first few distinct values get
+	// their own label value each reconcile
+	if e.testedVersionLimit > 0 && cs.TestedVersion != nil {
+		testedVersion := *cs.TestedVersion
+		if !e.testedVersions[testedVersion] && len(e.testedVersions) >= e.testedVersionLimit {
+			testedVersion = otherTestedVersion
+		} else {
+			e.testedVersions[testedVersion] = true
+		}
+		add(e.collectionTestedVersion, 1, collectionName, directory, environment, testedVersion)
+	}
+
+	// Newman versions that don't report these leave them nil
+	if cs.RequestsTotal != nil {
+		add(e.collectionRequests, float64(*cs.RequestsTotal), collectionName, directory, environment)
+	}
+	if cs.TransferredBytes != nil {
+		add(e.collectionTransferred, float64(*cs.TransferredBytes), collectionName, directory, environment)
+	}
+
+	// Only phases some request went through get a series
+	if e.config.RequestTimings && cs.RequestPhasesMs != nil {
+		phases := []struct {
+			name  string
+			value *float64
+		}{
+			{"dns", cs.RequestPhasesMs.DNS},
+			{"connect", cs.RequestPhasesMs.Connect},
+			{"tls", cs.RequestPhasesMs.TLS},
+			{"first_byte", cs.RequestPhasesMs.FirstByte},
+			{"download", cs.RequestPhasesMs.Download},
+		}
+		for _, phase := range phases {
+			if phase.value != nil {
+				add(e.collectionRequestPhase, *phase.value, collectionName, directory, environment, phase.name)
 			}
 		}
 	}
+
+	if cs.Regression != nil {
+		add(e.collectionRegression, boolValue(*cs.Regression), collectionName, directory, environment)
+	}
+
+	// Test-level series; a test name repeated across requests or
+	// iterations has a single pass rate
+	passRates := make(map[string]bool)
+	for _, test := range cs.Results {
+		add(e.testStatus, boolValue(test.Passed), collectionName, test.Name, test.URL, test.Method, directory, environment)
+		if test.ResponseTimeMs != nil {
+			add(e.testLatency, float64(*test.ResponseTimeMs), collectionName, test.Name, test.URL, test.Method, directory, environment)
+		}
+		if test.PassRate != nil && !passRates[test.Name] {
+			passRates[test.Name] = true
+			add(e.assertionPassRate, *test.PassRate, collectionName, test.Name, directory, environment)
+		}
+	}
+
+	if cs.ResponseTimeMs != nil {
+		add(e.collectionP50, cs.ResponseTimeMs.P50, collectionName, directory, environment)
+		add(e.collectionP95, cs.ResponseTimeMs.P95, collectionName, directory, environment)
+		add(e.collectionP99, cs.ResponseTimeMs.P99, collectionName, directory, environment)
+	}
+	return list
+}
+
+// SetSchedulerStalled sets whether the scheduler has stopped completing cycles
+func (e *PrometheusExporter) SetSchedulerStalled(stalled bool) {
+	e.schedulerStalled.Set(boolValue(stalled))
+}
+
+// Snapshot computes the metrics data of the latest results as of now, for
+// /api/metrics.json. It is empty until the first metrics update.
+func (e *PrometheusExporter) Snapshot() *Snapshot {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := time.Now()
+	snapshot := &Snapshot{GeneratedAt: now, Collections: make([]CollectionSnapshot, 0, len(e.collections))}
+	for _, state := range e.collections {
+		snapshot.Collections = append(snapshot.Collections, newCollectionSnapshot(state.result, now))
+	}
+	sort.Slice(snapshot.Collections, func(i, j int) bool {
+		a, b := snapshot.Collections[i], snapshot.Collections[j]
+		if a.Directory != b.Directory {
+			return a.Directory < b.Directory
+		}
+		if a.Environment != b.Environment {
+			return a.Environment < b.Environment
+		}
+		return a.Collection < b.Collection
+	})
+	return snapshot
+}
+
+// boolValue converts a flag to a gauge value
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// percentile returns the nearest-rank percentile p of sorted, which must be non-empty
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
 }
 
 // GetRegistry returns the Prometheus registry (for custom metrics)
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	exporterOnce   sync.Once
+	sharedExporter *PrometheusExporter
+)
+
+// testExporter returns the package's one exporter, since its gauges are
+// registered with the default registry; callers reset it with UpdateMetrics
+func testExporter() *PrometheusExporter {
+	exporterOnce.Do(func() {
+		sharedExporter = NewPrometheusExporter(Config{Namespace: DefaultNamespace})
+	})
+	return sharedExporter
+}
+
+// syntheticResults builds collections results of testsPerCollection timed
+// tests each, spread over ten directories. Each collection exports two series
+// per test besides its collection series, so 1000 collections of 20 tests
+// export over 40,000 series.
+func syntheticResults(collections, testsPerCollection int) *storage.LatestResults {
+	startedAt := time.Now().Add(-time.Minute)
+	groups := make([]storage.EnvironmentGroup, 10)
+	for i := range groups {
+		groups[i].Directory = fmt.Sprintf("dir%d", i)
+	}
+	for id := 1; id <= collections; id++ {
+		group := &groups[id%len(groups)]
+		cr := storage.CollectionResult{
+			Collection: storage.Collection{
+				ID:              id,
+				Name:            fmt.Sprintf("collection%d", id),
+				DirectoryName:   group.Directory,
+				EnvironmentName: "prod",
+			},
+			Execution: &storage.TestExecution{
+				ID:          id,
+				StartedAt:   startedAt,
+				DurationMs:  1200,
+				TotalTests:  testsPerCollection,
+				PassedTests: testsPerCollection,
+			},
+		}
+		for t := 0; t < testsPerCollection; t++ {
+			url := fmt.Sprintf("https://api.example.com/items/%d", t)
+			method := "GET"
+			responseTime := 50 + t
+			cr.Results = append(cr.Results, storage.TestResult{
+				TestName:       fmt.Sprintf("test %d", t),
+				URL:            &url,
+				Method:         &method,
+				Passed:         true,
+				ResponseTimeMs: &responseTime,
+			})
+		}
+		group.Collections = append(group.Collections, cr)
+	}
+	return &storage.LatestResults{EnvironmentGroups: groups}
+}
+
+// rebuildMetrics drops every series and exports them all again, the way
+// metrics were refreshed before collections were updated incrementally
+func rebuildMetrics(e *PrometheusExporter, results *storage.LatestResults) {
+	e.mu.Lock()
+	for id, state := range e.collections {
+		deleteSeries(state.series, nil)
+		delete(e.collections, id)
+	}
+	e.mu.Unlock()
+	e.UpdateMetrics(results)
+}
+
+func benchmarkUpdateMetrics(b *testing.B, collections int, update func(e *PrometheusExporter, results *storage.LatestResults)) {
+	e := testExporter()
+	results := syntheticResults(collections, 20)
+	e.UpdateMetrics(results)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		update(e, results)
+	}
+	b.StopTimer()
+	e.UpdateMetrics(nil)
+}
+
+func BenchmarkUpdateMetricsRebuild(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("collections=%d", n), func(b *testing.B) {
+			benchmarkUpdateMetrics(b, n, rebuildMetrics)
+		})
+	}
+}
+
+func BenchmarkUpdateMetricsReconcile(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("collections=%d", n), func(b *testing.B) {
+			benchmarkUpdateMetrics(b, n, (*PrometheusExporter).UpdateMetrics)
+		})
+	}
+}
+
+func BenchmarkUpdateMetricsCollection(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("collections=%d", n), func(b *testing.B) {
+			benchmarkUpdateMetrics(b, n, func(e *PrometheusExporter, results *storage.LatestResults) {
+				e.UpdateCollection(results.EnvironmentGroups[0].Collections[0])
+			})
+		})
+	}
+}
+
+func TestUpdateMetricsSameEnvironmentInDifferentDirectories(t *testing.T) {
+	e := testExporter()
+	t.Cleanup(func() { e.UpdateMetrics(nil) })
+
+	startedAt := time.Now()
+	result := func(id int, directory string, durationMs int) storage.CollectionResult {
+		return storage.CollectionResult{
+			Collection: storage.Collection{ID: id, Name: "smoke", DirectoryName: directory, EnvironmentName: "prod"},
+			Execution:  &storage.TestExecution{ID: id, StartedAt: startedAt, DurationMs: durationMs, TotalTests: 1, PassedTests: 1},
+		}
+	}
+	e.UpdateMetrics(&storage.LatestResults{EnvironmentGroups: []storage.EnvironmentGroup{
+		{Directory: "orders", Collections: []storage.CollectionResult{result(1, "orders", 100)}},
+		{Directory: "payments", Collections: []storage.CollectionResult{result(2, "payments", 200)}},
+	}})
+
+	ch := make(chan prometheus.Metric, 10)
+	e.collectionDuration.Collect(ch)
+	close(ch)
+	if n := len(ch); n != 2 {
+		t.Errorf("got %d duration series, want one per directory", n)
+	}
+
+	snapshot := e.Snapshot()
+	if len(snapshot.Collections) != 2 {
+		t.Fatalf("snapshot has %d collections, want 2", len(snapshot.Collections))
+	}
+	for i, want := range []struct {
+		directory  string
+		durationMs int
+	}{{"orders", 100}, {"payments", 200}} {
+		cs := snapshot.Collections[i]
+		if cs.Directory != want.directory || cs.Environment != "prod" || cs.DurationMs != want.durationMs {
+			t.Errorf("snapshot collection %d = %s/%s %dms, want %s/prod %dms", i, cs.Directory, cs.Environment, cs.DurationMs, want.directory, want.durationMs)
+		}
+	}
+}
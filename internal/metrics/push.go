@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// PushConfig controls pushing metrics to a Prometheus Pushgateway, for
+// short-lived instances Prometheus can't scrape in time
+type PushConfig struct {
+	// URL of the Pushgateway; empty disables pushing
+	URL string
+	// Job and Instance group the pushed metrics on the Pushgateway
+	Job      string
+	Instance string
+	// DeleteOnShutdown removes the group when Scout shuts down cleanly
+	DeleteOnShutdown bool
+}
+
+// DefaultPushJob is the Pushgateway job label used when none is configured
+const DefaultPushJob = "scout"
+
+// pushTimeout bounds a single push to or delete from the Pushgateway
+const pushTimeout = 10 * time.Second
+
+// pusher pushes the default registry's metrics to a Pushgateway
+type pusher struct {
+	config PushConfig
+	pusher *push.Pusher
+	mu     sync.Mutex // Serializes pushes so an older update can't land last
+}
+
+// newPusher returns a pusher for config, or nil when pushing is disabled
+func newPusher(config PushConfig) *pusher {
+	if config.URL == "" {
+		return nil
+	}
+	log.Printf("Pushing metrics to Pushgateway %s as job %q, instance %q", config.URL, config.Job, config.Instance)
+	return &pusher{
+		config: config,
+		pusher: push.New(config.URL, config.Job).
+			Gatherer(prometheus.DefaultGatherer).
+			Grouping("instance", config.Instance).
+			Client(&http.Client{Timeout: pushTimeout}),
+	}
+}
+
+// push replaces the group's metrics with the current ones. Failures are
+// logged, since the next update pushes again.
+func (p *pusher) push() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.pusher.Push(); err != nil {
+		log.Printf("Error pushing metrics to Pushgateway %s: %v", p.config.URL, err)
+	}
+}
+
+// delete removes the group from the Pushgateway
+func (p *pusher) delete() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.pusher.Delete(); err != nil {
+		log.Printf("Error deleting metrics from Pushgateway %s: %v", p.config.URL, err)
+		return
+	}
+	log.Printf("Deleted metrics from Pushgateway %s", p.config.URL)
+}
+
+// Push sends the current metrics to the Pushgateway, when one is configured
+func (e *PrometheusExporter) Push() {
+	if e.pusher != nil {
+		e.pusher.push()
+	}
+}
+
+// Close deletes the exporter's metrics from the Pushgateway when pushing
+// with DeleteOnShutdown, and otherwise pushes them a final time so they
+// outlive the process
+func (e *PrometheusExporter) Close() {
+	if e.pusher == nil {
+		return
+	}
+	if e.pusher.config.DeleteOnShutdown {
+		e.pusher.delete()
+		return
+	}
+	e.pusher.push()
+}
@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// Snapshot is the per-collection data behind the Prometheus metrics, for
+// monitoring pipelines that pull JSON rather than scrape
+type Snapshot struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Collections []CollectionSnapshot `json:"collections"`
+}
+
+// CollectionSnapshot is one collection's latest run. Run fields are empty for
+// collections that have never run.
+type CollectionSnapshot struct {
+	Collection  string `json:"collection"`
+	Directory   string `json:"directory"`
+	Environment string `json:"environment"`
+	Status      string `json:"status"`
+	// LastRun is when the latest run started; Succeeded is set when all of
+	// its tests passed
+	LastRun                 *time.Time `json:"last_run,omitempty"`
+	Succeeded               bool       `json:"succeeded"`
+	LastSuccess             *time.Time `json:"last_success,omitempty"`
+	SecondsSinceLastRun     *float64   `json:"seconds_since_last_run,omitempty"`
+	SecondsSinceLastSuccess *float64   `json:"seconds_since_last_success,omitempty"`
+	DurationMs              int        `json:"duration_ms"`
+	Tests                   TestCounts `json:"tests"`
+	ErrorCategory           *string    `json:"error_category,omitempty"`
+	Warning                 bool       `json:"warning"`
+	NoTests                 bool       `json:"no_tests"`
+	GraphQLErrors           int        `json:"graphql_errors"`
+	SnapshotChanges         int        `json:"snapshot_changes"`
+	P95Exceeded             bool       `json:"p95_exceeded"`
+	// Regression is nil when there isn't enough history to compare against
+	Regression       *bool        `json:"regression,omitempty"`
+	RequestsTotal    *int         `json:"requests_total,omitempty"`
+	TransferredBytes *int64       `json:"transferred_bytes,omitempty"`
+	TestedVersion    *string      `json:"tested_version,omitempty"`
+	ResponseTimeMs   *Percentiles `json:"response_time_ms,omitempty"`
+	// RequestPhasesMs is absent when Newman measured no request phases
+	RequestPhasesMs *RequestPhases `json:"request_phases_ms,omitempty"`
+	Results         []TestResult   `json:"results"`
+}
+
+// TestCounts counts a run's tests
+type TestCounts struct {
+	Total  int `json:"total"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+// Percentiles summarizes a run's response times, nearest-rank
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// RequestPhases is the mean of each request timing phase across the requests
+// of a run that went through it; a phase no request went through is nil
+type RequestPhases struct {
+	DNS       *float64 `json:"dns,omitempty"`
+	Connect   *float64 `json:"connect,omitempty"`
+	TLS       *float64 `json:"tls,omitempty"`
+	FirstByte *float64 `json:"first_byte,omitempty"`
+	Download  *float64 `json:"download,omitempty"`
+}
+
+// phaseMean accumulates one request timing phase for RequestPhases
+type phaseMean struct {
+	sum   float64
+	count int
+}
+
+// add counts a request's phase, if it went through it
+func (m *phaseMean) add(ms *float64) {
+	if ms != nil {
+		m.sum += *ms
+		m.count++
+	}
+}
+
+// mean returns the phase's mean, or nil when no request went through it
+func (m phaseMean) mean() *float64 {
+	if m.count == 0 {
+		return nil
+	}
+	mean := m.sum / float64(m.count)
+	return &mean
+}
+
+// TestResult is one test of a collection's latest run
+type TestResult struct {
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	Method         string `json:"method"`
+	Passed         bool   `json:"passed"`
+	ResponseTimeMs *int   `json:"response_time_ms,omitempty"`
+	// ExpectedFailure marks a test expected to fail, which passes when it fails
+	ExpectedFailure bool `json:"expected_failure,omitempty"`
+	// PassRate is the fraction of the collection's recent executions in
+	// which the test passed
+	PassRate *float64 `json:"pass_rate,omitempty"`
+}
+
+// newCollectionSnapshot computes one collection's metrics data
+func newCollectionSnapshot(cr storage.CollectionResult, now time.Time) CollectionSnapshot {
+	cs := CollectionSnapshot{
+		Collection: cr.Collection.Name,
+		// Directory and environment keep same-named collections and
+		// environments in different directories apart
+		Directory:   cr.Collection.DirectoryName,
+		Environment: cr.Collection.EnvironmentName,
+		Status:      cr.Execution.Status(),
+		Results:     []TestResult{},
+	}
+
+	exec := cr.Execution
+	if exec == nil {
+		return cs
+	}
+
+	startedAt := exec.StartedAt
+	sinceLastRun := now.Sub(startedAt).Seconds()
+	cs.LastRun = &startedAt
+	cs.SecondsSinceLastRun = &sinceLastRun
+	cs.Succeeded = exec.FailedTests == 0 && exec.TotalTests > 0
+
+	// Collections that have never succeeded have no last success, so
+	// staleness alerts don't fire on brand-new collections
+	if cr.LastSuccessExecution != nil {
+		lastSuccess := cr.LastSuccessExecution.StartedAt
+		sinceLastSuccess := now.Sub(lastSuccess).Seconds()
+		cs.LastSuccess = &lastSuccess
+		cs.SecondsSinceLastSuccess = &sinceLastSuccess
+	}
+
+	cs.DurationMs = exec.DurationMs
+	cs.Tests = TestCounts{Total: exec.TotalTests, Passed: exec.PassedTests, Failed: exec.FailedTests}
+	cs.ErrorCategory = exec.ErrorCategory
+	cs.Warning = exec.Warning
+	cs.NoTests = cs.Status == storage.StatusNoTests
+	cs.RequestsTotal = exec.RequestsTotal
+	cs.TransferredBytes = exec.TransferredBytes
+	cs.TestedVersion = exec.TestedVersion
+	cs.P95Exceeded = cr.LatencyAlert != nil
+	if cr.DurationTrend != nil {
+		regression := cr.DurationTrend.Regression
+		cs.Regression = &regression
+	}
+
+	var responseTimes []float64
+	changedRequests := make(map[string]bool)
+	timedRequests := 0
+	var dns, connect, tls, firstByte, download phaseMean
+	for _, result := range cr.Results {
+		// The truncation summary row is not a real test
+		if result.Status == storage.ResultStatusTruncated {
+			continue
+		}
+		if result.TestName == storage.GraphQLTestName && !result.Passed {
+			cs.GraphQLErrors++
+		}
+
+		test := TestResult{
+			Name:            result.TestName,
+			Passed:          result.Passed,
+			ResponseTimeMs:  result.ResponseTimeMs,
+			ExpectedFailure: result.ExpectedFailure,
+		}
+		if result.URL != nil {
+			test.URL = *result.URL
+		}
+		if result.Method != nil {
+			test.Method = *result.Method
+		}
+		if rate, ok := cr.AssertionPassRates[result.TestName]; ok {
+			test.PassRate = &rate
+		}
+		cs.Results = append(cs.Results, test)
+
+		if result.ResponseTimeMs != nil {
+			responseTimes = append(responseTimes, float64(*result.ResponseTimeMs))
+		}
+	}
+	for _, request := range cr.Requests {
+		if request.Snapshot != nil && request.Snapshot.Changed {
+			changedRequests[request.Name] = true
+		}
+		if request.Timings != nil {
+			timedRequests++
+			dns.add(request.Timings.DNSMs)
+			connect.add(request.Timings.ConnectMs)
+			tls.add(request.Timings.TLSMs)
+			firstByte.add(request.Timings.FirstByteMs)
+			download.add(request.Timings.DownloadMs)
+		}
+	}
+	cs.SnapshotChanges = len(changedRequests)
+	if timedRequests > 0 {
+		cs.RequestPhasesMs = &RequestPhases{
+			DNS:       dns.mean(),
+			Connect:   connect.mean(),
+			TLS:       tls.mean(),
+			FirstByte: firstByte.mean(),
+			Download:  download.mean(),
+		}
+	}
+
+	// Collections with no timed tests get no percentiles
+	if len(responseTimes) > 0 {
+		sort.Float64s(responseTimes)
+		cs.ResponseTimeMs = &Percentiles{
+			P50: percentile(responseTimes, 50),
+			P95: percentile(responseTimes, 95),
+			P99: percentile(responseTimes, 99),
+		}
+	}
+	return cs
+}
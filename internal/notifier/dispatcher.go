@@ -0,0 +1,222 @@
+package notifier
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// sendTimeout bounds how long a single notifier may take
+const sendTimeout = 10 * time.Second
+
+// Dispatcher decides when a collection's executions warrant a notification
+// and fans notifications out to every configured notifier
+type Dispatcher struct {
+	storage   *storage.Storage
+	notifiers []Notifier
+	grace     time.Duration
+	warnings  bool
+	noTests   bool
+	mode      NotifyMode
+	throttle  time.Duration
+
+	mu          sync.Mutex
+	lastFailing map[int]time.Time // When each failing collection last notified, by ID
+}
+
+// NewDispatcher creates a dispatcher for the given notifiers
+func NewDispatcher(store *storage.Storage, notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		storage:     store,
+		notifiers:   notifiers,
+		mode:        NotifyTransitions,
+		lastFailing: make(map[int]time.Time),
+	}
+}
+
+// SetGracePeriod sets how long after a collection is first seen its failures
+// are recorded without notifying, so collections still being authored don't page
+func (d *Dispatcher) SetGracePeriod(grace time.Duration) {
+	d.grace = grace
+}
+
+// SetNotifyWarnings sets whether passing runs that exceed their warn threshold
+// notify; warnings are muted by default since they aren't actionable failures
+func (d *Dispatcher) SetNotifyWarnings(enabled bool) {
+	d.warnings = enabled
+}
+
+// SetNotifyNoTests sets whether runs that made no assertions notify, catching
+// collections monitored without test scripts
+func (d *Dispatcher) SetNotifyNoTests(enabled bool) {
+	d.noTests = enabled
+}
+
+// InGracePeriod reports whether a collection was first seen within the grace
+// period before t
+func (d *Dispatcher) InGracePeriod(collection *storage.Collection, t time.Time) bool {
+	return collection.InGracePeriod(d.grace, t)
+}
+
+// Enabled reports whether any notifiers are configured
+func (d *Dispatcher) Enabled() bool {
+	return len(d.notifiers) > 0
+}
+
+// EnabledFor reports whether a collection's notifications go anywhere, either
+// to the configured notifiers or to its owner rules' own destinations
+func (d *Dispatcher) EnabledFor(collection *storage.Collection) bool {
+	return d.Enabled() || collection.Owners.HasDestinations()
+}
+
+// HandleExecution notifies on pass->fail and fail->pass transitions, and on
+// pass->warning when warnings are enabled, and on a first run without
+// assertions when no-tests notifications are enabled. Depending on the notify
+// mode, later runs of a failing collection notify again as repeats.
+// Failures and recoveries are routed to the owners of the affected tests.
+// Failures acknowledged by on-call are recorded but not notified. Runs in a new
+// collection's grace period never notify; a failure that outlasts the grace
+// period notifies on the first run after it ends.
+func (d *Dispatcher) HandleExecution(collection *storage.Collection, previous, current *storage.TestExecution) {
+	if !d.EnabledFor(collection) || current.Overridden {
+		return
+	}
+
+	if collection.InGracePeriod(d.grace, current.StartedAt) {
+		if current.Status() == storage.StatusFailing {
+			log.Printf("Collection %s is in its %v grace period, not notifying", collection.CompositeKey, d.grace)
+		}
+		return
+	}
+
+	// A failure during the grace period was never notified, so treat it as passing
+	previousStatus := previous.Status()
+	if previous != nil && collection.InGracePeriod(d.grace, previous.StartedAt) && previousStatus == storage.StatusFailing {
+		previousStatus = storage.StatusNeverRun
+	}
+
+	now := time.Now()
+	status := current.Status()
+	if status != storage.StatusFailing {
+		d.recordFailing(collection.ID, time.Time{})
+	}
+
+	var event string
+	repeat := false
+	switch {
+	case status == storage.StatusFailing && previousStatus != storage.StatusFailing:
+		event = EventFailing
+	case status == storage.StatusFailing && d.repeatFailure(collection.ID, now):
+		event = EventFailing
+		repeat = true
+	// A recovery takes precedence, so a failure that resolves into a run
+	// without assertions still closes the incident
+	case status != storage.StatusFailing && previousStatus == storage.StatusFailing:
+		event = EventRecovered
+	case status == storage.StatusNoTests && previousStatus != storage.StatusNoTests && d.noTests:
+		event = EventNoTests
+	case status == storage.StatusWarning && previousStatus != storage.StatusWarning && d.warnings:
+		event = EventWarning
+	default:
+		return
+	}
+
+	if event == EventFailing {
+		ack, err := d.storage.GetAck(collection.ID)
+		if err != nil {
+			log.Printf("Error checking acknowledgment for %s: %v", collection.CompositeKey, err)
+		} else if ack != nil {
+			log.Printf("Failure of %s is acknowledged by %s, not notifying", collection.CompositeKey, ack.User)
+			return
+		}
+	}
+
+	// Failures are routed by the tests failing now, recoveries by the tests
+	// that were failing before
+	var routed *storage.TestExecution
+	switch event {
+	case EventFailing:
+		routed = current
+		d.recordFailing(collection.ID, now)
+	case EventRecovered:
+		routed = previous
+	}
+
+	d.sendRouted(collection, routed, Notification{
+		Event:          event,
+		CompositeKey:   collection.CompositeKey,
+		CollectionName: collection.CollectionName,
+		Directory:      collection.DirectoryName,
+		Environment:    collection.EnvironmentName,
+		ExecutionID:    current.ID,
+		TotalTests:     current.TotalTests,
+		FailedTests:    current.FailedTests,
+		Error:          current.Error,
+		ErrorCategory:  current.ErrorCategory,
+		Annotations:    collection.Annotations,
+		Repeat:         repeat,
+		Timestamp:      current.StartedAt,
+	})
+}
+
+// HandleLatency notifies that a collection's rolling p95 response time went
+// over its threshold, or is back under it when recovered. The alert concerns
+// the whole collection, so it goes to every owner with a destination of its own.
+func (d *Dispatcher) HandleLatency(collection *storage.Collection, execution *storage.TestExecution, p95Ms, thresholdMs int, recovered bool) {
+	if !d.EnabledFor(collection) {
+		return
+	}
+
+	event := EventLatencyExceeded
+	if recovered {
+		event = EventLatencyRecovered
+	}
+	d.sendToOwners(collection, Notification{
+		Event:          event,
+		CompositeKey:   collection.CompositeKey,
+		CollectionName: collection.CollectionName,
+		Directory:      collection.DirectoryName,
+		Environment:    collection.EnvironmentName,
+		ExecutionID:    execution.ID,
+		TotalTests:     execution.TotalTests,
+		FailedTests:    execution.FailedTests,
+		Annotations:    collection.Annotations,
+		P95Ms:          p95Ms,
+		ThresholdMs:    thresholdMs,
+		Timestamp:      execution.StartedAt,
+	})
+}
+
+// Result is the outcome of sending a notification through one notifier
+type Result struct {
+	Notifier string  `json:"notifier"`
+	Success  bool    `json:"success"`
+	Error    *string `json:"error,omitempty"`
+}
+
+// Send delivers a notification through every notifier and reports each outcome
+func (d *Dispatcher) Send(n Notification) []Result {
+	return d.sendTo(d.notifiers, n)
+}
+
+// sendTo delivers a notification through the given notifiers
+func (d *Dispatcher) sendTo(notifiers []Notifier, n Notification) []Result {
+	results := make([]Result, 0, len(notifiers))
+	for _, notifier := range notifiers {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err := notifier.Notify(ctx, n)
+		cancel()
+
+		result := Result{Notifier: notifier.Name(), Success: err == nil}
+		if err != nil {
+			message := err.Error()
+			result.Error = &message
+			log.Printf("Error sending %s notification for %s via %s: %v", n.Event, n.CompositeKey, notifier.Name(), err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// recordingNotifier keeps every notification it is sent
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (r *recordingNotifier) Name() string { return "recording" }
+
+func (r *recordingNotifier) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func (r *recordingNotifier) events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []string
+	for _, n := range r.sent {
+		events = append(events, n.Event)
+	}
+	return events
+}
+
+func TestHandleExecutionTransitions(t *testing.T) {
+	errMsg := "connection refused"
+	failing := &storage.TestExecution{ID: 1, TotalTests: 2, PassedTests: 1, FailedTests: 1}
+	erroring := &storage.TestExecution{ID: 1, Error: &errMsg}
+	passing := &storage.TestExecution{ID: 2, TotalTests: 2, PassedTests: 2}
+	noTests := &storage.TestExecution{ID: 2}
+
+	tests := []struct {
+		name     string
+		previous *storage.TestExecution
+		current  *storage.TestExecution
+		want     string
+	}{
+		{name: "failing to passing", previous: failing, current: passing, want: EventRecovered},
+		{name: "failing to no tests", previous: failing, current: noTests, want: EventRecovered},
+		{name: "erroring to no tests", previous: erroring, current: noTests, want: EventRecovered},
+		{name: "passing to no tests", previous: passing, current: noTests, want: EventNoTests},
+		{name: "first run without tests", current: noTests, want: EventNoTests},
+		{name: "no tests again", previous: noTests, current: noTests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &recordingNotifier{}
+			d := NewDispatcher(nil, recorder)
+			d.SetNotifyNoTests(true)
+
+			current := *tt.current
+			current.StartedAt = time.Now()
+			d.HandleExecution(&storage.Collection{ID: 1, CompositeKey: "shop_env_orders"}, tt.previous, &current)
+
+			got := recorder.events()
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Errorf("sent %q, want nothing", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("sent %q, want [%q]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleLatencyReachesOwnerDestinations(t *testing.T) {
+	var mu sync.Mutex
+	var received []Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	collection := &storage.Collection{
+		ID:           1,
+		CompositeKey: "shop_env_orders",
+		Owners: storage.OwnerRules{
+			{Pattern: "checkout*", Owner: "payments", WebhookURL: server.URL},
+			{Pattern: "refund*", Owner: "payments", WebhookURL: server.URL},
+			{Pattern: "*", Owner: "catalog"},
+		},
+	}
+	execution := &storage.TestExecution{ID: 7, StartedAt: time.Now()}
+
+	// No global notifiers: only the owner destination is configured
+	d := NewDispatcher(nil)
+	d.HandleLatency(collection, execution, 900, 500, false)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("owner webhook received %d notification(s), want 1", len(received))
+	}
+	if got := received[0]; got.Event != EventLatencyExceeded || got.Owner != "payments" || got.P95Ms != 900 {
+		t.Errorf("owner webhook received %+v, want a p95_exceeded for payments at 900ms", got)
+	}
+}
@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+)
+
+// NotifyMode decides which runs of a failing collection notify
+type NotifyMode string
+
+// Notify modes, from quietest to noisiest
+const (
+	// NotifyTransitions notifies when a collection starts failing and when
+	// it recovers
+	NotifyTransitions NotifyMode = "transitions"
+	// NotifyThrottled also repeats the failure at most once per throttle
+	// window while the collection keeps failing
+	NotifyThrottled NotifyMode = "throttled"
+	// NotifyEvery notifies on every failing run
+	NotifyEvery NotifyMode = "every"
+)
+
+// ParseNotifyMode validates a notify mode; empty means transitions
+func ParseNotifyMode(mode string) (NotifyMode, error) {
+	switch NotifyMode(mode) {
+	case "":
+		return NotifyTransitions, nil
+	case NotifyTransitions, NotifyThrottled, NotifyEvery:
+		return NotifyMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown notify mode %q (expected transitions, throttled or every)", mode)
+	}
+}
+
+// SetNotifyMode sets which runs of a failing collection notify; throttle is
+// the window of NotifyThrottled
+func (d *Dispatcher) SetNotifyMode(mode NotifyMode, throttle time.Duration) {
+	d.mode = mode
+	d.throttle = throttle
+}
+
+// repeatFailure reports whether a collection that was already failing
+// notifies again, given when it last notified
+func (d *Dispatcher) repeatFailure(collectionID int, now time.Time) bool {
+	switch d.mode {
+	case NotifyEvery:
+		return true
+	case NotifyThrottled:
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		last, ok := d.lastFailing[collectionID]
+		return !ok || now.Sub(last) >= d.throttle
+	default:
+		return false
+	}
+}
+
+// recordFailing records when a collection last notified that it was
+// failing; a zero time clears it once the collection stops failing
+func (d *Dispatcher) recordFailing(collectionID int, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if at.IsZero() {
+		delete(d.lastFailing, collectionID)
+		return
+	}
+	d.lastFailing[collectionID] = at
+}
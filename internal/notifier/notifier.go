@@ -0,0 +1,129 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Notification events
+const (
+	EventFailing   = "failing"
+	EventRecovered = "recovered"
+	EventWarning   = "warning"
+	EventNoTests   = "no_tests"
+	EventTest      = "test"
+
+	// Latency events fire when a collection's rolling p95 response time goes
+	// over its threshold and when it's back under
+	EventLatencyExceeded  = "p95_exceeded"
+	EventLatencyRecovered = "p95_recovered"
+
+	// Scheduler events concern Scout itself rather than a collection
+	EventSchedulerStalled = "scheduler_stalled"
+	EventSchedulerResumed = "scheduler_resumed"
+)
+
+// Notification describes a change in a collection's status
+type Notification struct {
+	Event          string            `json:"event"`
+	CompositeKey   string            `json:"composite_key"`
+	CollectionName string            `json:"collection_name"`
+	Directory      string            `json:"directory"`
+	Environment    string            `json:"environment"`
+	ExecutionID    int               `json:"execution_id,omitempty"`
+	TotalTests     int               `json:"total_tests"`
+	FailedTests    int               `json:"failed_tests"`
+	Error          *string           `json:"error,omitempty"`
+	ErrorCategory  *string           `json:"error_category,omitempty"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	// Repeat marks a failing notification of a collection that was already
+	// failing, sent by the throttled and every notify modes
+	Repeat bool `json:"repeat,omitempty"`
+	// P95Ms and ThresholdMs are set on latency events
+	P95Ms       int `json:"p95_ms,omitempty"`
+	ThresholdMs int `json:"threshold_ms,omitempty"`
+	// Owner and Tests are set when the collection has owner rules: the owner
+	// this notification is routed to and their failing (or recovered) tests
+	Owner     string    `json:"owner,omitempty"`
+	Tests     []string  `json:"tests,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Text renders the notification as a single human-readable message
+func (n Notification) Text() string {
+	var b strings.Builder
+	switch n.Event {
+	case EventFailing:
+		state := "is failing"
+		if n.Repeat {
+			state = "is still failing"
+		}
+		fmt.Fprintf(&b, "Scout: %s %s (%d of %d tests failed)", n.CompositeKey, state, n.FailedTests, n.TotalTests)
+	case EventRecovered:
+		fmt.Fprintf(&b, "Scout: %s has recovered (%d tests passing)", n.CompositeKey, n.TotalTests)
+	case EventWarning:
+		fmt.Fprintf(&b, "Scout: %s is passing but slow (a response exceeded its warn threshold)", n.CompositeKey)
+	case EventLatencyExceeded:
+		fmt.Fprintf(&b, "Scout: %s is slow (p95 response time %dms, over its %dms threshold)", n.CompositeKey, n.P95Ms, n.ThresholdMs)
+	case EventLatencyRecovered:
+		fmt.Fprintf(&b, "Scout: %s is no longer slow (p95 response time %dms, within its %dms threshold)", n.CompositeKey, n.P95Ms, n.ThresholdMs)
+	case EventNoTests:
+		fmt.Fprintf(&b, "Scout: %s ran but made no assertions (check that its requests have test scripts)", n.CompositeKey)
+	case EventSchedulerStalled:
+		fmt.Fprintf(&b, "Scout: the scheduler has stalled, no execution cycle has completed since %s", n.Timestamp.Format(time.RFC3339))
+	case EventSchedulerResumed:
+		b.WriteString("Scout: the scheduler has resumed, an execution cycle completed")
+	case EventTest:
+		b.WriteString("Scout: this is a test notification")
+	default:
+		fmt.Fprintf(&b, "Scout: %s %s", n.CompositeKey, n.Event)
+	}
+	if n.ErrorCategory != nil {
+		fmt.Fprintf(&b, " [%s]", *n.ErrorCategory)
+	}
+	if n.Error != nil {
+		fmt.Fprintf(&b, ": %s", *n.Error)
+	}
+
+	if n.Owner != "" {
+		fmt.Fprintf(&b, "\nOwner: %s", n.Owner)
+	}
+	if len(n.Tests) > 0 {
+		label := "Failing tests"
+		if n.Event == EventRecovered {
+			label = "Recovered tests"
+		}
+		fmt.Fprintf(&b, "\n%s: %s", label, summarizeTests(n.Tests))
+	}
+
+	// Annotations such as runbook links go on their own lines, in key order
+	keys := make([]string, 0, len(n.Annotations))
+	for key := range n.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n%s: %s", key, n.Annotations[key])
+	}
+	return b.String()
+}
+
+// maxListedTests bounds how many test names a message lists
+const maxListedTests = 10
+
+// summarizeTests joins test names, eliding those beyond maxListedTests
+func summarizeTests(tests []string) string {
+	if len(tests) <= maxListedTests {
+		return strings.Join(tests, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(tests[:maxListedTests], ", "), len(tests)-maxListedTests)
+}
+
+// Notifier delivers notifications to an external destination
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n Notification) error
+}
@@ -0,0 +1,127 @@
+package notifier
+
+import (
+	"log"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// ownerAnnotation is the collection annotation naming the team that owns
+// tests no owner rule matches
+const ownerAnnotation = "owner"
+
+// route is a set of failing tests sent to one owner through one destination
+type route struct {
+	owner string
+	rule  *storage.OwnerRule
+	tests []string
+}
+
+// routeFailures groups an execution's failing tests by the owner rule that
+// matches them, in rule order. Tests no rule matches, or a run that failed
+// without a failing test, go to the collection's owner annotation.
+func routeFailures(collection *storage.Collection, results []storage.TestResult) []*route {
+	var routes []*route
+	byKey := make(map[string]*route)
+	fallback := &route{owner: collection.Annotations[ownerAnnotation]}
+
+	for _, result := range results {
+		if result.Passed || result.Status == storage.ResultStatusTruncated {
+			continue
+		}
+		rule := collection.Owners.Match(result.TestName)
+		if rule == nil {
+			fallback.tests = append(fallback.tests, result.TestName)
+			continue
+		}
+
+		// Rules naming the same owner and destination share a notification
+		key := rule.Owner + "\x00" + rule.SlackWebhookURL + "\x00" + rule.WebhookURL
+		r, ok := byKey[key]
+		if !ok {
+			r = &route{owner: rule.Owner, rule: rule}
+			byKey[key] = r
+			routes = append(routes, r)
+		}
+		r.tests = append(r.tests, result.TestName)
+	}
+
+	if len(fallback.tests) > 0 || len(routes) == 0 {
+		routes = append(routes, fallback)
+	}
+	return routes
+}
+
+// routeNotifiers returns where a route's notification is sent: the matching rule's
+// own destinations, or the globally configured notifiers
+func (d *Dispatcher) routeNotifiers(r *route) []Notifier {
+	if r.rule == nil || !r.rule.HasDestination() {
+		return d.notifiers
+	}
+	var notifiers []Notifier
+	if r.rule.SlackWebhookURL != "" {
+		notifiers = append(notifiers, NewSlackNotifier(r.rule.SlackWebhookURL))
+	}
+	if r.rule.WebhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(r.rule.WebhookURL))
+	}
+	return notifiers
+}
+
+// routeOwners returns a route to each owner rule with a destination of its
+// own, one per distinct owner and destination, in rule order
+func routeOwners(collection *storage.Collection) []*route {
+	var routes []*route
+	seen := make(map[string]bool)
+	for i := range collection.Owners {
+		rule := &collection.Owners[i]
+		key := rule.Owner + "\x00" + rule.SlackWebhookURL + "\x00" + rule.WebhookURL
+		if !rule.HasDestination() || seen[key] {
+			continue
+		}
+		seen[key] = true
+		routes = append(routes, &route{owner: rule.Owner, rule: rule})
+	}
+	return routes
+}
+
+// sendToOwners sends n, which concerns the whole collection rather than
+// particular tests, through the global notifiers and once to each owner
+// with a destination of its own
+func (d *Dispatcher) sendToOwners(collection *storage.Collection, n Notification) {
+	if d.Enabled() {
+		fallback := n
+		fallback.Owner = collection.Annotations[ownerAnnotation]
+		d.Send(fallback)
+	}
+	for _, r := range routeOwners(collection) {
+		routed := n
+		routed.Owner = r.owner
+		log.Printf("Routing %s notification for %s to owner %q", n.Event, collection.CompositeKey, r.owner)
+		d.sendTo(d.routeNotifiers(r), routed)
+	}
+}
+
+// sendRouted sends n once per owner of the failing tests in execution, or
+// once through the global notifiers when the collection has no owner rules
+func (d *Dispatcher) sendRouted(collection *storage.Collection, execution *storage.TestExecution, n Notification) {
+	if len(collection.Owners) == 0 || execution == nil {
+		d.Send(n)
+		return
+	}
+
+	results, err := d.storage.GetTestResultsByExecutionID(execution.ID, storage.ResultOrderSequence)
+	if err != nil {
+		log.Printf("Error fetching results to route notification for %s, sending to default notifiers: %v", collection.CompositeKey, err)
+		d.Send(n)
+		return
+	}
+
+	for _, r := range routeFailures(collection, results) {
+		routed := n
+		routed.Owner = r.owner
+		routed.Tests = r.tests
+		log.Printf("Routing %s notification for %s to owner %q (%d test(s))", n.Event, collection.CompositeKey, r.owner, len(r.tests))
+		d.sendTo(d.routeNotifiers(r), routed)
+	}
+}
@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier posts notifications as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier that posts the raw notification as JSON
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{}}
+}
+
+// Name implements Notifier
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify implements Notifier
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	return postJSON(ctx, w.client, w.url, n)
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier for a Slack incoming webhook URL
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{webhookURL: webhookURL, client: &http.Client{}}
+}
+
+// Name implements Notifier
+func (s *SlackNotifier) Name() string {
+	return "slack"
+}
+
+// Notify implements Notifier
+func (s *SlackNotifier) Notify(ctx context.Context, n Notification) error {
+	return postJSON(ctx, s.client, s.webhookURL, map[string]string{"text": n.Text()})
+}
+
+// postJSON posts body as JSON and treats any non-2xx response as an error
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+// maxCollectionSize bounds how much of a remote response is read
+const maxCollectionSize = 10 << 20
+
+// fetchQueueSize bounds how many refreshes can wait for a fetch worker
+const fetchQueueSize = 256
+
+// Fetcher downloads remote collections in the background and serves them from
+// an on-disk cache, so a slow or failing remote never blocks a scan
+type Fetcher struct {
+	cacheDir    string
+	ttl         time.Duration
+	timeout     time.Duration
+	concurrency int
+	client      *http.Client
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	requests    chan *SourceStatus
+	mu          sync.Mutex
+	sources     map[string]*SourceStatus
+}
+
+// Config contains fetcher configuration
+type Config struct {
+	CacheDir    string
+	TTL         time.Duration
+	Timeout     time.Duration
+	Concurrency int
+}
+
+// SourceStatus reports the fetch state of a single remote source
+type SourceStatus struct {
+	Directory     string     `json:"directory"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	LastFetchAt   *time.Time `json:"last_fetch_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	Fetching      bool       `json:"fetching"`
+	Cached        bool       `json:"cached"`
+	cachePath     string
+}
+
+// NewFetcher creates a new remote collection fetcher
+func NewFetcher(config Config) *Fetcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Fetcher{
+		cacheDir:    config.CacheDir,
+		ttl:         config.TTL,
+		timeout:     config.Timeout,
+		concurrency: concurrency,
+		client:      &http.Client{},
+		ctx:         ctx,
+		cancel:      cancel,
+		requests:    make(chan *SourceStatus, fetchQueueSize),
+		sources:     make(map[string]*SourceStatus),
+	}
+}
+
+// Start starts the fetch workers
+func (f *Fetcher) Start() {
+	log.Printf("Starting remote source fetcher (cache: %s, ttl: %v, timeout: %v, concurrency: %d)",
+		f.cacheDir, f.ttl, f.timeout, f.concurrency)
+
+	for i := 0; i < f.concurrency; i++ {
+		f.wg.Add(1)
+		go f.worker()
+	}
+}
+
+// CacheDir returns the directory cached copies of remote sources are stored in
+func (f *Fetcher) CacheDir() string {
+	return f.cacheDir
+}
+
+// Stop cancels in-flight fetches and waits for workers to exit
+func (f *Fetcher) Stop() {
+	f.cancel()
+	f.wg.Wait()
+}
+
+// Resolve implements watcher.SourceResolver. It returns the cached copy of a
+// source if one exists and queues a background refresh when the copy is stale.
+func (f *Fetcher) Resolve(directory string, source watcher.RemoteSource) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := directory + "/" + source.Name
+	status, ok := f.sources[key]
+	if !ok || status.URL != source.URL {
+		status = &SourceStatus{
+			Directory: directory,
+			Name:      source.Name,
+			URL:       source.URL,
+			cachePath: filepath.Join(f.cacheDir, directory, source.Name+".postman_collection.json"),
+		}
+		if ok {
+			// The URL changed, so the cached copy belongs to the old source
+			os.Remove(status.cachePath)
+		} else if info, err := os.Stat(status.cachePath); err == nil {
+			// A copy cached by a previous process counts as fetched at its mtime
+			modTime := info.ModTime()
+			status.LastFetchAt = &modTime
+			status.LastSuccessAt = &modTime
+			status.Cached = true
+		}
+		f.sources[key] = status
+	}
+
+	stale := status.LastFetchAt == nil || time.Since(*status.LastFetchAt) > f.ttl
+	if stale && !status.Fetching {
+		select {
+		case f.requests <- status:
+			status.Fetching = true
+		default:
+			log.Printf("Warning: fetch queue full, deferring refresh of %s", key)
+		}
+	}
+
+	return status.cachePath, status.Cached
+}
+
+// Statuses returns the fetch state of every known source, ordered by directory and name
+func (f *Fetcher) Statuses() []SourceStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	statuses := make([]SourceStatus, 0, len(f.sources))
+	for _, status := range f.sources {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(a, b int) bool {
+		if statuses[a].Directory != statuses[b].Directory {
+			return statuses[a].Directory < statuses[b].Directory
+		}
+		return statuses[a].Name < statuses[b].Name
+	})
+	return statuses
+}
+
+// worker refreshes queued sources until the fetcher is stopped
+func (f *Fetcher) worker() {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case status := <-f.requests:
+			f.refresh(status)
+		case <-f.ctx.Done():
+			return
+		}
+	}
+}
+
+// refresh downloads a source and records the outcome; on failure the last
+// good cached copy stays in use
+func (f *Fetcher) refresh(status *SourceStatus) {
+	f.mu.Lock()
+	url, cachePath := status.URL, status.cachePath
+	f.mu.Unlock()
+
+	err := f.download(url, cachePath)
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	status.Fetching = false
+	status.LastFetchAt = &now
+	if err != nil {
+		message := err.Error()
+		status.LastError = &message
+		if status.Cached {
+			log.Printf("Warning: failed to fetch %s/%s, using cached copy from %v: %v",
+				status.Directory, status.Name, status.LastSuccessAt.Format(time.RFC3339), err)
+		} else {
+			log.Printf("Warning: failed to fetch %s/%s, no cached copy available: %v", status.Directory, status.Name, err)
+		}
+		return
+	}
+
+	status.LastSuccessAt = &now
+	status.LastError = nil
+	status.Cached = true
+	log.Printf("Fetched remote source %s/%s", status.Directory, status.Name)
+}
+
+// download fetches url within the configured timeout and atomically replaces
+// the cached file, so a partial download never replaces a good copy
+func (f *Fetcher) download(url, cachePath string) error {
+	ctx, cancel := context.WithTimeout(f.ctx, f.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCollectionSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > maxCollectionSize {
+		return fmt.Errorf("collection exceeds %d bytes", maxCollectionSize)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("response is not valid JSON")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".fetch-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), cachePath)
+}
@@ -0,0 +1,69 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps reports as files below a directory
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store rooted at dir, creating it if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create report directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Name implements ReportStore
+func (f *FileStore) Name() string {
+	return "filesystem"
+}
+
+// Put implements ReportStore. The report is written to a temporary file and
+// renamed into place, so readers never see a partial report.
+func (f *FileStore) Put(_ context.Context, key string, data []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(f.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".report-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get implements ReportStore
+func (f *FileStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filepath.Join(f.dir, filepath.FromSlash(key)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
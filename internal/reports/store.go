@@ -0,0 +1,41 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned when a report does not exist in the store
+var ErrNotFound = errors.New("report not found")
+
+// ReportStore keeps raw execution reports outside the database. Keys are
+// slash-separated paths; each implementation maps them onto its own namespace.
+type ReportStore interface {
+	// Name identifies the store in logs and the version endpoint
+	Name() string
+	// Put stores a report, replacing any report with the same key
+	Put(ctx context.Context, key string, data []byte) error
+	// Get opens a stored report; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// Key returns the key an execution's report is stored under
+func Key(compositeKey string, executionID int) string {
+	return fmt.Sprintf("%s/%d.json", compositeKey, executionID)
+}
+
+// validKey rejects keys that could escape the store's namespace
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return fmt.Errorf("invalid report key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid report key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParseHostGroupLimits parses name=limit pairs setting the concurrency of
+// individual host groups
+func ParseHostGroupLimits(pairs []string) (map[string]int, error) {
+	limits := make(map[string]int, len(pairs))
+	for _, pair := range pairs {
+		name, raw, found := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid host group limit %q: expected name=limit", pair)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid host group limit %q: limit must be a positive integer", pair)
+		}
+		if _, ok := limits[name]; ok {
+			return nil, fmt.Errorf("duplicate host group limit %q", name)
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+// HostGroupStats reports a host group's usage for /api/stats
+type HostGroupStats struct {
+	Limit    int `json:"limit"`
+	InFlight int `json:"in_flight"`
+	Waiting  int `json:"waiting"`
+}
+
+// hostGroupLimiter is a keyed semaphore capping concurrent executions of
+// collections sharing a host group, across every directory
+type hostGroupLimiter struct {
+	defaultLimit int
+	limits       map[string]int
+	mu           sync.Mutex
+	slots        map[string]chan struct{}
+	waiting      map[string]int
+}
+
+// newHostGroupLimiter creates a limiter allowing defaultLimit concurrent
+// executions per host group, or the group's entry in limits
+func newHostGroupLimiter(defaultLimit int, limits map[string]int) *hostGroupLimiter {
+	return &hostGroupLimiter{
+		defaultLimit: max(defaultLimit, 1),
+		limits:       limits,
+		slots:        make(map[string]chan struct{}),
+		waiting:      make(map[string]int),
+	}
+}
+
+// slotsFor returns the semaphore of a host group, creating it on first use
+func (l *hostGroupLimiter) slotsFor(group string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slots, ok := l.slots[group]
+	if !ok {
+		limit, ok := l.limits[group]
+		if !ok {
+			limit = l.defaultLimit
+		}
+		slots = make(chan struct{}, limit)
+		l.slots[group] = slots
+	}
+	return slots
+}
+
+// acquire blocks until the host group has a free slot, returning a func
+// releasing it. Collections without a host group are never limited. It
+// returns false if ctx ends first.
+func (l *hostGroupLimiter) acquire(ctx context.Context, group string) (func(), bool) {
+	if l == nil || group == "" {
+		return func() {}, true
+	}
+	slots := l.slotsFor(group)
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+	}
+
+	l.mu.Lock()
+	l.waiting[group]++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.waiting[group]--
+		l.mu.Unlock()
+	}()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// stats reports each host group seen so far
+func (l *hostGroupLimiter) stats() map[string]HostGroupStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := make(map[string]HostGroupStats, len(l.slots))
+	for group, slots := range l.slots {
+		stats[group] = HostGroupStats{
+			Limit:    cap(slots),
+			InFlight: len(slots),
+			Waiting:  l.waiting[group],
+		}
+	}
+	return stats
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+)
+
+// KeyField is a component of a collection's composite key
+type KeyField string
+
+const (
+	// KeyFieldDirectory is the normalized collection directory name
+	KeyFieldDirectory KeyField = "directory"
+	// KeyFieldEnvironment is the environment name, or "env" without one
+	KeyFieldEnvironment KeyField = "environment"
+	// KeyFieldCollection is the collection file name without its extension
+	KeyFieldCollection KeyField = "collection"
+	// KeyFieldPathHash is a short hash of the case-preserved directory and file name
+	KeyFieldPathHash KeyField = "path_hash"
+)
+
+// pathHashLength is the number of hex characters of the path hash kept in keys
+const pathHashLength = 12
+
+// KeyStrategy lists the fields joined, in order, to form composite keys
+type KeyStrategy []KeyField
+
+// DefaultKeyStrategy produces the original {directory}_{environment}_{collection} keys
+var DefaultKeyStrategy = KeyStrategy{KeyFieldDirectory, KeyFieldEnvironment, KeyFieldCollection}
+
+// ParseKeyStrategy validates a list of key field names. An empty list yields
+// the default strategy. Every strategy must identify the collection file, by
+// name or by path hash, or keys would collide within a directory.
+func ParseKeyStrategy(fields []string) (KeyStrategy, error) {
+	if len(fields) == 0 {
+		return DefaultKeyStrategy, nil
+	}
+
+	strategy := make(KeyStrategy, 0, len(fields))
+	seen := make(map[KeyField]bool)
+	identifiesFile := false
+	for _, name := range fields {
+		field := KeyField(name)
+		switch field {
+		case KeyFieldDirectory, KeyFieldEnvironment:
+		case KeyFieldCollection, KeyFieldPathHash:
+			identifiesFile = true
+		default:
+			return nil, fmt.Errorf("unknown key field %q (expected directory, environment, collection or path_hash)", name)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("key field %q is listed more than once", name)
+		}
+		seen[field] = true
+		strategy = append(strategy, field)
+	}
+
+	if !identifiesFile {
+		return nil, fmt.Errorf("key fields must include collection or path_hash")
+	}
+	return strategy, nil
+}
+
+// pathHash hashes the collection's parent directory and file name as they
+// appear on disk, so files differing only in case get distinct keys. A data
+// file variant is hashed in too, leaving keys of plain collections unchanged.
+func pathHash(collectionPath, variant string) string {
+	path := filepath.Base(filepath.Dir(collectionPath)) + "/" + filepath.Base(collectionPath)
+	if variant != "" {
+		path += "@" + variant
+	}
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:])[:pathHashLength]
+}
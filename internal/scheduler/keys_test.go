@@ -0,0 +1,208 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+func TestParseKeyStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		want    KeyStrategy
+		wantErr string
+	}{
+		{name: "empty uses default", fields: nil, want: DefaultKeyStrategy},
+		{name: "default order", fields: []string{"directory", "environment", "collection"}, want: DefaultKeyStrategy},
+		{name: "custom order", fields: []string{"collection", "directory"}, want: KeyStrategy{KeyFieldCollection, KeyFieldDirectory}},
+		{name: "path hash only", fields: []string{"path_hash"}, want: KeyStrategy{KeyFieldPathHash}},
+		{name: "unknown field", fields: []string{"directory", "host", "collection"}, wantErr: `unknown key field "host"`},
+		{name: "duplicate field", fields: []string{"collection", "collection"}, wantErr: `"collection" is listed more than once`},
+		{name: "no file identity", fields: []string{"directory", "environment"}, wantErr: "must include collection or path_hash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyStrategy(tt.fields)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseKeyStrategy(%q) error = %v, want it to contain %q", tt.fields, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKeyStrategy(%q) error = %v", tt.fields, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeyStrategy(%q) = %v, want %v", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateCompositeKey(t *testing.T) {
+	prod := "Prod"
+	pathHashStrategy := KeyStrategy{KeyFieldDirectory, KeyFieldEnvironment, KeyFieldPathHash}
+
+	tests := []struct {
+		name           string
+		strategy       KeyStrategy
+		directory      string
+		environment    *string
+		collectionPath string
+		dataFile       string
+		wantKey        string
+		wantCollection string
+	}{
+		{
+			name:           "default strategy",
+			strategy:       DefaultKeyStrategy,
+			directory:      "Users",
+			environment:    &prod,
+			collectionPath: "/collections/Users/Smoke.postman_collection.json",
+			wantKey:        "users_prod_smoke",
+			wantCollection: "smoke",
+		},
+		{
+			name:           "no environment",
+			strategy:       DefaultKeyStrategy,
+			directory:      "users",
+			collectionPath: "/collections/users/smoke.postman_collection.json",
+			wantKey:        "users_env_smoke",
+			wantCollection: "smoke",
+		},
+		{
+			name:           "disabled file keeps its enabled key",
+			strategy:       DefaultKeyStrategy,
+			directory:      "users",
+			collectionPath: "/collections/users/_smoke.postman_collection.json",
+			wantKey:        "users_env_smoke",
+			wantCollection: "smoke",
+		},
+		{
+			name:           "data file variant",
+			strategy:       DefaultKeyStrategy,
+			directory:      "users",
+			collectionPath: "/collections/users/smoke.postman_collection.json",
+			dataFile:       "/collections/users/smoke.EU.data.json",
+			wantKey:        "users_env_smoke@eu",
+			wantCollection: "smoke@eu",
+		},
+		{
+			name:           "data file matching the collection has no variant",
+			strategy:       DefaultKeyStrategy,
+			directory:      "users",
+			collectionPath: "/collections/users/smoke.postman_collection.json",
+			dataFile:       "/collections/users/smoke.data.json",
+			wantKey:        "users_env_smoke",
+			wantCollection: "smoke",
+		},
+		{
+			name:           "custom field order",
+			strategy:       KeyStrategy{KeyFieldCollection, KeyFieldDirectory},
+			directory:      "users",
+			collectionPath: "/collections/users/smoke.postman_collection.json",
+			wantKey:        "smoke_users",
+			wantCollection: "smoke",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, _, _, collection := GenerateCompositeKey(tt.strategy, tt.directory, tt.environment, tt.collectionPath, tt.dataFile)
+			if key != tt.wantKey {
+				t.Errorf("key = %q, want %q", key, tt.wantKey)
+			}
+			if collection != tt.wantCollection {
+				t.Errorf("collection = %q, want %q", collection, tt.wantCollection)
+			}
+		})
+	}
+
+	t.Run("path hash is stable", func(t *testing.T) {
+		path := "/collections/users/smoke.postman_collection.json"
+		first, _, _, _ := GenerateCompositeKey(pathHashStrategy, "users", nil, path, "")
+		second, _, _, _ := GenerateCompositeKey(pathHashStrategy, "users", nil, path, "")
+		if first != second {
+			t.Errorf("keys differ across calls: %q and %q", first, second)
+		}
+		wantPrefix := "users_env_"
+		if !strings.HasPrefix(first, wantPrefix) || len(first) != len(wantPrefix)+pathHashLength {
+			t.Errorf("key = %q, want %q followed by a %d character hash", first, wantPrefix, pathHashLength)
+		}
+	})
+
+	t.Run("path hash ignores the disabled prefix", func(t *testing.T) {
+		enabled, _, _, _ := GenerateCompositeKey(pathHashStrategy, "users", nil, "/collections/users/smoke.postman_collection.json", "")
+		disabled, _, _, _ := GenerateCompositeKey(pathHashStrategy, "users", nil, "/collections/users/_smoke.postman_collection.json", "")
+		if enabled != disabled {
+			t.Errorf("disabling changed the key from %q to %q", enabled, disabled)
+		}
+	})
+
+	t.Run("path hash keeps keys unique", func(t *testing.T) {
+		// Each case would collide under the default strategy
+		cases := []struct {
+			directory      string
+			collectionPath string
+			dataFile       string
+		}{
+			{"users", "/collections/users/smoke.postman_collection.json", ""},
+			{"users", "/collections/orders/smoke.postman_collection.json", ""},
+			{"users", "/collections/Users/smoke.postman_collection.json", ""},
+			{"users", "/collections/users/Smoke.postman_collection.json", ""},
+			{"users", "/collections/users/smoke.postman_collection.json", "/collections/users/smoke.eu.data.json"},
+			{"users", "/collections/users/smoke.postman_collection.json", "/collections/users/smoke.us.data.json"},
+		}
+
+		seen := make(map[string]string)
+		for _, c := range cases {
+			key, _, _, _ := GenerateCompositeKey(pathHashStrategy, c.directory, nil, c.collectionPath, c.dataFile)
+			id := c.collectionPath + " " + c.dataFile
+			if other, ok := seen[key]; ok {
+				t.Errorf("%q and %q share key %q", other, id, key)
+			}
+			seen[key] = id
+		}
+	})
+}
+
+func TestGenerateCompositeKeySameEnvironmentInDifferentDirectories(t *testing.T) {
+	prod := "prod"
+	orders, _, _, _ := GenerateCompositeKey(DefaultKeyStrategy, "orders", &prod, "/collections/orders/smoke.postman_collection.json", "")
+	payments, _, _, _ := GenerateCompositeKey(DefaultKeyStrategy, "payments", &prod, "/collections/payments/smoke.postman_collection.json", "")
+	if orders == payments {
+		t.Errorf("both prod environments got key %q", orders)
+	}
+}
+
+func TestGenerateCompositeKeyRootCollection(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "Smoke.postman_collection.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys must not change between scans, or history would split
+	var keys []string
+	for scan := 0; scan < 2; scan++ {
+		groups, err := watcher.NewCollectionWatcher(root).ScanGroups()
+		if err != nil {
+			t.Fatalf("ScanGroups() error = %v", err)
+		}
+		if len(groups) != 1 || len(groups[0].Collections) != 1 {
+			t.Fatalf("got groups %+v, want one root collection", groups)
+		}
+		group := groups[0]
+		key, _, _, _ := GenerateCompositeKey(DefaultKeyStrategy, group.Directory, nil, group.Collections[0].FullPath, "")
+		keys = append(keys, key)
+	}
+
+	if keys[0] != "default_env_smoke" || keys[1] != keys[0] {
+		t.Errorf("keys = %q, want default_env_smoke from every scan", keys)
+	}
+}
@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+// defaultP95Window is how many runs the rolling p95 covers when a directory
+// doesn't set p95_window
+const defaultP95Window = 20
+
+// checkLatency compares a collection's rolling p95 response time with its
+// directory's p95_threshold_ms after a run. The open alert is stored, so the
+// collection notifies once when its p95 goes over and once when it's back
+// under, across restarts and replicas. Collections in their grace period
+// aren't checked.
+func (s *Scheduler) checkLatency(collection *storage.Collection, execution *storage.TestExecution, config watcher.DirectoryConfig) {
+	if execution.Overridden || execution.Cancelled {
+		return
+	}
+	if s.notifier != nil && s.notifier.InGracePeriod(collection, execution.StartedAt) {
+		return
+	}
+
+	threshold := config.P95ThresholdMs
+	if threshold <= 0 {
+		// The threshold was removed, so an alert it opened can't recover
+		if _, err := s.storage.CloseLatencyAlert(collection.ID); err != nil {
+			log.Printf("Error closing latency alert for %s: %v", collection.CompositeKey, err)
+		}
+		return
+	}
+
+	window := config.P95Window
+	if window <= 0 {
+		window = defaultP95Window
+	}
+	p95, err := s.storage.GetResponseTimeP95(collection.ID, window)
+	if err != nil {
+		log.Printf("Error computing p95 response time for %s: %v", collection.CompositeKey, err)
+		return
+	}
+	if p95 == nil {
+		return
+	}
+
+	if *p95 > threshold {
+		opened, err := s.storage.OpenLatencyAlert(&storage.LatencyAlert{
+			CollectionID: collection.ID,
+			P95Ms:        *p95,
+			ThresholdMs:  threshold,
+			Since:        time.Now(),
+		})
+		if err != nil {
+			log.Printf("Error opening latency alert for %s: %v", collection.CompositeKey, err)
+			return
+		}
+		if !opened {
+			return
+		}
+		log.Printf("Warning: collection %s p95 response time %dms is over its %dms threshold", collection.CompositeKey, *p95, threshold)
+		if s.notifier != nil {
+			s.notifier.HandleLatency(collection, execution, *p95, threshold, false)
+		}
+		return
+	}
+
+	closed, err := s.storage.CloseLatencyAlert(collection.ID)
+	if err != nil {
+		log.Printf("Error closing latency alert for %s: %v", collection.CompositeKey, err)
+		return
+	}
+	if closed == nil {
+		return
+	}
+	log.Printf("Collection %s p95 response time %dms is back within its %dms threshold", collection.CompositeKey, *p95, threshold)
+	if s.notifier != nil {
+		s.notifier.HandleLatency(collection, execution, *p95, threshold, true)
+	}
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// ErrMaintenance is returned when a run is requested while maintenance mode is enabled
+var ErrMaintenance = errors.New("maintenance mode is enabled")
+
+// loadMaintenance restores the persisted maintenance state at startup
+func (s *Scheduler) loadMaintenance() {
+	state, err := s.storage.GetMaintenance()
+	if err != nil {
+		log.Printf("Error loading maintenance state: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.maintenance = *state
+	s.mu.Unlock()
+
+	if state.Enabled {
+		log.Printf("Maintenance mode is enabled (reason: %q), executions are paused", state.Reason)
+	}
+}
+
+// GetMaintenance returns the current maintenance state, clearing it first if
+// its end time has passed
+func (s *Scheduler) GetMaintenance() storage.MaintenanceState {
+	s.mu.RLock()
+	state := s.maintenance
+	s.mu.RUnlock()
+
+	if !state.Expired(time.Now()) {
+		return state
+	}
+	return s.clearExpiredMaintenance()
+}
+
+// clearExpiredMaintenance persists the end of an expired maintenance window
+// and only then caches it, so callers of mu never wait on the database
+func (s *Scheduler) clearExpiredMaintenance() storage.MaintenanceState {
+	s.maintenanceWrites.Lock()
+	defer s.maintenanceWrites.Unlock()
+
+	// Another caller may have cleared or replaced the state in the meantime
+	s.mu.RLock()
+	state := s.maintenance
+	s.mu.RUnlock()
+	if !state.Expired(time.Now()) {
+		return state
+	}
+
+	log.Printf("Maintenance window ended at %v, resuming executions", *state.Until)
+	cleared := storage.MaintenanceState{UpdatedAt: time.Now()}
+	if err := s.storage.SetMaintenance(&cleared); err != nil {
+		log.Printf("Error clearing maintenance state: %v", err)
+	}
+
+	s.mu.Lock()
+	s.maintenance = cleared
+	s.mu.Unlock()
+	return cleared
+}
+
+// SetMaintenance enables or disables maintenance mode and persists it
+func (s *Scheduler) SetMaintenance(state storage.MaintenanceState) error {
+	state.UpdatedAt = time.Now()
+	if !state.Enabled {
+		state.Reason = ""
+		state.Until = nil
+	}
+
+	s.maintenanceWrites.Lock()
+	defer s.maintenanceWrites.Unlock()
+
+	if err := s.storage.SetMaintenance(&state); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.maintenance = state
+	s.mu.Unlock()
+
+	if state.Enabled {
+		log.Printf("Maintenance mode enabled (reason: %q)", state.Reason)
+	} else {
+		log.Println("Maintenance mode disabled")
+	}
+	return nil
+}
+
+// inMaintenance reports whether executions are currently paused
+func (s *Scheduler) inMaintenance() bool {
+	return s.GetMaintenance().Enabled
+}
@@ -0,0 +1,191 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+// EffectiveConfig layers a collection's API override over its directory's
+// scout.yaml and the global defaults; override may be nil. Cycles tick at the
+// global interval, so an interval shorter than it has no effect and the
+// global interval is reported instead.
+func (s *Scheduler) EffectiveConfig(config watcher.DirectoryConfig, override *storage.CollectionOverride) storage.EffectiveConfig {
+	effective := storage.EffectiveConfig{
+		Interval: s.interval,
+		Sources: map[string]string{
+			"interval":       storage.ConfigSourceDefault,
+			"paused":         storage.ConfigSourceDefault,
+			"pass_threshold": storage.ConfigSourceDefault,
+		},
+	}
+
+	if config.Interval > 0 && config.Interval >= s.interval {
+		effective.Interval = config.Interval
+		effective.Sources["interval"] = storage.ConfigSourceFile
+	}
+	if config.Paused {
+		effective.Paused = true
+		effective.Sources["paused"] = storage.ConfigSourceFile
+	}
+	if config.PassThreshold != nil {
+		effective.PassThreshold = config.PassThreshold
+		effective.Sources["pass_threshold"] = storage.ConfigSourceFile
+	}
+
+	if override == nil {
+		return effective
+	}
+	if override.Interval != nil && *override.Interval >= s.interval {
+		effective.Interval = *override.Interval
+		effective.Sources["interval"] = storage.ConfigSourceAPI
+	}
+	if override.Paused != nil {
+		effective.Paused = *override.Paused
+		effective.Sources["paused"] = storage.ConfigSourceAPI
+	}
+	if override.PassThreshold != nil {
+		effective.PassThreshold = override.PassThreshold
+		effective.Sources["pass_threshold"] = storage.ConfigSourceAPI
+	}
+	return effective
+}
+
+// enabledCollections drops disabled collections from groups, and groups
+// left without collections
+func enabledCollections(groups []watcher.CollectionGroup) []watcher.CollectionGroup {
+	enabled := make([]watcher.CollectionGroup, 0, len(groups))
+	for _, group := range groups {
+		filtered := group
+		filtered.Collections = nil
+		for _, col := range group.Collections {
+			if col.Disabled {
+				log.Printf("Collection %s in %s is disabled by its file name, skipping", col.Name, group.Directory)
+				continue
+			}
+			filtered.Collections = append(filtered.Collections, col)
+		}
+		if len(filtered.Collections) > 0 {
+			enabled = append(enabled, filtered)
+		}
+	}
+	return enabled
+}
+
+// CollectionConfig returns the effective config of a collection by ID
+func (s *Scheduler) CollectionConfig(collectionID int) (*storage.EffectiveConfig, error) {
+	collection, err := s.storage.GetCollectionByID(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if collection == nil {
+		return nil, fmt.Errorf("%w: %d", ErrCollectionNotFound, collectionID)
+	}
+
+	override, err := s.storage.GetCollectionOverride(collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.watcher.ScanGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan collection groups: %w", err)
+	}
+	for _, group := range groups {
+		for _, col := range group.Collections {
+			if s.newJob(group, col, SourceAPI, "").compositeKey == collection.CompositeKey {
+				effective := s.EffectiveConfig(group.Config, override)
+				return &effective, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s is no longer on disk", ErrCollectionNotFound, collection.CompositeKey)
+}
+
+// warnShortInterval warns once per directory and interval that its
+// scout.yaml interval is shorter than the global one, and so has no effect
+func (s *Scheduler) warnShortInterval(group watcher.CollectionGroup) {
+	interval := group.Config.Interval
+	if interval <= 0 || interval >= s.interval {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shortIntervals[group.Directory] == interval {
+		return
+	}
+	if s.shortIntervals == nil {
+		s.shortIntervals = make(map[string]time.Duration)
+	}
+	s.shortIntervals[group.Directory] = interval
+	log.Printf("Warning: interval %v in %s/%s is shorter than INTERVAL (%v) and has no effect; its collections run every cycle",
+		interval, group.Directory, watcher.DirectoryConfigFileName, s.interval)
+}
+
+// dueCollections drops disabled and paused collections from groups and, for
+// scheduled cycles, collections whose interval hasn't elapsed since their last
+// run. If settings can't be loaded every enabled collection runs.
+func (s *Scheduler) dueCollections(groups []watcher.CollectionGroup, source TriggerSource) []watcher.CollectionGroup {
+	groups = enabledCollections(groups)
+	collections, err := s.storage.GetAllCollections()
+	if err != nil {
+		log.Printf("Error loading collections for overrides, running all: %v", err)
+		return groups
+	}
+	overrides, err := s.storage.GetCollectionOverrides()
+	if err != nil {
+		log.Printf("Error loading collection overrides, running all: %v", err)
+		return groups
+	}
+	latest, err := s.storage.GetLatestExecutions()
+	if err != nil {
+		log.Printf("Error loading latest executions, running all: %v", err)
+		return groups
+	}
+
+	byKey := make(map[string]storage.Collection, len(collections))
+	for _, c := range collections {
+		byKey[c.CompositeKey] = c
+	}
+	lastRun := make(map[int]time.Time, len(latest))
+	for _, e := range latest {
+		lastRun[e.CollectionID] = e.StartedAt
+	}
+
+	timed := source == SourceScheduled || source == SourceStartup
+	due := make([]watcher.CollectionGroup, 0, len(groups))
+	for _, group := range groups {
+		s.warnShortInterval(group)
+		filtered := group
+		filtered.Collections = nil
+		for _, col := range group.Collections {
+			key := s.newJob(group, col, source, "").compositeKey
+			c, known := byKey[key]
+			var override *storage.CollectionOverride
+			if known {
+				override = overrides[c.ID]
+			}
+			effective := s.EffectiveConfig(group.Config, override)
+
+			if effective.Paused {
+				log.Printf("Collection %s is paused (%s), skipping", key, effective.Sources["paused"])
+				continue
+			}
+			// Cycles tick at the global interval, so allow half a tick of slack
+			if last, ok := lastRun[c.ID]; timed && known && ok && effective.Interval > s.interval &&
+				time.Since(last)+s.interval/2 < effective.Interval {
+				continue
+			}
+			filtered.Collections = append(filtered.Collections, col)
+		}
+		if len(filtered.Collections) > 0 {
+			due = append(due, filtered)
+		}
+	}
+	return due
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josepht96/scout/internal/storage"
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+func TestEnabledCollections(t *testing.T) {
+	groups := []watcher.CollectionGroup{
+		{
+			Directory: "shop",
+			Collections: []watcher.CollectionFile{
+				{Name: "orders.postman_collection.json"},
+				{Name: "_legacy.postman_collection.json", Disabled: true},
+				{Name: "cart.postman_collection.disabled.json", Disabled: true},
+			},
+		},
+		{
+			Directory: "archive",
+			Collections: []watcher.CollectionFile{
+				{Name: "_old.postman_collection.json", Disabled: true},
+			},
+		},
+	}
+
+	enabled := enabledCollections(groups)
+
+	if len(enabled) != 1 || enabled[0].Directory != "shop" {
+		t.Fatalf("got groups %v, want only shop; groups left empty must be dropped", enabled)
+	}
+	if n := len(enabled[0].Collections); n != 1 || enabled[0].Collections[0].Name != "orders.postman_collection.json" {
+		t.Errorf("got collections %v, want only orders.postman_collection.json", enabled[0].Collections)
+	}
+	// The scanned groups still list the disabled collections for the API
+	if len(groups[0].Collections) != 3 {
+		t.Errorf("enabledCollections modified its input: %v", groups[0].Collections)
+	}
+}
+
+func TestEffectiveConfigIntervalNotShorterThanGlobal(t *testing.T) {
+	s := &Scheduler{interval: time.Minute}
+	short, long := 30*time.Second, 5*time.Minute
+
+	tests := []struct {
+		name       string
+		file       time.Duration
+		override   *time.Duration
+		want       time.Duration
+		wantSource string
+	}{
+		{name: "no interval", want: time.Minute, wantSource: storage.ConfigSourceDefault},
+		{name: "longer file interval", file: long, want: long, wantSource: storage.ConfigSourceFile},
+		{name: "shorter file interval", file: short, want: time.Minute, wantSource: storage.ConfigSourceDefault},
+		{name: "longer override", file: short, override: &long, want: long, wantSource: storage.ConfigSourceAPI},
+		{name: "shorter override", file: long, override: &short, want: long, wantSource: storage.ConfigSourceFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var override *storage.CollectionOverride
+			if tt.override != nil {
+				override = &storage.CollectionOverride{Interval: tt.override}
+			}
+			effective := s.EffectiveConfig(watcher.DirectoryConfig{Interval: tt.file}, override)
+			if effective.Interval != tt.want || effective.Sources["interval"] != tt.wantSource {
+				t.Errorf("interval = %v from %s, want %v from %s", effective.Interval, effective.Sources["interval"], tt.want, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/josepht96/scout/internal/executor"
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+// TriggerSource identifies what caused a collection to be executed
+type TriggerSource string
+
+const (
+	// SourceScheduled is a run started by the scheduler's interval ticker
+	SourceScheduled TriggerSource = "scheduled"
+	// SourceManual is a run started from the dashboard
+	SourceManual TriggerSource = "manual"
+	// SourceAPI is a run started by an API client via /api/run
+	SourceAPI TriggerSource = "api"
+	// SourceStartup is the cycle run when the scheduler starts
+	SourceStartup TriggerSource = "startup"
+	// SourceReplay is a re-run of a stored execution's inputs via
+	// /api/executions/{id}/replay
+	SourceReplay TriggerSource = "replay"
+)
+
+// ParseTriggerSource validates a trigger source supplied by a client
+func ParseTriggerSource(value string) (TriggerSource, error) {
+	switch source := TriggerSource(value); source {
+	case SourceManual, SourceAPI:
+		return source, nil
+	default:
+		return "", fmt.Errorf("invalid source %q (expected manual or api)", value)
+	}
+}
+
+// queueSize bounds the number of pending jobs before enqueueing blocks
+const queueSize = 1000
+
+// job is a single collection execution waiting for or running on a worker
+type job struct {
+	compositeKey      string
+	collection        watcher.CollectionFile
+	environmentPath   *string
+	environmentName   *string
+	sharedEnvironment *watcher.EnvironmentFile
+	hostGroup         string
+	directory         string
+	config            watcher.DirectoryConfig
+	source            TriggerSource
+	overrides         []executor.EnvVar
+	collectionVars    []executor.EnvVar // Recorded inputs a replay starts from
+	version           string
+	orderPosition     *int
+	replayOf          *int
+	failed            bool
+	executionID       int // Set once the run is stored; read after done is closed
+	enqueuedAt        time.Time
+	startedAt         time.Time
+	release           func()             // Frees the host group slot, set before a worker gets the job
+	cancel            context.CancelFunc // Set while in flight
+	cancelled         bool               // Set by CancelCollection, guarded by queueMu
+	done              chan struct{}
+}
+
+// QueueEntry describes a pending or in-flight execution for the API
+type QueueEntry struct {
+	CompositeKey   string        `json:"composite_key"`
+	CollectionName string        `json:"collection_name"`
+	Directory      string        `json:"directory"`
+	Source         TriggerSource `json:"source"`
+	EnqueuedAt     time.Time     `json:"enqueued_at"`
+	StartedAt      *time.Time    `json:"started_at,omitempty"`
+}
+
+// QueueSnapshot is a point-in-time view of the execution queue
+type QueueSnapshot struct {
+	Pending  []QueueEntry `json:"pending"`
+	InFlight []QueueEntry `json:"in_flight"`
+}
+
+// newJob builds a job for a collection within a group
+func (s *Scheduler) newJob(group watcher.CollectionGroup, col watcher.CollectionFile, source TriggerSource, version string) *job {
+	// Determine environment path for this collection
+	var envPath *string
+	var envName *string
+	if group.Environment != nil {
+		envPath = &group.Environment.FullPath
+		// Extract environment name from filename (strip .postman_environment.json and .enc)
+		name := watcher.EnvironmentBaseName(group.Environment.FileName)
+		envName = &name
+	}
+
+	compositeKey, _, _, _ := s.CompositeKey(group.Directory, envName, col.FullPath, col.DataFile)
+
+	return &job{
+		compositeKey:      compositeKey,
+		collection:        col,
+		environmentPath:   envPath,
+		environmentName:   envName,
+		sharedEnvironment: group.SharedEnvironment,
+		hostGroup:         group.Config.HostGroupOf(col.Name),
+		directory:         group.Directory,
+		config:            group.Config,
+		source:            source,
+		version:           version,
+	}
+}
+
+// queueKey identifies a job for coalescing. Jobs with variable overrides, a
+// version label or replaying an execution have distinct inputs, so they are
+// never merged with a plain run.
+func (j *job) queueKey() string {
+	if len(j.overrides) == 0 && j.version == "" && j.replayOf == nil {
+		return j.compositeKey
+	}
+	key := j.compositeKey
+	if j.replayOf != nil {
+		key += fmt.Sprintf("#replay-%d", *j.replayOf)
+	}
+	if j.version != "" {
+		key += "@" + j.version
+	}
+	for _, v := range j.overrides {
+		key += "|" + v.Key + "=" + v.Value
+	}
+	return key
+}
+
+// executeOptions builds executor options from the job's directory config and overrides
+func (j *job) executeOptions() executor.ExecuteOptions {
+	opts := executor.ExecuteOptions{
+		EnvVars:        j.overrides,
+		CollectionVars: j.collectionVars,
+		CaptureHeaders: j.config.CaptureHeaders,
+		GraphQLPaths:   j.config.GraphQLPaths,
+		DataFile:       j.collection.DataFile,
+		NewmanArgs:     j.config.NewmanArgs,
+	}
+	// Shared variables sit beneath the environment: scout.yaml, then the shared file
+	if len(j.config.Shared) > 0 {
+		opts.SharedLayers = append(opts.SharedLayers, executor.EnvironmentLayer{
+			Name:   watcher.DirectoryConfigFileName,
+			Values: j.config.Shared,
+		})
+	}
+	if j.sharedEnvironment != nil {
+		opts.SharedLayers = append(opts.SharedLayers, executor.EnvironmentLayer{
+			Name: j.sharedEnvironment.FileName,
+			Path: j.sharedEnvironment.FullPath,
+		})
+	}
+	if j.config.Proxy != nil {
+		opts.Proxy = executor.ProxyConfig{
+			HTTPProxy:  j.config.Proxy.HTTP,
+			HTTPSProxy: j.config.Proxy.HTTPS,
+			NoProxy:    j.config.Proxy.NoProxy,
+		}
+	}
+	if j.config.Snapshots != nil {
+		opts.Snapshots = &executor.SnapshotConfig{
+			Requests:  j.config.Snapshots.Requests,
+			Ignore:    j.config.Snapshots.Ignore,
+			StoreBody: j.config.Snapshots.StoreBody,
+		}
+	}
+	if j.config.Auth != nil {
+		opts.Auth = &executor.AuthConfig{
+			Type:     j.config.Auth.Type,
+			Token:    j.config.Auth.Token,
+			Username: j.config.Auth.Username,
+			Password: j.config.Auth.Password,
+		}
+	}
+	return opts
+}
+
+// enqueue adds a job to the queue, coalescing it with an already pending job
+// for the same collection. It returns the job whose completion callers should await.
+func (s *Scheduler) enqueue(j *job) *job {
+	s.queueMu.Lock()
+	if existing, ok := s.pending[j.queueKey()]; ok {
+		s.queueMu.Unlock()
+		log.Printf("Collection %s is already queued (source: %s), coalescing %s trigger", j.compositeKey, existing.source, j.source)
+		return existing
+	}
+	j.enqueuedAt = time.Now()
+	j.done = make(chan struct{})
+	s.pending[j.queueKey()] = j
+	s.queueMu.Unlock()
+
+	if j.hostGroup == "" {
+		s.dispatch(j)
+	} else {
+		// Wait for the host group off the worker pool, so a burst from one
+		// group can't hold every worker while other groups' jobs queue behind it
+		go s.dispatch(j)
+	}
+	return j
+}
+
+// dispatch hands a job to the workers once its host group has a free slot,
+// keeping it listed as pending while it waits
+func (s *Scheduler) dispatch(j *job) {
+	release, ok := s.hostGroups.acquire(s.ctx, j.hostGroup)
+	if ok {
+		j.release = release
+		select {
+		case s.jobs <- j:
+			return
+		case <-s.ctx.Done():
+			release()
+		}
+	}
+
+	s.queueMu.Lock()
+	delete(s.pending, j.queueKey())
+	s.queueMu.Unlock()
+	close(j.done)
+}
+
+// startAfter calls start once delay has passed, returning a channel that is
+// closed when the work start returns has finished
+func (s *Scheduler) startAfter(delay time.Duration, start func() <-chan struct{}) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return
+		}
+		s.wait([]<-chan struct{}{start()})
+	}()
+	return done
+}
+
+// worker executes queued jobs until the scheduler is stopped
+func (s *Scheduler) worker() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case j := <-s.jobs:
+			// Executions are only stopped by CancelCollection; shutdown
+			// lets in-flight runs finish as before
+			ctx, cancel := context.WithCancel(context.Background())
+
+			s.queueMu.Lock()
+			delete(s.pending, j.queueKey())
+			j.startedAt = time.Now()
+			j.cancel = cancel
+			s.inFlight[j.queueKey()] = j
+			s.queueMu.Unlock()
+
+			if err := s.executeCollection(ctx, j); err != nil {
+				log.Printf("Error executing collection %s: %v", j.collection.Name, err)
+				j.failed = true
+			}
+			j.release()
+
+			s.queueMu.Lock()
+			delete(s.inFlight, j.queueKey())
+			s.queueMu.Unlock()
+			cancel()
+			close(j.done)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// wait blocks until every channel is closed, returning false if the scheduler stopped first
+func (s *Scheduler) wait(done []<-chan struct{}) bool {
+	for _, d := range done {
+		select {
+		case <-d:
+		case <-s.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// CancelCollection stops the in-flight executions of a collection, killing
+// their Newman processes. Each is recorded as cancelled. It returns
+// ErrNotRunning if the collection has no execution in flight.
+func (s *Scheduler) CancelCollection(collectionID int) error {
+	collection, err := s.storage.GetCollectionByID(collectionID)
+	if err != nil {
+		return err
+	}
+	if collection == nil {
+		return fmt.Errorf("%w: %d", ErrCollectionNotFound, collectionID)
+	}
+
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	cancelled := 0
+	for _, j := range s.inFlight {
+		if j.compositeKey != collection.CompositeKey || j.cancelled {
+			continue
+		}
+		j.cancelled = true
+		j.cancel()
+		cancelled++
+	}
+	if cancelled == 0 {
+		return fmt.Errorf("%w: %s", ErrNotRunning, collection.CompositeKey)
+	}
+	log.Printf("Cancelling %d in-flight execution(s) of %s", cancelled, collection.CompositeKey)
+	return nil
+}
+
+// GetQueue returns the pending and in-flight executions, oldest first
+func (s *Scheduler) GetQueue() QueueSnapshot {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	snapshot := QueueSnapshot{
+		Pending:  []QueueEntry{},
+		InFlight: []QueueEntry{},
+	}
+	for _, j := range s.pending {
+		snapshot.Pending = append(snapshot.Pending, j.entry(false))
+	}
+	for _, j := range s.inFlight {
+		snapshot.InFlight = append(snapshot.InFlight, j.entry(true))
+	}
+
+	sortEntries := func(entries []QueueEntry) {
+		sort.Slice(entries, func(a, b int) bool {
+			return entries[a].EnqueuedAt.Before(entries[b].EnqueuedAt)
+		})
+	}
+	sortEntries(snapshot.Pending)
+	sortEntries(snapshot.InFlight)
+
+	return snapshot
+}
+
+// entry converts a job to its API representation
+func (j *job) entry(started bool) QueueEntry {
+	e := QueueEntry{
+		CompositeKey:   j.compositeKey,
+		CollectionName: j.collection.Name,
+		Directory:      j.directory,
+		Source:         j.source,
+		EnqueuedAt:     j.enqueuedAt,
+	}
+	if started {
+		startedAt := j.startedAt
+		e.StartedAt = &startedAt
+	}
+	return e
+}
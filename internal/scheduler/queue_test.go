@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+func TestEnqueueWaitsForHostGroupOffTheWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &Scheduler{
+		ctx:        ctx,
+		jobs:       make(chan *job, queueSize),
+		pending:    make(map[string]*job),
+		hostGroups: newHostGroupLimiter(1, nil),
+	}
+	newJob := func(key, hostGroup string) *job {
+		return &job{compositeKey: key, hostGroup: hostGroup, collection: watcher.CollectionFile{Name: key}}
+	}
+
+	// A burst from one group, then a job from another and one without a group
+	for _, key := range []string{"a1", "a2", "a3"} {
+		s.enqueue(newJob(key, "a"))
+	}
+	s.enqueue(newJob("b1", "b"))
+	s.enqueue(newJob("plain", ""))
+
+	ready := make(map[string]*job)
+	for len(ready) < 3 {
+		select {
+		case j := <-s.jobs:
+			ready[j.compositeKey] = j
+		case <-time.After(time.Second):
+			t.Fatalf("only %d jobs reached the workers, want one per host group and the ungrouped one", len(ready))
+		}
+	}
+	var first *job
+	for _, key := range []string{"a1", "a2", "a3"} {
+		if j, ok := ready[key]; ok {
+			if first != nil {
+				t.Fatalf("two jobs of host group a reached the workers at once")
+			}
+			first = j
+		}
+	}
+	if first == nil || ready["b1"] == nil || ready["plain"] == nil {
+		t.Fatalf("jobs ready = %v, want one of a, b1 and plain", ready)
+	}
+	if n := len(s.pending); n != 5 {
+		t.Errorf("%d jobs pending, want all 5 until a worker starts them", n)
+	}
+
+	// Finishing the running job lets the next one of the group through
+	first.release()
+	select {
+	case j := <-s.jobs:
+		if j.hostGroup != "a" {
+			t.Errorf("next job = %s, want another of host group a", j.compositeKey)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("releasing the host group slot didn't dispatch the next job")
+	}
+}
+
+func TestEnqueueShutdownWhileWaitingForHostGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		ctx:        ctx,
+		jobs:       make(chan *job, queueSize),
+		pending:    make(map[string]*job),
+		hostGroups: newHostGroupLimiter(1, nil),
+	}
+	s.enqueue(&job{compositeKey: "a1", hostGroup: "a"})
+	select {
+	case <-s.jobs:
+	case <-time.After(time.Second):
+		t.Fatal("first job of the host group wasn't dispatched")
+	}
+	waiting := s.enqueue(&job{compositeKey: "a2", hostGroup: "a"})
+
+	cancel()
+	select {
+	case <-waiting.done:
+	case <-time.After(time.Second):
+		t.Fatal("job waiting for its host group wasn't finished on shutdown")
+	}
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	if _, ok := s.pending[waiting.queueKey()]; ok {
+		t.Error("job finished on shutdown is still listed as pending")
+	}
+}
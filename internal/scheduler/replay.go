@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/josepht96/scout/internal/executor"
+)
+
+// ErrExecutionNotFound is returned when replaying an execution that does not exist
+var ErrExecutionNotFound = errors.New("execution not found")
+
+// ErrReplayIncomplete is returned when an execution's inputs weren't all
+// stored, so replaying it as-is would not reproduce the run
+var ErrReplayIncomplete = errors.New("execution inputs were not fully recorded")
+
+// maskedValue marks collection variable values the Newman script masked
+// before reporting them, matching its MASKED_VALUE
+const maskedValue = "****"
+
+// Replay describes a queued replay of a stored execution
+type Replay struct {
+	ExecutionID  int    `json:"execution_id"`
+	CompositeKey string `json:"composite_key"`
+	// Variables are the recorded collection variables the replay starts from
+	Variables []string `json:"variables"`
+	// ReResolved are variables recorded masked, so the replay resolves them
+	// from the collection and environment as they are now
+	ReResolved []string `json:"re_resolved"`
+}
+
+// ReplayExecution queues a run of an execution's collection starting from the
+// collection variables recorded as that execution started, labeled with its
+// tested version and linked to it as a replay. They are set as collection
+// variables, so the environment shadows them and scripts update them as in
+// the original run. The collection and environment files are read as they are
+// now, and executions stored before inputs were recorded replay the
+// collection's current variables. Variables recorded masked are re-resolved
+// rather than replayed. Variable overrides aren't stored, so an execution that
+// had them can only be replayed by passing them again as overrides.
+func (s *Scheduler) ReplayExecution(executionID int, overrides []executor.EnvVar) (*Replay, error) {
+	if s.inMaintenance() {
+		return nil, ErrMaintenance
+	}
+
+	execution, err := s.storage.GetExecutionByID(executionID)
+	if err != nil {
+		return nil, err
+	}
+	if execution == nil {
+		return nil, fmt.Errorf("%w: %d", ErrExecutionNotFound, executionID)
+	}
+	// A replay is itself recorded as overridden, so only the original's own
+	// overrides are missing
+	if execution.Overridden && execution.ReplayOf == nil && len(overrides) == 0 {
+		return nil, fmt.Errorf("%w: execution %d ran with variable overrides, pass them again as var", ErrReplayIncomplete, executionID)
+	}
+
+	variables, err := s.storage.GetExecutionInputs(executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := ""
+	if execution.TestedVersion != nil {
+		version = *execution.TestedVersion
+	}
+	j, err := s.collectionJob(execution.CollectionID, SourceReplay, version)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := &Replay{ExecutionID: executionID, CompositeKey: j.compositeKey, Variables: []string{}, ReResolved: []string{}}
+	for _, v := range variables {
+		if strings.Contains(v.Value, maskedValue) {
+			replay.ReResolved = append(replay.ReResolved, v.Name)
+			continue
+		}
+		j.collectionVars = append(j.collectionVars, executor.EnvVar{Key: v.Name, Value: v.Value})
+		replay.Variables = append(replay.Variables, v.Name)
+	}
+	j.overrides = overrides
+	j.replayOf = &executionID
+
+	s.runJob(j)
+	return replay, nil
+}
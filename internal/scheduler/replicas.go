@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// claimCollection makes sure only one of several replicas sharing the
+// database runs a scheduled job's collection each cycle. It takes the
+// collection's advisory lock, then skips the collection when its latest
+// execution started less than half an interval ago, as when another
+// replica's cycle just ran it. It reports false when the job should be
+// skipped; otherwise release must be called once the job has run.
+func (s *Scheduler) claimCollection(ctx context.Context, j *job) (release func(), claimed bool) {
+	unlock, locked, err := s.locker.TryLock(ctx, j.compositeKey)
+	if err != nil {
+		// Running twice beats not running at all
+		log.Printf("Error locking collection %s, running it without the lock: %v", j.compositeKey, err)
+		return func() {}, true
+	}
+	if !locked {
+		log.Printf("Collection %s is running on another replica, skipping", j.compositeKey)
+		return nil, false
+	}
+
+	latest, err := s.storage.GetLatestExecutionByCompositeKey(j.compositeKey)
+	if err != nil {
+		log.Printf("Error checking the latest execution of %s, running it: %v", j.compositeKey, err)
+	} else if latest != nil && time.Since(latest.StartedAt) < s.interval/2 {
+		replica := "another replica"
+		if latest.Replica != nil {
+			replica = "replica " + *latest.Replica
+		}
+		log.Printf("Collection %s already ran on %s %v ago, skipping",
+			j.compositeKey, replica, time.Since(latest.StartedAt).Round(time.Second))
+		unlock()
+		// Export the other replica's result so this replica's metrics agree
+		s.updateCollectionMetrics(j.compositeKey)
+		return nil, false
+	}
+
+	log.Printf("Replica %s claimed collection %s", s.replica, j.compositeKey)
+	return unlock, true
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/josepht96/scout/internal/watcher"
+)
+
+// pruneInterval is how often old executions are deleted
+const pruneInterval = time.Hour
+
+// retentionLoop prunes old executions at startup and then every pruneInterval
+func (s *Scheduler) retentionLoop() {
+	defer s.wg.Done()
+
+	s.pruneExecutions()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.pruneExecutions()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// directoryRetention maps composite keys of collections on disk to the
+// retention set in their directory's scout.yaml, when set
+func (s *Scheduler) directoryRetention(groups []watcher.CollectionGroup) map[string]time.Duration {
+	retention := make(map[string]time.Duration)
+	for _, group := range groups {
+		if group.Config.Retention == 0 {
+			continue
+		}
+		for _, col := range group.Collections {
+			key := s.newJob(group, col, SourceScheduled, "").compositeKey
+			retention[key] = group.Config.Retention
+		}
+	}
+	return retention
+}
+
+// pruneExecutions deletes each collection's executions older than its
+// effective retention, the directory's retention or else the global one,
+// keeping at least the configured minimum per collection
+func (s *Scheduler) pruneExecutions() {
+	groups, err := s.watcher.ScanGroups()
+	if err != nil {
+		log.Printf("Error scanning collection groups for retention, skipping pruning: %v", err)
+		return
+	}
+	overrides := s.directoryRetention(groups)
+	if s.retention == 0 && len(overrides) == 0 {
+		return
+	}
+
+	collections, err := s.storage.GetAllCollections()
+	if err != nil {
+		log.Printf("Error fetching collections for retention: %v", err)
+		return
+	}
+
+	now := time.Now()
+	var total int64
+	for _, collection := range collections {
+		retention, ok := overrides[collection.CompositeKey]
+		if !ok {
+			retention = s.retention
+		}
+		if retention == 0 {
+			continue
+		}
+
+		deleted, err := s.storage.PruneExecutions(collection.ID, now.Add(-retention), s.retentionMinKeep)
+		if err != nil {
+			log.Printf("Error pruning executions of %s: %v", collection.CompositeKey, err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Pruned %d execution(s) of %s older than %v", deleted, collection.CompositeKey, retention)
+			total += deleted
+		}
+	}
+	if total > 0 {
+		log.Printf("Retention pruned %d execution(s) in total", total)
+	}
+}
@@ -2,23 +2,38 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/josepht96/scout/internal/executor"
+	"github.com/josepht96/scout/internal/notifier"
+	"github.com/josepht96/scout/internal/reports"
 	"github.com/josepht96/scout/internal/storage"
 	"github.com/josepht96/scout/internal/watcher"
 )
 
 // GenerateCompositeKey creates a unique composite key from directory, environment, and collection names
-// Format: {directory}_{environment}_{collection} (all lowercase)
+// Format with the default strategy: {directory}_{environment}_{collection} (all lowercase)
 // If no environment: {directory}_env_{collection}
-func GenerateCompositeKey(directoryName string, environmentName *string, collectionFileName string) (compositeKey, directory, environment, collection string) {
-	// Extract collection name from filename (strip .postman_collection.json)
-	collectionName := strings.TrimSuffix(collectionFileName, ".postman_collection.json")
+// A collection run with a {collection}.{variant}.data.json data file is named {collection}@{variant}
+func GenerateCompositeKey(strategy KeyStrategy, directoryName string, environmentName *string, collectionPath, dataFile string) (compositeKey, directory, environment, collection string) {
+	// Extract collection name from filename (strip .postman_collection.json),
+	// ignoring any prefix or suffix disabling the collection
+	fileName := watcher.EnabledFileName(filepath.Base(collectionPath))
+	collectionName := strings.TrimSuffix(fileName, ".postman_collection.json")
+	variant := watcher.DataVariant(collectionPath, dataFile)
+	if variant != "" {
+		collectionName += "@" + variant
+	}
 
 	// Use environment name or "env" as placeholder
 	envName := "env"
@@ -31,32 +46,117 @@ func GenerateCompositeKey(directoryName string, environmentName *string, collect
 	env := strings.ToLower(envName)
 	col := strings.ToLower(collectionName)
 
-	key := dir + "_" + env + "_" + col
+	parts := make([]string, 0, len(strategy))
+	for _, field := range strategy {
+		switch field {
+		case KeyFieldDirectory:
+			parts = append(parts, dir)
+		case KeyFieldEnvironment:
+			parts = append(parts, env)
+		case KeyFieldCollection:
+			parts = append(parts, col)
+		case KeyFieldPathHash:
+			parts = append(parts, pathHash(filepath.Join(filepath.Dir(collectionPath), fileName), variant))
+		}
+	}
+	key := strings.Join(parts, "_")
 
 	return key, dir, env, col
 }
 
+// CompositeKey generates a composite key using the scheduler's key strategy
+func (s *Scheduler) CompositeKey(directoryName string, environmentName *string, collectionPath, dataFile string) (compositeKey, directory, environment, collection string) {
+	return GenerateCompositeKey(s.keyStrategy, directoryName, environmentName, collectionPath, dataFile)
+}
+
+// ErrCollectionNotFound is returned when a requested collection does not exist
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// ErrCollectionDisabled is returned when running a collection whose file is
+// named to disable it
+var ErrCollectionDisabled = errors.New("collection is disabled")
+
+// ErrCycleRunning is returned when a cycle is requested while one is already running
+var ErrCycleRunning = errors.New("an execution cycle is already running")
+
+// ErrNotRunning is returned when cancelling a collection with no execution in flight
+var ErrNotRunning = errors.New("collection is not running")
+
+// cancelledMessage is the error recorded on cancelled executions
+const cancelledMessage = "Execution cancelled"
+
+// Scanning is retried with doubling delays before a cycle is given up, to
+// ride out a collections volume that is briefly unavailable (e.g. an NFS remount)
+const (
+	scanAttempts   = 3
+	scanRetryDelay = 2 * time.Second
+)
+
 // Scheduler manages periodic execution of Postman collections
 type Scheduler struct {
-	storage        *storage.Storage
-	executor       *executor.NewmanExecutor
-	watcher        *watcher.CollectionWatcher
-	interval       time.Duration
-	ctx            context.Context
-	cancel         context.CancelFunc
-	wg             sync.WaitGroup
-	metricsUpdater MetricsUpdater
-	mu             sync.RWMutex
-	lastRunTime    time.Time
-	totalRuns      int
-	failedRuns     int
+	storage                *storage.Storage
+	executor               *executor.NewmanExecutor
+	watcher                *watcher.CollectionWatcher
+	interval               time.Duration
+	ctx                    context.Context
+	cancel                 context.CancelFunc
+	wg                     sync.WaitGroup
+	metricsUpdater         MetricsUpdater
+	notifier               *notifier.Dispatcher
+	mu                     sync.RWMutex
+	lastRunTime            time.Time
+	cycleRunning           bool
+	lastCycleCompleted     time.Time
+	lastMetricsReconcile   time.Time
+	stalled                bool
+	stallIntervals         int
+	retention              time.Duration
+	retentionMinKeep       int
+	locker                 *storage.CollectionLocker
+	replica                string
+	totalRuns              int
+	failedRuns             int
+	scanRetries            int
+	scanFailures           int
+	resultsVersion         uint64
+	concurrency            int
+	runOnStart             bool
+	startJitter            time.Duration
+	collectionJitter       time.Duration
+	maxResultsPerExecution int
+	maintenance            storage.MaintenanceState
+	maintenanceWrites      sync.Mutex // Orders maintenance writes, which are made without holding mu
+	writeSlots             chan struct{}
+	maxErrorLength         int
+	trendWindow            int
+	regressionFactor       float64
+	keyStrategy            KeyStrategy
+	reports                reports.ReportStore
+	throttle               *throttle
+	hostGroups             *hostGroupLimiter
+	jobs                   chan *job
+	queueMu                sync.Mutex
+	pending                map[string]*job
+	inFlight               map[string]*job
+	shortIntervals         map[string]time.Duration // scout.yaml intervals already warned about as shorter than interval, by directory
 }
 
 // MetricsUpdater is an interface for updating metrics
 type MetricsUpdater interface {
+	// UpdateMetrics reconciles every collection's metrics, removing those
+	// of collections that are gone
 	UpdateMetrics(*storage.LatestResults)
+	// UpdateCollection updates one collection's metrics after it runs
+	UpdateCollection(storage.CollectionResult)
+	SetSchedulerStalled(bool)
+	// Push sends the current metrics wherever they are pushed, if anywhere
+	Push()
 }
 
+// metricsReconcileInterval is how often cycles rebuild every collection's
+// metrics; between reconciles each execution updates its own collection's
+const metricsReconcileInterval = 5 * time.Minute
+
 // Config contains scheduler configuration
 type Config struct {
 	Storage        *storage.Storage
@@ -64,41 +164,172 @@ type Config struct {
 	Watcher        *watcher.CollectionWatcher
 	Interval       time.Duration
 	MetricsUpdater MetricsUpdater
+	Notifier       *notifier.Dispatcher
+	Concurrency    int
+	RunOnStart     bool
+	StartJitter    time.Duration
+	// MaxResultsPerExecution caps stored test results per execution (0 = unlimited)
+	MaxResultsPerExecution int
+	// MaxErrorLength caps stored error strings (0 = unlimited)
+	MaxErrorLength int
+	// WriteConcurrency caps executions writing to the database at once
+	WriteConcurrency int
+	// DurationTrendWindow and DurationRegressionFactor control duration regression detection
+	DurationTrendWindow      int
+	DurationRegressionFactor float64
+	// KeyStrategy selects the composite key fields (nil = DefaultKeyStrategy)
+	KeyStrategy KeyStrategy
+	// CollectionJitter randomly offsets each collection's start within a
+	// scheduled cycle by up to this much; clamped to half the interval
+	CollectionJitter time.Duration
+	// Reports stores each execution's raw Newman report (nil = not stored)
+	Reports reports.ReportStore
+	// GlobalRPS caps requests per second across all collections by spacing
+	// each run's requests (0 = unlimited)
+	GlobalRPS float64
+	// HostGroupConcurrency caps concurrent executions per host group;
+	// HostGroupLimits overrides it for individual host groups
+	HostGroupConcurrency int
+	HostGroupLimits      map[string]int
+	// StallIntervals is how many intervals may pass without a completed
+	// cycle before the scheduler is reported as stalled (0 = never)
+	StallIntervals int
+	// Retention is how long executions are kept before they are pruned
+	// (0 = forever); a directory's scout.yaml retention overrides it.
+	// RetentionMinKeep executions per collection are always kept.
+	Retention        time.Duration
+	RetentionMinKeep int
+	// Locker coordinates replicas sharing the database so each scheduled
+	// collection runs on only one of them (nil = single replica); Replica
+	// names this replica in logs and on its executions
+	Locker  *storage.CollectionLocker
+	Replica string
 }
 
+// reportTimeout bounds how long writing a raw report may take
+const reportTimeout = 30 * time.Second
+
+// writeWaitWarning is how long an execution may wait for a write slot before it is logged
+const writeWaitWarning = time.Second
+
 // NewScheduler creates a new scheduler
 func NewScheduler(config Config) *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
+
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	writeConcurrency := config.WriteConcurrency
+	if writeConcurrency < 1 {
+		writeConcurrency = 1
+	}
+
+	keyStrategy := config.KeyStrategy
+	if len(keyStrategy) == 0 {
+		keyStrategy = DefaultKeyStrategy
+	}
+
+	// Keep jittered starts well inside the cycle so they never spill into the next one
+	collectionJitter := config.CollectionJitter
+	if limit := config.Interval / 2; collectionJitter > limit {
+		log.Printf("Collection jitter %v exceeds half the interval, clamping to %v", collectionJitter, limit)
+		collectionJitter = limit
+	}
+
 	return &Scheduler{
-		storage:        config.Storage,
-		executor:       config.Executor,
-		watcher:        config.Watcher,
-		interval:       config.Interval,
-		ctx:            ctx,
-		cancel:         cancel,
-		metricsUpdater: config.MetricsUpdater,
+		storage:                config.Storage,
+		executor:               config.Executor,
+		watcher:                config.Watcher,
+		interval:               config.Interval,
+		ctx:                    ctx,
+		cancel:                 cancel,
+		metricsUpdater:         config.MetricsUpdater,
+		notifier:               config.Notifier,
+		concurrency:            concurrency,
+		runOnStart:             config.RunOnStart,
+		startJitter:            config.StartJitter,
+		collectionJitter:       collectionJitter,
+		maxResultsPerExecution: config.MaxResultsPerExecution,
+		writeSlots:             make(chan struct{}, writeConcurrency),
+		maxErrorLength:         config.MaxErrorLength,
+		trendWindow:            config.DurationTrendWindow,
+		regressionFactor:       config.DurationRegressionFactor,
+		keyStrategy:            keyStrategy,
+		reports:                config.Reports,
+		throttle:               newThrottle(config.GlobalRPS, concurrency),
+		hostGroups:             newHostGroupLimiter(config.HostGroupConcurrency, config.HostGroupLimits),
+		stallIntervals:         config.StallIntervals,
+		retention:              config.Retention,
+		retentionMinKeep:       config.RetentionMinKeep,
+		locker:                 config.Locker,
+		replica:                config.Replica,
+		jobs:                   make(chan *job, queueSize),
+		pending:                make(map[string]*job),
+		inFlight:               make(map[string]*job),
 	}
 }
 
 // Start starts the scheduler
 func (s *Scheduler) Start() {
-	log.Printf("Starting scheduler with interval: %v", s.interval)
+	log.Printf("Starting scheduler with interval: %v, concurrency: %d", s.interval, s.concurrency)
+	if s.throttle != nil {
+		log.Printf("Global request budget: %v requests/s, %v between requests per collection", s.throttle.rps, s.throttle.delay)
+	}
 
-	// Run once immediately
-	s.runOnce()
+	s.loadMaintenance()
 
-	// Start ticker for periodic execution
+	// Measure stalls from startup, since the first cycle may be delayed
+	s.mu.Lock()
+	s.lastCycleCompleted = time.Now()
+	s.mu.Unlock()
+	if s.stallIntervals > 0 {
+		s.wg.Add(1)
+		go s.watchdog()
+	}
+
+	// Pruning always runs, since a directory may set a retention even when
+	// the global one keeps executions forever
+	s.wg.Add(1)
+	go s.retentionLoop()
+
+	// Start the worker pool
+	for i := 0; i < s.concurrency; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	// Start ticker for periodic execution; the first cycle runs in the
+	// background so the HTTP server can become ready immediately
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
 
+		// Spread the first cycle across replicas
+		if s.startJitter > 0 {
+			delay := time.Duration(rand.Int63n(int64(s.startJitter)))
+			log.Printf("Delaying first execution cycle by %v (start jitter)", delay)
+			select {
+			case <-time.After(delay):
+			case <-s.ctx.Done():
+				return
+			}
+		}
+
+		if s.runOnStart {
+			s.runOnce(SourceStartup)
+		} else {
+			log.Printf("Skipping run on start; first cycle in %v", s.interval)
+		}
+
 		ticker := time.NewTicker(s.interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
-				s.runOnce()
+				s.runOnce(SourceScheduled)
 			case <-s.ctx.Done():
 				log.Println("Scheduler stopped")
 				return
@@ -115,8 +346,44 @@ func (s *Scheduler) Stop() {
 	log.Println("Scheduler stopped")
 }
 
-// runOnce executes all collections once
-func (s *Scheduler) runOnce() {
+// runOnce runs a cycle unless one is already running, as when a manual
+// cycle overlaps the ticker
+func (s *Scheduler) runOnce(source TriggerSource) {
+	if !s.beginCycle() {
+		log.Printf("An execution cycle is already running, skipping %s cycle", source)
+		return
+	}
+	defer s.endCycle()
+	s.runCycle(source, "")
+}
+
+// beginCycle marks a cycle as running, returning false if one already is
+func (s *Scheduler) beginCycle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cycleRunning {
+		return false
+	}
+	s.cycleRunning = true
+	return true
+}
+
+// endCycle marks the running cycle as finished
+func (s *Scheduler) endCycle() {
+	s.mu.Lock()
+	s.cycleRunning = false
+	s.mu.Unlock()
+	s.completeCycle()
+}
+
+// runCycle queues all collections once and waits for them to finish,
+// labeling each execution with version when it is set
+func (s *Scheduler) runCycle(source TriggerSource, version string) {
+	if s.inMaintenance() {
+		log.Printf("Maintenance mode is enabled, skipping %s execution cycle", source)
+		return
+	}
+
 	s.mu.Lock()
 	s.lastRunTime = time.Now()
 	s.totalRuns++
@@ -125,10 +392,9 @@ func (s *Scheduler) runOnce() {
 	log.Println("Starting test execution cycle")
 
 	// Scan for collection groups
-	groups, err := s.watcher.ScanGroups()
+	groups, err := s.scanGroups()
 	if err != nil {
-		log.Printf("Error scanning for collection groups: %v", err)
-		s.incrementFailedRuns()
+		log.Printf("Error scanning for collection groups, skipping this cycle: %v", err)
 		return
 	}
 
@@ -144,52 +410,292 @@ func (s *Scheduler) runOnce() {
 
 	log.Printf("Found %d group(s) with %d total collection(s)", len(groups), totalCollections)
 
-	// Execute collections from each group
-	var wg sync.WaitGroup
+	// Queue collections from each group, highest priority first; ordered and
+	// sequential groups run as a single task
+	var queued []<-chan struct{}
+	for _, a := range admissionOrder(s.dueCollections(groups, source)) {
+		start := func() <-chan struct{} {
+			if a.collection == nil {
+				return s.runGroup(a.group, source, version)
+			}
+			return s.enqueue(s.newJob(a.group, *a.collection, source, version)).done
+		}
+
+		// Jitter smooths bursts from timed cycles; triggered runs start at once
+		if s.collectionJitter > 0 && (source == SourceScheduled || source == SourceStartup) {
+			queued = append(queued, s.startAfter(time.Duration(rand.Int63n(int64(s.collectionJitter))), start))
+			continue
+		}
+		queued = append(queued, start())
+	}
+
+	// Wait for all executions to complete
+	if !s.wait(queued) {
+		return
+	}
+
+	s.reconcileMetrics()
+
+	log.Println("Test execution cycle completed")
+}
+
+// admission is one unit of work submitted to the queue in a cycle: a single
+// collection, or a whole ordered or sequential group when collection is nil
+type admission struct {
+	group      watcher.CollectionGroup
+	collection *watcher.CollectionFile
+	priority   watcher.Priority
+}
+
+// name identifies the admission in logs
+func (a admission) name() string {
+	if a.collection == nil {
+		return a.group.Directory + "/*"
+	}
+	return a.group.Directory + "/" + a.collection.Name
+}
+
+// admissionOrder returns the cycle's work sorted by priority, highest first,
+// keeping discovery order within a tier. Groups that run as a single task take
+// the highest priority of their collections.
+func admissionOrder(groups []watcher.CollectionGroup) []admission {
+	var admissions []admission
 	for _, group := range groups {
-		for _, col := range group.Collections {
-			wg.Add(1)
-
-			// Determine environment path for this collection
-			var envPath *string
-			var envName *string
-			if group.Environment != nil {
-				envPath = &group.Environment.FullPath
-				// Extract environment name from filename (strip .postman_environment.json)
-				name := strings.TrimSuffix(group.Environment.FileName, ".postman_environment.json")
-				envName = &name
+		if group.Config.Sequential || len(group.Config.Order) > 0 {
+			a := admission{group: group, priority: group.Config.Priority}
+			for _, col := range group.Collections {
+				if p := group.Config.PriorityOf(col.Name); p.Rank() > a.priority.Rank() {
+					a.priority = p
+				}
 			}
+			admissions = append(admissions, a)
+			continue
+		}
+		for i := range group.Collections {
+			col := &group.Collections[i]
+			admissions = append(admissions, admission{group: group, collection: col, priority: group.Config.PriorityOf(col.Name)})
+		}
+	}
+
+	sort.SliceStable(admissions, func(a, b int) bool {
+		return admissions[a].priority.Rank() > admissions[b].priority.Rank()
+	})
+
+	names := make([]string, len(admissions))
+	for i, a := range admissions {
+		names[i] = fmt.Sprintf("%s (%d)", a.name(), a.priority.Rank())
+	}
+	log.Printf("[DEBUG] Admission order: %s", strings.Join(names, ", "))
+
+	return admissions
+}
+
+// runGroup executes a group's ordered collections one at a time, then the
+// rest in parallel, or one at a time in file name order for sequential groups.
+// It returns a channel that is closed once every collection finishes.
+func (s *Scheduler) runGroup(group watcher.CollectionGroup, source TriggerSource, version string) <-chan struct{} {
+	ordered, unlisted := orderCollections(group)
+
+	names := make([]string, len(ordered))
+	for i, col := range ordered {
+		names[i] = col.Name
+	}
+	if group.Config.Sequential {
+		for _, col := range unlisted {
+			names = append(names, col.Name)
+		}
+		log.Printf("Running group %s sequentially: %s", group.Directory, strings.Join(names, " -> "))
+	} else {
+		log.Printf("Running group %s in order: %s, then %d collection(s) in parallel",
+			group.Directory, strings.Join(names, " -> "), len(unlisted))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
 
-			// Get directory name
-			dirName := group.Directory
+		for i, col := range ordered {
+			j := s.newJob(group, col, source, version)
+			position := i + 1
+			j.orderPosition = &position
+			j = s.enqueue(j)
+			if !s.wait([]<-chan struct{}{j.done}) {
+				return
+			}
+			if j.failed && group.Config.OrderBail && i < len(ordered)-1 {
+				log.Printf("Ordered collection %s in group %s failed, skipping %d remaining ordered collection(s)",
+					col.Name, group.Directory, len(ordered)-i-1)
+				break
+			}
+		}
 
-			go func(c watcher.CollectionFile, env *string, dir string, eName *string) {
-				defer wg.Done()
-				if err := s.executeCollection(c, env, dir, eName); err != nil {
-					log.Printf("Error executing collection %s: %v", c.Name, err)
+		if group.Config.Sequential {
+			for _, col := range unlisted {
+				if !s.wait([]<-chan struct{}{s.enqueue(s.newJob(group, col, source, version)).done}) {
+					return
 				}
-			}(col, envPath, dirName, envName)
+			}
+			return
 		}
+
+		var queued []<-chan struct{}
+		for _, col := range unlisted {
+			queued = append(queued, s.enqueue(s.newJob(group, col, source, version)).done)
+		}
+		s.wait(queued)
+	}()
+	return done
+}
+
+// orderCollections splits a group's collections into those listed in its
+// order config, in that order, and the rest in file name order. A collection
+// run once per data file takes its place with all of its data files.
+func orderCollections(group watcher.CollectionGroup) (ordered, unlisted []watcher.CollectionFile) {
+	byName := make(map[string][]watcher.CollectionFile, len(group.Collections))
+	for _, col := range group.Collections {
+		byName[col.Name] = append(byName[col.Name], col)
 	}
 
-	// Wait for all executions to complete
-	wg.Wait()
+	listed := make(map[string]bool, len(group.Config.Order))
+	for _, name := range group.Config.Order {
+		cols, ok := byName[name]
+		if !ok {
+			log.Printf("Warning: ordered collection %s not found in group %s", name, group.Directory)
+			continue
+		}
+		ordered = append(ordered, cols...)
+		listed[name] = true
+	}
 
-	// Update metrics
-	if s.metricsUpdater != nil {
-		results, err := s.storage.GetLatestResults()
-		if err != nil {
-			log.Printf("Error getting latest results for metrics: %v", err)
-		} else {
-			s.metricsUpdater.UpdateMetrics(results)
+	for _, col := range group.Collections {
+		if !listed[col.Name] {
+			unlisted = append(unlisted, col)
 		}
 	}
+	sort.SliceStable(unlisted, func(a, b int) bool {
+		return unlisted[a].Name < unlisted[b].Name
+	})
 
-	log.Println("Test execution cycle completed")
+	return ordered, unlisted
+}
+
+// reconcileMetrics rebuilds metrics from all the latest stored results at
+// the end of a cycle, once metricsReconcileInterval has passed since the
+// last rebuild, so removed collections drop out. Other cycles only push
+// the metrics their executions already updated.
+func (s *Scheduler) reconcileMetrics() {
+	if s.metricsUpdater == nil {
+		return
+	}
+
+	s.mu.Lock()
+	due := time.Since(s.lastMetricsReconcile) >= metricsReconcileInterval
+	if due {
+		s.lastMetricsReconcile = time.Now()
+	}
+	s.mu.Unlock()
+	if !due {
+		s.metricsUpdater.Push()
+		return
+	}
+
+	results, err := s.storage.GetLatestResults()
+	if err != nil {
+		log.Printf("Error getting latest results for metrics: %v", err)
+		return
+	}
+
+	// Attach duration trends and assertion pass rates so they can be
+	// exported, each fetched for every collection at once
+	trends, err := s.storage.GetAllDurationTrends(s.trendWindow, s.regressionFactor)
+	if err != nil {
+		log.Printf("Error getting duration trends for metrics: %v", err)
+	}
+	passRates, err := s.storage.GetAllAssertionPassRates(s.trendWindow)
+	if err != nil {
+		log.Printf("Error getting assertion pass rates for metrics: %v", err)
+	}
+	for _, group := range results.EnvironmentGroups {
+		for i := range group.Collections {
+			cr := &group.Collections[i]
+			cr.DurationTrend = trends[cr.Collection.ID]
+			if cr.Execution != nil {
+				cr.AssertionPassRates = passRates[cr.Collection.ID]
+			}
+		}
+	}
+
+	s.metricsUpdater.UpdateMetrics(results)
 }
 
-// executeCollection executes a single collection with optional environment
-func (s *Scheduler) executeCollection(col watcher.CollectionFile, environmentPath *string, directoryName string, environmentName *string) error {
+// updateCollectionMetrics updates a collection's metrics from its latest
+// stored result, right after one of its executions is stored
+func (s *Scheduler) updateCollectionMetrics(compositeKey string) {
+	if s.metricsUpdater == nil {
+		return
+	}
+
+	cr, err := s.storage.GetLatestResultByCompositeKey(compositeKey)
+	if err != nil {
+		log.Printf("Error getting latest result of %s for metrics: %v", compositeKey, err)
+		return
+	}
+	if cr == nil {
+		return
+	}
+
+	if s.attachDurationTrend(cr) {
+		log.Printf("Warning: collection %s took %dms, over %.1fx its %.0fms average",
+			compositeKey, cr.DurationTrend.LatestMs, cr.DurationTrend.Factor, cr.DurationTrend.RollingAverageMs)
+	}
+	s.attachAssertionPassRates(cr)
+
+	s.metricsUpdater.UpdateCollection(*cr)
+}
+
+// attachDurationTrend sets a collection result's duration trend so
+// regressions can be exported, reporting whether the latest run regressed
+func (s *Scheduler) attachDurationTrend(cr *storage.CollectionResult) bool {
+	trend, err := s.storage.GetDurationTrend(cr.Collection.ID, s.trendWindow, s.regressionFactor)
+	if err != nil {
+		log.Printf("Error getting duration trend for %s: %v", cr.Collection.CompositeKey, err)
+		return false
+	}
+	cr.DurationTrend = trend
+	return trend.Regression
+}
+
+// attachAssertionPassRates sets a collection result's assertion pass rates
+// over the duration trend window so they can be exported
+func (s *Scheduler) attachAssertionPassRates(cr *storage.CollectionResult) {
+	if cr.Execution == nil {
+		return
+	}
+	rates, err := s.storage.GetAssertionPassRates(cr.Collection.ID, s.trendWindow)
+	if err != nil {
+		log.Printf("Error getting assertion pass rates for %s: %v", cr.Collection.CompositeKey, err)
+		return
+	}
+	cr.AssertionPassRates = rates
+}
+
+// executeCollection executes a single queued collection with optional environment
+func (s *Scheduler) executeCollection(ctx context.Context, j *job) error {
+	col := j.collection
+	environmentPath := j.environmentPath
+	directoryName := j.directory
+	environmentName := j.environmentName
+
+	// Replicas sharing the database each run every cycle, so a scheduled
+	// collection runs only on the replica that claims it
+	if s.locker != nil && (j.source == SourceScheduled || j.source == SourceStartup) {
+		release, claimed := s.claimCollection(ctx, j)
+		if !claimed {
+			return nil
+		}
+		defer release()
+	}
+
 	if environmentPath != nil {
 		log.Printf("Executing collection: %s with environment", col.Name)
 	} else {
@@ -200,7 +706,7 @@ func (s *Scheduler) executeCollection(col watcher.CollectionFile, environmentPat
 
 	// Generate composite key and extract normalized components BEFORE execution
 	// This ensures the executor receives the same normalized values used in the composite key
-	compositeKey, dir, env, collName := GenerateCompositeKey(directoryName, environmentName, filepath.Base(col.FullPath))
+	compositeKey, dir, env, collName := s.CompositeKey(directoryName, environmentName, col.FullPath, col.DataFile)
 
 	// Execute with Newman using normalized directory and environment names
 	normalizedEnvName := &env
@@ -208,8 +714,29 @@ func (s *Scheduler) executeCollection(col watcher.CollectionFile, environmentPat
 		// If env is the placeholder "env", pass nil to executor
 		normalizedEnvName = nil
 	}
-	result, err := s.executor.Execute(col.FullPath, environmentPath, dir, normalizedEnvName)
-	if err != nil {
+	opts := j.executeOptions()
+	opts.DelayRequest = s.throttle.requestDelay()
+	result, err := s.executor.Execute(ctx, col.FullPath, environmentPath, dir, normalizedEnvName, opts)
+	if result != nil && result.RequestsTotal != nil {
+		s.throttle.record(startTime, time.Now(), *result.RequestsTotal)
+	}
+	cancelled := ctx.Err() != nil
+	if cancelled {
+		// Newman was killed before it reported, so record the run with nothing but its timing
+		log.Printf("Collection %s was cancelled after %v", col.Name, time.Since(startTime))
+		message := cancelledMessage
+		name := col.Name
+		if existing, err := s.storage.GetCollectionByCompositeKey(compositeKey); err == nil && existing != nil {
+			name = existing.Name // Keep the name Newman reported on earlier runs
+		}
+		result = &executor.NewmanResult{
+			CollectionName:  name,
+			Timestamp:       startTime.Format(time.RFC3339),
+			TotalDurationMs: int(time.Since(startTime).Milliseconds()),
+			Error:           &message,
+			Versions:        s.executor.GetToolchainVersions(),
+		}
+	} else if err != nil {
 		log.Printf("Newman execution error for %s: %v", col.Name, err)
 		// Continue to store the partial result if available
 		if result == nil {
@@ -218,11 +745,21 @@ func (s *Scheduler) executeCollection(col watcher.CollectionFile, environmentPat
 		}
 	}
 
+	// Siblings run from one collection with different data files report the
+	// same name, so tell them apart by their data file variant
+	if col.Variant != "" && !cancelled {
+		result.CollectionName = fmt.Sprintf("%s (%s)", result.CollectionName, col.Variant)
+	}
+
 	// Debug logging
 	log.Printf("[DEBUG] Composite key generation: dir=%s, env=%s, collection=%s -> key=%s", dir, env, collName, compositeKey)
 
+	// Bound concurrent database writes so they can't starve the connection pool
+	release := s.acquireWriteSlot(col.Name)
+	defer release()
+
 	// Ensure collection exists in database with composite key
-	dbCollection, err := s.storage.UpsertCollection(result.CollectionName, col.FullPath, compositeKey, dir, env, collName)
+	dbCollection, err := s.storage.UpsertCollection(result.CollectionName, col.FullPath, compositeKey, dir, env, collName, j.config.Annotations, ownerRules(j.config.Owners), optionalString(col.DataFile))
 	if err != nil {
 		log.Printf("Error upserting collection %s: %v", col.Name, err)
 		s.incrementFailedRuns()
@@ -235,17 +772,56 @@ func (s *Scheduler) executeCollection(col watcher.CollectionFile, environmentPat
 		timestamp = startTime
 	}
 
+	// Tests expected to fail count as passing when they fail, before anything
+	// derives a status from the results
+	applyExpectedFailures(result, j.config.ExpectedFailures)
+
+	truncated := s.maxResultsPerExecution > 0 && len(result.Tests) > s.maxResultsPerExecution
+	if truncated {
+		log.Printf("Warning: collection %s produced %d results, storing only the first %d",
+			col.Name, len(result.Tests), s.maxResultsPerExecution)
+	}
+
 	// Create execution record
 	execution := &storage.TestExecution{
-		CollectionID:   dbCollection.ID,
-		CollectionName: result.CollectionName,
-		StartedAt:      timestamp,
-		CompletedAt:    timestamp.Add(time.Duration(result.TotalDurationMs) * time.Millisecond),
-		DurationMs:     result.TotalDurationMs,
-		TotalTests:     result.Summary.Total,
-		PassedTests:    result.Summary.Passed,
-		FailedTests:    result.Summary.Failed,
-		Error:          result.Error,
+		CollectionID:      dbCollection.ID,
+		CollectionName:    result.CollectionName,
+		StartedAt:         timestamp,
+		CompletedAt:       timestamp.Add(time.Duration(result.TotalDurationMs) * time.Millisecond),
+		DurationMs:        result.TotalDurationMs,
+		TotalTests:        result.Summary.Total,
+		PassedTests:       result.Summary.Passed,
+		FailedTests:       result.Summary.Failed,
+		Error:             s.truncateError(result.Error),
+		ProxyUsed:         result.ProxyUsed,
+		NodeVersion:       optionalString(result.Versions.Node),
+		NewmanVersion:     optionalString(result.Versions.Newman),
+		Overridden:        len(j.overrides) > 0 || j.replayOf != nil,
+		ErrorCategory:     optionalString(string(result.Classify())),
+		TriggerSource:     string(j.source),
+		ResultCount:       len(result.Tests),
+		Truncated:         truncated,
+		OrderPosition:     j.orderPosition,
+		RequestsTotal:     result.RequestsTotal,
+		TransferredBytes:  result.TransferredBytes,
+		TestedVersion:     j.testedVersion(result.TestedVersion),
+		Cancelled:         cancelled,
+		EnvironmentLayers: result.EnvironmentLayers,
+		NewmanArgs:        j.config.NewmanArgs,
+		ReplayOf:          j.replayOf,
+		Replica:           optionalString(s.replica),
+	}
+	if cancelled {
+		execution.ErrorCategory = nil
+	}
+	override, err := s.storage.GetCollectionOverride(dbCollection.ID)
+	if err != nil {
+		log.Printf("Error loading override for %s, using its file config: %v", compositeKey, err)
+	}
+	execution.PassThreshold = s.EffectiveConfig(j.config, override).PassThreshold
+	if execution.Status() == storage.StatusPassing && exceedsWarnThreshold(result, j.config.WarnResponseTimeMs) {
+		execution.Warning = true
+		log.Printf("Collection %s passed but a request took longer than %dms", col.Name, j.config.WarnResponseTimeMs)
 	}
 
 	if err := s.storage.CreateTestExecution(execution); err != nil {
@@ -253,16 +829,25 @@ func (s *Scheduler) executeCollection(col watcher.CollectionFile, environmentPat
 		s.incrementFailedRuns()
 		return err
 	}
+	j.executionID = execution.ID
 
-	// Store test results
-	for _, test := range result.Tests {
-		testResult := &storage.TestResult{
-			ExecutionID:   execution.ID,
-			TestName:      test.Name,
-			ExecutionName: &test.ExecutionName,
-			Status:        "unknown",
-			Passed:        test.Passed,
-			Error:         test.Error,
+	// Store test results, capped so a runaway collection can't flood the table
+	tests := result.Tests
+	if truncated {
+		tests = tests[:s.maxResultsPerExecution]
+	}
+	testResults := make([]storage.TestResult, 0, len(tests)+1)
+	for _, test := range tests {
+		testResult := storage.TestResult{
+			ExecutionID:     execution.ID,
+			TestName:        test.Name,
+			ExecutionName:   &test.ExecutionName,
+			Status:          "unknown",
+			Passed:          test.Passed,
+			Error:           s.truncateError(test.Error),
+			Sequence:        test.Sequence,
+			Detail:          assertionDetail(test.Detail),
+			ExpectedFailure: test.ExpectedFailure,
 		}
 
 		// Try to find matching execution info
@@ -273,21 +858,69 @@ func (s *Scheduler) executeCollection(col watcher.CollectionFile, environmentPat
 				testResult.Status = exec.Status
 				testResult.StatusCode = exec.StatusCode
 				testResult.ResponseTimeMs = exec.ResponseTime
+				for _, header := range exec.Headers {
+					testResult.Headers = append(testResult.Headers, storage.ResponseHeader{
+						Name:  header.Name,
+						Value: header.Value,
+					})
+				}
+				for _, redirect := range exec.Redirects {
+					testResult.Redirects = append(testResult.Redirects, storage.Redirect{
+						StatusCode: redirect.StatusCode,
+						Location:   redirect.Location,
+					})
+				}
 				break
 			}
 		}
 
-		if err := s.storage.CreateTestResult(testResult); err != nil {
-			log.Printf("Error creating test result for %s: %v", test.Name, err)
-		}
+		testResults = append(testResults, testResult)
+	}
+
+	if truncated {
+		message := fmt.Sprintf("Stored %d of %d results; the remaining %d were dropped (MAX_RESULTS_PER_EXECUTION)",
+			len(tests), len(result.Tests), len(result.Tests)-len(tests))
+		testResults = append(testResults, storage.TestResult{
+			ExecutionID: execution.ID,
+			TestName:    "[scout] results truncated",
+			Status:      storage.ResultStatusTruncated,
+			Passed:      true,
+			Error:       &message,
+		})
+	}
+
+	if err := s.storage.CreateTestResults(testResults); err != nil {
+		log.Printf("Error creating test results for %s: %v", col.Name, err)
+	}
+	if err := s.storage.CreateRequestResults(s.requestResults(dbCollection, execution.ID, result)); err != nil {
+		log.Printf("Error creating request results for %s: %v", col.Name, err)
+	}
+	if err := s.storage.CreateExecutionVariables(execution.ID, collectionVariables(result.CollectionVariables), collectionVariables(result.InitialVariables)); err != nil {
+		log.Printf("Error recording collection variables for %s: %v", col.Name, err)
+	}
+	release()
+
+	if s.reports != nil {
+		s.storeReport(compositeKey, execution, result)
 	}
 
+	j.failed = execution.Status() == storage.StatusFailing
+	s.handleTransition(dbCollection, execution)
+	s.checkLatency(dbCollection, execution, j.config)
+	s.bumpResultsVersion()
+	s.updateCollectionMetrics(compositeKey)
+
 	duration := time.Since(startTime)
 	status := "SUCCESS"
-	if result.Summary.Failed > 0 && result.Summary.Passed > 0 {
+	if cancelled {
+		status = "CANCELLED"
+	} else if result.Summary.Failed > 0 && result.Summary.Passed > 0 {
 		status = "PARTIAL"
 	} else if result.Summary.Failed > 0 {
 		status = "FAILED"
+	} else if execution.Status() == storage.StatusNoTests {
+		status = "NO TESTS"
+		log.Printf("Collection %s made no assertions; add test scripts so its results are meaningful", col.Name)
 	}
 
 	log.Printf("Collection %s completed in %v - Status: %s (Passed: %d, Failed: %d)",
@@ -296,6 +929,173 @@ func (s *Scheduler) executeCollection(col watcher.CollectionFile, environmentPat
 	return nil
 }
 
+// storeReport writes the full Newman result to the report store, including
+// results beyond MAX_RESULTS_PER_EXECUTION, and records its key on the
+// execution. Failures are logged; the execution itself is already stored.
+func (s *Scheduler) storeReport(compositeKey string, execution *storage.TestExecution, result *executor.NewmanResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Error encoding report for %s: %v", compositeKey, err)
+		return
+	}
+
+	key := reports.Key(compositeKey, execution.ID)
+	ctx, cancel := context.WithTimeout(s.ctx, reportTimeout)
+	defer cancel()
+	if err := s.reports.Put(ctx, key, data); err != nil {
+		log.Printf("Error storing report for %s in %s store: %v", compositeKey, s.reports.Name(), err)
+		return
+	}
+	if err := s.storage.SetReportKey(execution.ID, key); err != nil {
+		log.Printf("Error recording report key for %s: %v", compositeKey, err)
+		return
+	}
+	execution.ReportKey = &key
+}
+
+// handleTransition notifies on status changes and clears acknowledgments once
+// a collection passes again. Overridden and cancelled runs don't affect either.
+func (s *Scheduler) handleTransition(collection *storage.Collection, execution *storage.TestExecution) {
+	if execution.Overridden || execution.Cancelled {
+		return
+	}
+
+	if s.notifier != nil && s.notifier.EnabledFor(collection) {
+		previous, err := s.storage.GetPreviousExecution(collection.ID, execution.ID)
+		if err != nil {
+			log.Printf("Error fetching previous execution for %s: %v", collection.CompositeKey, err)
+		} else {
+			s.notifier.HandleExecution(collection, previous, execution)
+		}
+	}
+
+	if execution.Status() != storage.StatusFailing {
+		cleared, err := s.storage.ClearAck(collection.ID)
+		if err != nil {
+			log.Printf("Error clearing acknowledgment for %s: %v", collection.CompositeKey, err)
+		} else if cleared {
+			log.Printf("Collection %s passed, cleared acknowledgment", collection.CompositeKey)
+		}
+	}
+}
+
+// collectionVariables converts the collection variables Newman reported for storage
+func collectionVariables(variables []executor.CollectionVariable) []storage.CollectionVariable {
+	converted := make([]storage.CollectionVariable, len(variables))
+	for i, v := range variables {
+		converted[i] = storage.CollectionVariable{Name: v.Key, Value: v.Value, Overridden: v.Overridden}
+	}
+	return converted
+}
+
+// assertionDetail converts structured failure detail from Newman for storage
+func assertionDetail(detail *executor.AssertionDetail) *storage.AssertionDetail {
+	if detail == nil {
+		return nil
+	}
+	converted := &storage.AssertionDetail{
+		Path:     detail.Path,
+		Expected: detail.Expected,
+		Actual:   detail.Actual,
+	}
+	for _, schemaError := range detail.SchemaErrors {
+		converted.SchemaErrors = append(converted.SchemaErrors, storage.SchemaError(schemaError))
+	}
+	return converted
+}
+
+// ownerRules converts a directory's owner rules for storage with its collections
+func ownerRules(rules []watcher.OwnerRule) storage.OwnerRules {
+	if len(rules) == 0 {
+		return nil
+	}
+	converted := make(storage.OwnerRules, len(rules))
+	for i, rule := range rules {
+		converted[i] = storage.OwnerRule(rule)
+	}
+	return converted
+}
+
+// exceedsWarnThreshold reports whether any request in the run took longer than
+// thresholdMs; a zero threshold never warns
+func exceedsWarnThreshold(result *executor.NewmanResult, thresholdMs int) bool {
+	if thresholdMs <= 0 {
+		return false
+	}
+	for _, exec := range result.Executions {
+		if exec.ResponseTime != nil && *exec.ResponseTime > thresholdMs {
+			return true
+		}
+	}
+	return false
+}
+
+// acquireWriteSlot blocks until a database write slot is free and returns an
+// idempotent release func. Long waits are logged since they mean writes, not
+// Newman, are the bottleneck.
+func (s *Scheduler) acquireWriteSlot(name string) func() {
+	start := time.Now()
+	s.writeSlots <- struct{}{}
+	if wait := time.Since(start); wait > writeWaitWarning {
+		log.Printf("Waited %v for a database write slot for %s", wait, name)
+	}
+	return sync.OnceFunc(func() { <-s.writeSlots })
+}
+
+// truncateError shortens an error string to the configured maximum, noting the
+// original length so giant validation dumps don't bloat the results tables
+func (s *Scheduler) truncateError(message *string) *string {
+	if message == nil || s.maxErrorLength <= 0 || len(*message) <= s.maxErrorLength {
+		return message
+	}
+
+	// Back up to a rune boundary so the stored string stays valid UTF-8
+	cut := s.maxErrorLength
+	for cut > 0 && !utf8.RuneStart((*message)[cut]) {
+		cut--
+	}
+
+	truncated := fmt.Sprintf("%s...(truncated, %d bytes total)", (*message)[:cut], len(*message))
+	return &truncated
+}
+
+// optionalString returns nil for empty strings so they are stored as NULL
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// scanGroups scans for collection groups, retrying transient failures with
+// backoff. It counts each retry, and a scan failure once all attempts fail.
+func (s *Scheduler) scanGroups() ([]watcher.CollectionGroup, error) {
+	delay := scanRetryDelay
+	for attempt := 1; ; attempt++ {
+		groups, err := s.watcher.ScanGroups()
+		if err == nil {
+			return groups, nil
+		}
+		if attempt == scanAttempts {
+			s.mu.Lock()
+			s.scanFailures++
+			s.mu.Unlock()
+			return nil, fmt.Errorf("%d attempts failed: %w", scanAttempts, err)
+		}
+
+		log.Printf("Scanning for collection groups failed (attempt %d of %d), retrying in %v: %v", attempt, scanAttempts, delay, err)
+		s.mu.Lock()
+		s.scanRetries++
+		s.mu.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
 // incrementFailedRuns increments the failed runs counter
 func (s *Scheduler) incrementFailedRuns() {
 	s.mu.Lock()
@@ -310,13 +1110,140 @@ func (s *Scheduler) GetStats() map[string]interface{} {
 
 	return map[string]interface{}{
 		"last_run_time": s.lastRunTime,
+		"cycle_running": s.cycleRunning,
+		"stalled":       s.stalled,
 		"total_runs":    s.totalRuns,
 		"failed_runs":   s.failedRuns,
+		"scan_retries":  s.scanRetries,
+		"scan_failures": s.scanFailures,
 		"interval":      s.interval.String(),
+		"db_pool":       s.storage.PoolStats(),
+		"throttle":      s.throttle.stats(),
+		"host_groups":   s.hostGroups.stats(),
+	}
+}
+
+// Interval returns the global interval cycles tick at, the shortest interval
+// a collection can run at
+func (s *Scheduler) Interval() time.Duration {
+	return s.interval
+}
+
+// ResultsVersion changes whenever an execution is stored, so cached results
+// can tell they are out of date
+func (s *Scheduler) ResultsVersion() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.resultsVersion
+}
+
+// bumpResultsVersion records that an execution was stored
+func (s *Scheduler) bumpResultsVersion() {
+	s.mu.Lock()
+	s.resultsVersion++
+	s.mu.Unlock()
+}
+
+// RunNow triggers an immediate execution cycle in the background, labeling
+// its executions with version when set. It returns ErrCycleRunning without
+// starting anything if a cycle is already running.
+func (s *Scheduler) RunNow(source TriggerSource, version string) error {
+	if !s.beginCycle() {
+		return ErrCycleRunning
+	}
+	go func() {
+		defer s.endCycle()
+		s.runCycle(source, version)
+	}()
+	return nil
+}
+
+// RunCollection queues a single collection by ID, with optional environment
+// variable overrides and version label that apply to this execution only
+func (s *Scheduler) RunCollection(collectionID int, overrides []executor.EnvVar, source TriggerSource, version string) error {
+	if s.inMaintenance() {
+		return ErrMaintenance
+	}
+
+	j, err := s.collectionJob(collectionID, source, version)
+	if err != nil {
+		return err
+	}
+	j.overrides = overrides
+	s.runJob(j)
+	return nil
+}
+
+// RunCollectionSync runs a collection like RunCollection and waits for it to
+// finish, returning the ID of its execution. If ctx ends first its error is
+// returned, and the run carries on and is recorded in the background. A run
+// coalesced with one already queued waits for that run instead.
+func (s *Scheduler) RunCollectionSync(ctx context.Context, collectionID int, overrides []executor.EnvVar, source TriggerSource, version string) (int, error) {
+	if s.inMaintenance() {
+		return 0, ErrMaintenance
+	}
+
+	j, err := s.collectionJob(collectionID, source, version)
+	if err != nil {
+		return 0, err
+	}
+	j.overrides = overrides
+
+	select {
+	case executionID := <-s.runJob(j):
+		if executionID == 0 {
+			return 0, fmt.Errorf("collection %d finished without recording an execution", collectionID)
+		}
+		return executionID, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// collectionJob builds a job for a collection by ID from its files on disk
+func (s *Scheduler) collectionJob(collectionID int, source TriggerSource, version string) (*job, error) {
+	collection, err := s.storage.GetCollectionByID(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if collection == nil {
+		return nil, fmt.Errorf("%w: %d", ErrCollectionNotFound, collectionID)
+	}
+
+	groups, err := s.watcher.ScanGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan collection groups: %w", err)
 	}
+
+	for _, group := range groups {
+		for _, col := range group.Collections {
+			if j := s.newJob(group, col, source, version); j.compositeKey == collection.CompositeKey {
+				if col.Disabled {
+					return nil, fmt.Errorf("%w: %s is named %s", ErrCollectionDisabled, collection.CompositeKey, col.Name)
+				}
+				return j, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s is no longer on disk", ErrCollectionNotFound, collection.CompositeKey)
 }
 
-// RunNow triggers an immediate execution cycle
-func (s *Scheduler) RunNow() {
-	go s.runOnce()
+// runJob queues a single job in the background, pushing metrics once it
+// finishes. The returned channel receives the ID of the execution the job, or
+// the queued job it was coalesced with, recorded; 0 if none was recorded.
+func (s *Scheduler) runJob(j *job) <-chan int {
+	result := make(chan int, 1)
+	go func() {
+		queued := s.enqueue(j)
+		if !s.wait([]<-chan struct{}{queued.done}) {
+			result <- 0
+			return
+		}
+		if s.metricsUpdater != nil {
+			s.metricsUpdater.Push()
+		}
+		result <- queued.executionID
+	}()
+	return result
 }
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateError(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name      string
+		maxLength int
+		message   *string
+		want      *string
+	}{
+		{name: "nil", maxLength: 8, message: nil, want: nil},
+		{name: "short", maxLength: 8, message: ptr("timeout"), want: ptr("timeout")},
+		{name: "exactly the limit", maxLength: 7, message: ptr("timeout"), want: ptr("timeout")},
+		{name: "unlimited", maxLength: 0, message: ptr(strings.Repeat("x", 10000)), want: ptr(strings.Repeat("x", 10000))},
+		{
+			name:      "oversized",
+			maxLength: 16,
+			message:   ptr(strings.Repeat("x", 10000)),
+			want:      ptr(strings.Repeat("x", 16) + "...(truncated, 10000 bytes total)"),
+		},
+		{
+			// "é" is two bytes, so the limit falls inside the fourth one
+			name:      "multibyte boundary",
+			maxLength: 7,
+			message:   ptr("éééééé"),
+			want:      ptr("ééé...(truncated, 12 bytes total)"),
+		},
+		{
+			// "😀" is four bytes; a limit inside the first leaves nothing of it
+			name:      "limit inside the first rune",
+			maxLength: 3,
+			message:   ptr("😀😀"),
+			want:      ptr("...(truncated, 8 bytes total)"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler{maxErrorLength: tt.maxLength}
+			got := s.truncateError(tt.message)
+
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("truncateError() = %q, want nil", *got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("truncateError() = nil, want %q", *tt.want)
+			}
+			if *got != *tt.want {
+				t.Errorf("truncateError() = %q, want %q", *got, *tt.want)
+			}
+			if !utf8.ValidString(*got) {
+				t.Errorf("truncateError() = %q, which is not valid UTF-8", *got)
+			}
+		})
+	}
+}
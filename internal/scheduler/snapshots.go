@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"log"
+
+	"github.com/josepht96/scout/internal/executor"
+	"github.com/josepht96/scout/internal/storage"
+)
+
+// requestResults returns the run's requests that have a snapshot or timings,
+// in the order they were sent. Snapshots are flagged changed when their hash
+// differs from the request's previous snapshot; a request's first snapshot is
+// never a change.
+func (s *Scheduler) requestResults(collection *storage.Collection, executionID int, result *executor.NewmanResult) []storage.RequestResult {
+	var requests []storage.RequestResult
+	snapshotted := false
+	for i, exec := range result.Executions {
+		if exec.Snapshot == nil && exec.Timings == nil {
+			continue
+		}
+		request := storage.RequestResult{ExecutionID: executionID, Name: exec.Name, Sequence: i}
+		if exec.Snapshot != nil {
+			request.Snapshot = &storage.ResponseSnapshot{Hash: exec.Snapshot.Hash, Body: exec.Snapshot.Body}
+			snapshotted = true
+		}
+		if exec.Timings != nil {
+			request.Timings = &storage.RequestTimings{
+				DNSMs:       exec.Timings.DNS,
+				ConnectMs:   exec.Timings.Connect,
+				TLSMs:       exec.Timings.TLS,
+				FirstByteMs: exec.Timings.FirstByte,
+				DownloadMs:  exec.Timings.Download,
+			}
+		}
+		requests = append(requests, request)
+	}
+	if !snapshotted {
+		return requests
+	}
+
+	previous, err := s.storage.GetPreviousSnapshots(collection.ID, executionID)
+	if err != nil {
+		log.Printf("Error loading previous snapshots of %s, not detecting changes: %v", collection.CompositeKey, err)
+		return requests
+	}
+	for _, request := range requests {
+		snapshot := request.Snapshot
+		if snapshot == nil {
+			continue
+		}
+		hash, ok := previous[request.Name]
+		if !ok {
+			continue
+		}
+		snapshot.PreviousHash = &hash
+		if hash != snapshot.Hash {
+			snapshot.Changed = true
+			log.Printf("Response body of request %s in %s changed since its previous snapshot", request.Name, collection.CompositeKey)
+		}
+	}
+	return requests
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/josepht96/scout/internal/executor"
+	"github.com/josepht96/scout/internal/storage"
+)
+
+func TestRequestResultsWithoutSnapshots(t *testing.T) {
+	ms := func(v float64) *float64 { return &v }
+	result := &executor.NewmanResult{
+		Executions: []executor.ExecutionInfo{
+			{Name: "login", Timings: &executor.RequestTimings{DNS: ms(3), FirstByte: ms(40)}},
+			// No response, so nothing to store
+			{Name: "unreachable"},
+			{Name: "list orders", Timings: &executor.RequestTimings{FirstByte: ms(25)}},
+		},
+	}
+	// No test references either request; they are stored anyway
+
+	requests := (&Scheduler{}).requestResults(&storage.Collection{ID: 1}, 7, result)
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want the 2 with timings", len(requests))
+	}
+	for i, want := range []struct {
+		name      string
+		sequence  int
+		firstByte float64
+	}{{"login", 0, 40}, {"list orders", 2, 25}} {
+		r := requests[i]
+		if r.Name != want.name || r.Sequence != want.sequence || r.ExecutionID != 7 {
+			t.Errorf("request %d = %s #%d of execution %d, want %s #%d of 7", i, r.Name, r.Sequence, r.ExecutionID, want.name, want.sequence)
+		}
+		if r.Timings == nil || r.Timings.FirstByteMs == nil || *r.Timings.FirstByteMs != want.firstByte {
+			t.Errorf("request %s timings = %+v, want first byte %vms", r.Name, r.Timings, want.firstByte)
+		}
+		if r.Snapshot != nil {
+			t.Errorf("request %s has a snapshot it wasn't flagged for", r.Name)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// throttleWindow is how far back observed request rates are measured
+const throttleWindow = time.Minute
+
+// throttle spreads a global requests-per-second budget across the worker
+// pool. Newman sends a collection's requests one at a time, so spacing them
+// by concurrency/rps bounds the combined rate even with every worker busy.
+type throttle struct {
+	rps   float64
+	delay time.Duration
+	mu    sync.Mutex
+	runs  []throttledRun
+}
+
+// throttledRun is a finished execution's requests, spread over its duration
+type throttledRun struct {
+	start    time.Time
+	end      time.Time
+	requests int
+}
+
+// ThrottleStats describes the global request budget and how much of it
+// recently finished executions used
+type ThrottleStats struct {
+	RPS            float64 `json:"rps"`
+	DelayRequestMs int64   `json:"delay_request_ms"`
+	ObservedRPS    float64 `json:"observed_rps"`
+	Utilization    float64 `json:"utilization"`
+	Window         string  `json:"window"`
+}
+
+// newThrottle creates a throttle for rps shared by concurrency workers, or
+// nil when rps is not positive (no limit)
+func newThrottle(rps float64, concurrency int) *throttle {
+	if rps <= 0 {
+		return nil
+	}
+	// Round up so the per-worker rate never exceeds its share
+	delay := time.Duration(math.Ceil(float64(concurrency)/rps*1000)) * time.Millisecond
+	return &throttle{rps: rps, delay: delay}
+}
+
+// requestDelay returns the delay between requests each Newman run should use
+func (t *throttle) requestDelay() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return t.delay
+}
+
+// record adds a finished execution's request count to the observed rate
+func (t *throttle) record(start, end time.Time, requests int) {
+	if t == nil || requests <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.runs = append(t.runs, throttledRun{start: start, end: end, requests: requests})
+	t.prune(end)
+}
+
+// prune drops runs that ended before the observation window. Must hold t.mu.
+func (t *throttle) prune(now time.Time) {
+	cutoff := now.Add(-throttleWindow)
+	kept := t.runs[:0]
+	for _, run := range t.runs {
+		if run.end.After(cutoff) {
+			kept = append(kept, run)
+		}
+	}
+	t.runs = kept
+}
+
+// stats returns the budget and observed rate over the last window, or nil
+// when no budget is configured. Each run's requests are assumed to be evenly
+// spread over its duration.
+func (t *throttle) stats() *ThrottleStats {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.prune(now)
+	windowStart := now.Add(-throttleWindow)
+
+	var requests float64
+	for _, run := range t.runs {
+		duration := run.end.Sub(run.start)
+		if duration <= 0 {
+			requests += float64(run.requests)
+			continue
+		}
+		overlapStart := run.start
+		if overlapStart.Before(windowStart) {
+			overlapStart = windowStart
+		}
+		overlap := run.end.Sub(overlapStart)
+		requests += float64(run.requests) * overlap.Seconds() / duration.Seconds()
+	}
+
+	observed := requests / throttleWindow.Seconds()
+	return &ThrottleStats{
+		RPS:            t.rps,
+		DelayRequestMs: t.delay.Milliseconds(),
+		ObservedRPS:    math.Round(observed*100) / 100,
+		Utilization:    math.Round(observed/t.rps*1000) / 1000,
+		Window:         throttleWindow.String(),
+	}
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// maxTestedVersionLength bounds a tested version label; long enough for a
+// full commit SHA with a tag prefix
+const maxTestedVersionLength = 128
+
+// testedVersionPattern allows version strings and commit SHAs such as
+// "v1.4.2", "1.4.2+build.7" or "release/2024-06@3f2c1ab"
+var testedVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+/:@-]*$`)
+
+// ParseTestedVersion validates the version or commit a run is labeled with
+func ParseTestedVersion(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > maxTestedVersionLength {
+		return "", fmt.Errorf("version must be at most %d characters", maxTestedVersionLength)
+	}
+	if !testedVersionPattern.MatchString(value) {
+		return "", fmt.Errorf("invalid version %q: use letters, digits and . _ + / : @ -", value)
+	}
+	return value, nil
+}
+
+// testedVersion picks the version an execution is recorded against: the label
+// the run was triggered with, otherwise the one the collection reported
+func (j *job) testedVersion(reported *string) *string {
+	if j.version != "" {
+		return &j.version
+	}
+	if reported == nil {
+		return nil
+	}
+	version, err := ParseTestedVersion(*reported)
+	if err != nil {
+		log.Printf("Ignoring tested version reported by %s: %v", j.compositeKey, err)
+		return nil
+	}
+	return &version
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/josepht96/scout/internal/notifier"
+)
+
+// maxStallCheckInterval bounds how long a stall can go unnoticed once it
+// passes the threshold
+const maxStallCheckInterval = 30 * time.Second
+
+// stallThreshold is how long without a completed cycle counts as a stall,
+// or 0 when stall detection is disabled
+func (s *Scheduler) stallThreshold() time.Duration {
+	return time.Duration(s.stallIntervals) * s.interval
+}
+
+// watchdog checks for a stalled scheduler on its own timer, so it still fires
+// when the scheduler loop or a cycle is wedged
+func (s *Scheduler) watchdog() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(min(s.interval, maxStallCheckInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkStall()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkStall flags the scheduler as stalled when no cycle has completed
+// within the threshold. Cycles skipped for maintenance still count as
+// completed, since the loop is alive.
+func (s *Scheduler) checkStall() {
+	s.mu.RLock()
+	lastCompleted := s.lastCycleCompleted
+	stalled := s.stalled
+	s.mu.RUnlock()
+
+	since := time.Since(lastCompleted)
+	if stalled || since <= s.stallThreshold() {
+		return
+	}
+
+	s.mu.Lock()
+	if s.stalled {
+		s.mu.Unlock()
+		return
+	}
+	s.stalled = true
+	s.mu.Unlock()
+
+	log.Printf("Warning: scheduler stalled, no execution cycle has completed in %v (last completed %s)",
+		since.Round(time.Second), lastCompleted.Format(time.RFC3339))
+	s.setStalledMetric(true)
+	s.notifyStall(notifier.EventSchedulerStalled, lastCompleted)
+}
+
+// completeCycle records a finished cycle, clearing a stall
+func (s *Scheduler) completeCycle() {
+	now := time.Now()
+	s.mu.Lock()
+	s.lastCycleCompleted = now
+	stalled := s.stalled
+	s.stalled = false
+	s.mu.Unlock()
+
+	if stalled {
+		log.Printf("Scheduler resumed, an execution cycle completed")
+		s.setStalledMetric(false)
+		s.notifyStall(notifier.EventSchedulerResumed, now)
+	}
+}
+
+// Stalled reports whether no cycle has completed within the stall threshold
+func (s *Scheduler) Stalled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stalled
+}
+
+// setStalledMetric exports the stall state when metrics are enabled
+func (s *Scheduler) setStalledMetric(stalled bool) {
+	if s.metricsUpdater != nil {
+		s.metricsUpdater.SetSchedulerStalled(stalled)
+	}
+}
+
+// notifyStall sends a scheduler stall or resume notification to every notifier
+func (s *Scheduler) notifyStall(event string, at time.Time) {
+	if s.notifier == nil || !s.notifier.Enabled() {
+		return
+	}
+	s.notifier.Send(notifier.Notification{
+		Event:     event,
+		Timestamp: at,
+	})
+}
@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// EncryptedExtension marks an environment file as encrypted regardless of its contents
+const EncryptedExtension = ".enc"
+
+// encryptedHeaders are prefixes of encrypted files recognized without the extension
+var encryptedHeaders = []string{
+	"age-encryption.org/v1",              // age binary format
+	"-----BEGIN AGE ENCRYPTED FILE-----", // age armored format
+	"vault:v",                            // Vault transit ciphertext
+}
+
+// maxDecryptErrorLength bounds the decryptor output quoted in errors
+const maxDecryptErrorLength = 512
+
+// Decryptor decrypts encrypted environment files before Newman reads them.
+// Implementations must not log or return plaintext in errors.
+type Decryptor interface {
+	// Name identifies the decryptor in logs and errors
+	Name() string
+	// Decrypt returns the plaintext of an encrypted file's contents
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// IsEncrypted reports whether a file is encrypted, by its .enc extension or
+// by a recognized header at the start of its contents
+func IsEncrypted(name string, data []byte) bool {
+	if strings.HasSuffix(strings.ToLower(name), EncryptedExtension) {
+		return true
+	}
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	for _, header := range encryptedHeaders {
+		if bytes.HasPrefix(trimmed, []byte(header)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandDecryptor decrypts by running an external command with the
+// ciphertext on stdin and reading the plaintext from stdout, which covers
+// age, sops, gpg or a wrapper script around Vault transit
+type CommandDecryptor struct {
+	args []string
+}
+
+// NewCommandDecryptor creates a decryptor running args[0] with args[1:]
+func NewCommandDecryptor(args []string) (*CommandDecryptor, error) {
+	if len(args) == 0 {
+		return nil, errors.New("decrypt command is empty")
+	}
+	return &CommandDecryptor{args: args}, nil
+}
+
+// Name implements Decryptor
+func (d *CommandDecryptor) Name() string {
+	return d.args[0]
+}
+
+// Decrypt implements Decryptor. Only stderr is quoted on failure; stdout may
+// hold partial plaintext and is discarded.
+func (d *CommandDecryptor) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, d.args[0], d.args[1:]...)
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > maxDecryptErrorLength {
+			message = message[:maxDecryptErrorLength] + "..."
+		}
+		if message == "" {
+			return nil, fmt.Errorf("%s failed: %w", d.Name(), err)
+		}
+		return nil, fmt.Errorf("%s failed: %w: %s", d.Name(), err, message)
+	}
+	return stdout.Bytes(), nil
+}
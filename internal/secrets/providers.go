@@ -0,0 +1,59 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvProvider resolves {{env:NAME}} from the Scout process environment
+type EnvProvider struct{}
+
+// Scheme implements Provider
+func (EnvProvider) Scheme() string {
+	return "env"
+}
+
+// Resolve implements Provider
+func (EnvProvider) Resolve(_ context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable is not set")
+	}
+	return value, nil
+}
+
+// FileProvider resolves {{file:/path}} to a file's trimmed contents, or
+// {{file:/path#field}} to a top-level string field of a JSON file, which
+// covers secrets mounted by Kubernetes, Docker or a Vault agent
+type FileProvider struct{}
+
+// Scheme implements Provider
+func (FileProvider) Scheme() string {
+	return "file"
+}
+
+// Resolve implements Provider
+func (FileProvider) Resolve(_ context.Context, ref string) (string, error) {
+	path, field, hasField := strings.Cut(ref, "#")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !hasField {
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("file is not a JSON object: %w", err)
+	}
+	value, ok := fields[field].(string)
+	if !ok {
+		return "", fmt.Errorf("field %q is missing or not a string", field)
+	}
+	return value, nil
+}
@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// referencePattern matches secret references such as {{file:/run/secrets/db#password}}
+var referencePattern = regexp.MustCompile(`\{\{([a-z][a-z0-9-]*):([^{}]+)\}\}`)
+
+// Provider resolves secret references for a single scheme. Implement it and
+// pass it to NewResolver to add a backend such as Vault or AWS Secrets Manager.
+type Provider interface {
+	// Scheme is the prefix used in references, e.g. "vault" for {{vault:path#field}}
+	Scheme() string
+	// Resolve returns the secret value for the part of the reference after the scheme
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Resolver expands secret references using registered providers, caching
+// resolved values for a short TTL so backends aren't queried every cycle
+type Resolver struct {
+	providers map[string]Provider
+	ttl       time.Duration
+	mu        sync.Mutex
+	cache     map[string]cachedSecret
+}
+
+// cachedSecret is a resolved value and when it stops being reused
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewResolver creates a resolver for the given providers; a ttl of 0 disables caching
+func NewResolver(ttl time.Duration, providers ...Provider) *Resolver {
+	r := &Resolver{
+		providers: make(map[string]Provider),
+		ttl:       ttl,
+		cache:     make(map[string]cachedSecret),
+	}
+	for _, p := range providers {
+		r.providers[p.Scheme()] = p
+	}
+	return r
+}
+
+// HasReference reports whether value contains any secret references
+func HasReference(value string) bool {
+	return referencePattern.MatchString(value)
+}
+
+// Expand replaces every secret reference in value, returning the expanded
+// value and the secrets it resolved so callers can mask them
+func (r *Resolver) Expand(ctx context.Context, value string) (string, []string, error) {
+	var resolved []string
+	var firstErr error
+
+	expanded := referencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if firstErr != nil {
+			return match
+		}
+		parts := referencePattern.FindStringSubmatch(match)
+		secret, err := r.resolve(ctx, parts[1], parts[2])
+		if err != nil {
+			firstErr = err
+			return match
+		}
+		resolved = append(resolved, secret)
+		return secret
+	})
+	if firstErr != nil {
+		return "", nil, firstErr
+	}
+	return expanded, resolved, nil
+}
+
+// resolve looks up a single reference, serving it from the cache when fresh
+func (r *Resolver) resolve(ctx context.Context, scheme, ref string) (string, error) {
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("no secret provider for scheme %q", scheme)
+	}
+
+	key := scheme + ":" + ref
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	value, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		// The reference names the secret, not its value, so it is safe to report
+		return "", fmt.Errorf("failed to resolve %s secret %q: %w", scheme, ref, err)
+	}
+
+	if r.ttl > 0 {
+		r.mu.Lock()
+		r.cache[key] = cachedSecret{value: value, expiresAt: time.Now().Add(r.ttl)}
+		r.mu.Unlock()
+	}
+	return value, nil
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// AssertionDetail is structured failure detail captured from a failed
+// assertion, such as the expected and actual values or the JSON Schema
+// violations behind a schema validation failure
+type AssertionDetail struct {
+	// Path locates the mismatch in the response, when the assertion reports one
+	Path string `json:"path,omitempty"`
+	// Expected and Actual are JSON renderings of the compared values,
+	// truncated by the Newman script
+	Expected     *string       `json:"expected,omitempty"`
+	Actual       *string       `json:"actual,omitempty"`
+	SchemaErrors []SchemaError `json:"schema_errors,omitempty"`
+}
+
+// SchemaError is a single JSON Schema violation, e.g. "data.id" "should be integer"
+type SchemaError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Value implements driver.Valuer
+func (d AssertionDetail) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Scan implements sql.Scanner
+func (d *AssertionDetail) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("cannot scan %T into assertion detail", src)
+	}
+}
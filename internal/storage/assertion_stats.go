@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// topFailureActuals is how many of an assertion's most frequent failing
+// actual values AssertionStats reports
+const topFailureActuals = 5
+
+// AssertionBucket counts an assertion's results in executions started within
+// [Start, Start+bucket). PassRate is nil for buckets without results.
+type AssertionBucket struct {
+	Start    time.Time `json:"start"`
+	Total    int       `json:"total"`
+	Passed   int       `json:"passed"`
+	Failed   int       `json:"failed"`
+	PassRate *float64  `json:"pass_rate,omitempty"`
+}
+
+// FailureActual is an actual value a failing assertion reported, with how
+// often it did
+type FailureActual struct {
+	Actual string `json:"actual"`
+	Count  int    `json:"count"`
+}
+
+// AssertionStats is an assertion's pass rate over time in a collection,
+// bucketed by execution start. Every bucket from Since to now is listed, so
+// gaps show as empty buckets. Pass rates are fractions from 0 to 1.
+type AssertionStats struct {
+	CollectionID  int               `json:"collection_id"`
+	Name          string            `json:"name"`
+	Since         time.Time         `json:"since"`
+	BucketSeconds int64             `json:"bucket_seconds"`
+	Total         int               `json:"total"`
+	Passed        int               `json:"passed"`
+	PassRate      *float64          `json:"pass_rate,omitempty"`
+	Buckets       []AssertionBucket `json:"buckets"`
+	// TopFailures are the most frequent actual values of the assertion's
+	// failures, from their stored detail, most frequent first
+	TopFailures []FailureActual `json:"top_failures"`
+}
+
+// passRate returns passed/total, or nil when there were no results
+func passRate(passed, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	rate := float64(passed) / float64(total)
+	return &rate
+}
+
+// GetAssertionStatsContext counts a collection's results of the assertion
+// named name since a point in time, in buckets of the given length aligned
+// to the Unix epoch. Runs with variable overrides and cancelled runs are
+// excluded, as for uptime.
+func (s *Storage) GetAssertionStatsContext(ctx context.Context, collectionID int, name string, since time.Time, bucket time.Duration) (*AssertionStats, error) {
+	bucketSeconds := int64(bucket / time.Second)
+	if bucketSeconds < 1 {
+		return nil, fmt.Errorf("bucket must be at least a second, got %v", bucket)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT FLOOR(EXTRACT(EPOCH FROM te.started_at) / $4)::BIGINT AS bucket,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE tr.passed)
+		FROM test_results tr
+		JOIN test_executions te ON te.id = tr.execution_id
+		WHERE te.collection_id = $1
+		  AND tr.test_name = $2
+		  AND te.started_at >= $3
+		  AND NOT te.overridden
+		  AND NOT te.cancelled
+		GROUP BY bucket
+	`, collectionID, name, since, bucketSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assertion stats: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int64][2]int)
+	for rows.Next() {
+		var index int64
+		var total, passed int
+		if err := rows.Scan(&index, &total, &passed); err != nil {
+			return nil, fmt.Errorf("failed to scan assertion stats: %w", err)
+		}
+		counts[index] = [2]int{total, passed}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := &AssertionStats{
+		CollectionID:  collectionID,
+		Name:          name,
+		Since:         since,
+		BucketSeconds: bucketSeconds,
+		Buckets:       []AssertionBucket{},
+		TopFailures:   []FailureActual{},
+	}
+	first := since.Unix() / bucketSeconds
+	last := time.Now().Unix() / bucketSeconds
+	for index := first; index <= last; index++ {
+		b := AssertionBucket{Start: time.Unix(index*bucketSeconds, 0).UTC()}
+		if c, ok := counts[index]; ok {
+			b.Total, b.Passed = c[0], c[1]
+			b.Failed = b.Total - b.Passed
+			b.PassRate = passRate(b.Passed, b.Total)
+		}
+		stats.Total += b.Total
+		stats.Passed += b.Passed
+		stats.Buckets = append(stats.Buckets, b)
+	}
+	stats.PassRate = passRate(stats.Passed, stats.Total)
+
+	failures, err := s.db.QueryContext(ctx, `
+		SELECT tr.detail->>'actual' AS actual, COUNT(*)
+		FROM test_results tr
+		JOIN test_executions te ON te.id = tr.execution_id
+		WHERE te.collection_id = $1
+		  AND tr.test_name = $2
+		  AND te.started_at >= $3
+		  AND NOT te.overridden
+		  AND NOT te.cancelled
+		  AND NOT tr.passed
+		  AND tr.detail->>'actual' IS NOT NULL
+		GROUP BY actual
+		ORDER BY COUNT(*) DESC, actual
+		LIMIT $4
+	`, collectionID, name, since, topFailureActuals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assertion failures: %w", err)
+	}
+	defer failures.Close()
+
+	for failures.Next() {
+		var f FailureActual
+		if err := failures.Scan(&f.Actual, &f.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan assertion failure: %w", err)
+		}
+		stats.TopFailures = append(stats.TopFailures, f)
+	}
+	return stats, failures.Err()
+}
+
+// GetAssertionPassRates computes the pass rate of each assertion over a
+// collection's last window executions, by test name. Overridden and
+// cancelled executions are left out, as is the truncation summary row.
+func (s *Storage) GetAssertionPassRates(collectionID, window int) (map[string]float64, error) {
+	rows, err := s.db.Query(`
+		SELECT tr.test_name, COUNT(*), COUNT(*) FILTER (WHERE tr.passed)
+		FROM test_results tr
+		JOIN (
+			SELECT id
+			FROM test_executions
+			WHERE collection_id = $1 AND NOT overridden AND NOT cancelled
+			ORDER BY started_at DESC
+			LIMIT $2
+		) te ON te.id = tr.execution_id
+		WHERE tr.status <> $3
+		GROUP BY tr.test_name
+	`, collectionID, window, ResultStatusTruncated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assertion pass rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make(map[string]float64)
+	for rows.Next() {
+		var name string
+		var total, passed int
+		if err := rows.Scan(&name, &total, &passed); err != nil {
+			return nil, fmt.Errorf("failed to scan assertion pass rate: %w", err)
+		}
+		rates[name] = *passRate(passed, total)
+	}
+	return rates, rows.Err()
+}
+
+// GetAllAssertionPassRates is GetAssertionPassRates for every collection in
+// one query, by collection ID. Collections without results are left out.
+func (s *Storage) GetAllAssertionPassRates(window int) (map[int]map[string]float64, error) {
+	rows, err := s.db.Query(`
+		SELECT c.id, tr.test_name, COUNT(*), COUNT(*) FILTER (WHERE tr.passed)
+		FROM collections c
+		CROSS JOIN LATERAL (
+			SELECT id
+			FROM test_executions
+			WHERE collection_id = c.id AND NOT overridden AND NOT cancelled
+			ORDER BY started_at DESC
+			LIMIT $1
+		) te
+		JOIN test_results tr ON tr.execution_id = te.id
+		WHERE tr.status <> $2
+		GROUP BY c.id, tr.test_name
+	`, window, ResultStatusTruncated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assertion pass rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make(map[int]map[string]float64)
+	for rows.Next() {
+		var collectionID int
+		var name string
+		var total, passed int
+		if err := rows.Scan(&collectionID, &name, &total, &passed); err != nil {
+			return nil, fmt.Errorf("failed to scan assertion pass rate: %w", err)
+		}
+		if rates[collectionID] == nil {
+			rates[collectionID] = make(map[string]float64)
+		}
+		rates[collectionID][name] = *passRate(passed, total)
+	}
+	return rates, rows.Err()
+}
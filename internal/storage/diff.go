@@ -0,0 +1,158 @@
+package storage
+
+import "sort"
+
+// Test transitions between two executions
+const (
+	TransitionNewlyFailing = "newly_failing"
+	TransitionNewlyPassing = "newly_passing"
+	TransitionStillFailing = "still_failing"
+	TransitionStillPassing = "still_passing"
+	TransitionAdded        = "added"
+	TransitionRemoved      = "removed"
+)
+
+// TestDiff describes how a single test changed between two executions
+type TestDiff struct {
+	TestName            string  `json:"test_name"`
+	ExecutionName       *string `json:"execution_name,omitempty"`
+	Transition          string  `json:"transition"`
+	FromStatusCode      *int    `json:"from_status_code,omitempty"`
+	ToStatusCode        *int    `json:"to_status_code,omitempty"`
+	StatusCodeChanged   bool    `json:"status_code_changed"`
+	FromResponseTimeMs  *int    `json:"from_response_time_ms,omitempty"`
+	ToResponseTimeMs    *int    `json:"to_response_time_ms,omitempty"`
+	ResponseTimeDeltaMs *int    `json:"response_time_delta_ms,omitempty"`
+	FromError           *string `json:"from_error,omitempty"`
+	ToError             *string `json:"to_error,omitempty"`
+}
+
+// ExecutionDiff compares two executions of the same collection
+type ExecutionDiff struct {
+	From    TestExecution  `json:"from"`
+	To      TestExecution  `json:"to"`
+	Summary map[string]int `json:"summary"`
+	Tests   []TestDiff     `json:"tests"`
+}
+
+// testKey identifies a test across executions; the request name disambiguates
+// assertions with the same name in different requests
+func testKey(r TestResult) string {
+	key := r.TestName
+	if r.ExecutionName != nil {
+		key = *r.ExecutionName + "\x00" + key
+	}
+	return key
+}
+
+// DiffExecutions computes per-test transitions from one execution to another
+func DiffExecutions(from, to ExecutionWithResults) *ExecutionDiff {
+	fromResults := make(map[string]TestResult, len(from.Results))
+	for _, r := range from.Results {
+		fromResults[testKey(r)] = r
+	}
+
+	diff := &ExecutionDiff{
+		From:    from.Execution,
+		To:      to.Execution,
+		Summary: map[string]int{},
+		Tests:   []TestDiff{},
+	}
+
+	seen := make(map[string]bool, len(to.Results))
+	for _, toResult := range to.Results {
+		key := testKey(toResult)
+		seen[key] = true
+
+		td := TestDiff{
+			TestName:         toResult.TestName,
+			ExecutionName:    toResult.ExecutionName,
+			ToStatusCode:     toResult.StatusCode,
+			ToResponseTimeMs: toResult.ResponseTimeMs,
+			ToError:          toResult.Error,
+		}
+
+		fromResult, found := fromResults[key]
+		switch {
+		case !found:
+			td.Transition = TransitionAdded
+		case fromResult.Passed && !toResult.Passed:
+			td.Transition = TransitionNewlyFailing
+		case !fromResult.Passed && toResult.Passed:
+			td.Transition = TransitionNewlyPassing
+		case toResult.Passed:
+			td.Transition = TransitionStillPassing
+		default:
+			td.Transition = TransitionStillFailing
+		}
+
+		if found {
+			td.FromStatusCode = fromResult.StatusCode
+			td.FromResponseTimeMs = fromResult.ResponseTimeMs
+			td.FromError = fromResult.Error
+			td.StatusCodeChanged = !equalIntPtr(fromResult.StatusCode, toResult.StatusCode)
+			if fromResult.ResponseTimeMs != nil && toResult.ResponseTimeMs != nil {
+				delta := *toResult.ResponseTimeMs - *fromResult.ResponseTimeMs
+				td.ResponseTimeDeltaMs = &delta
+			}
+		}
+
+		diff.Tests = append(diff.Tests, td)
+		diff.Summary[td.Transition]++
+	}
+
+	for _, fromResult := range from.Results {
+		if seen[testKey(fromResult)] {
+			continue
+		}
+		diff.Tests = append(diff.Tests, TestDiff{
+			TestName:           fromResult.TestName,
+			ExecutionName:      fromResult.ExecutionName,
+			Transition:         TransitionRemoved,
+			FromStatusCode:     fromResult.StatusCode,
+			FromResponseTimeMs: fromResult.ResponseTimeMs,
+			FromError:          fromResult.Error,
+		})
+		diff.Summary[TransitionRemoved]++
+	}
+
+	// Most interesting transitions first, then by test name
+	rank := map[string]int{
+		TransitionNewlyFailing: 0,
+		TransitionNewlyPassing: 1,
+		TransitionAdded:        2,
+		TransitionRemoved:      3,
+		TransitionStillFailing: 4,
+		TransitionStillPassing: 5,
+	}
+	sort.SliceStable(diff.Tests, func(a, b int) bool {
+		if rank[diff.Tests[a].Transition] != rank[diff.Tests[b].Transition] {
+			return rank[diff.Tests[a].Transition] < rank[diff.Tests[b].Transition]
+		}
+		return diff.Tests[a].TestName < diff.Tests[b].TestName
+	})
+
+	return diff
+}
+
+// Diverged reports whether the later execution's result set departs from the
+// earlier one: tests newly failing, added or removed, or status codes changed
+func (d *ExecutionDiff) Diverged() bool {
+	if d.Summary[TransitionNewlyFailing] > 0 || d.Summary[TransitionAdded] > 0 || d.Summary[TransitionRemoved] > 0 {
+		return true
+	}
+	for _, test := range d.Tests {
+		if test.StatusCodeChanged {
+			return true
+		}
+	}
+	return false
+}
+
+// equalIntPtr reports whether two optional ints are equal
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,409 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ExportFormatVersion identifies the layout of export records
+const ExportFormatVersion = 1
+
+// Export record types, in the order they appear in an export
+const (
+	RecordHeader     = "header"
+	RecordCollection = "collection"
+	RecordExecution  = "execution"
+	RecordResult     = "result"
+	RecordRequest    = "request"
+	RecordVariable   = "variable"
+)
+
+// ExportRecord is one line of an NDJSON export. Exactly one payload field is
+// set, matching Type. Collections precede the executions that reference them,
+// and executions precede their results, requests and variables.
+type ExportRecord struct {
+	Type          string             `json:"type"`
+	FormatVersion int                `json:"format_version,omitempty"`
+	ExportedAt    *time.Time         `json:"exported_at,omitempty"`
+	Collection    *Collection        `json:"collection,omitempty"`
+	Execution     *TestExecution     `json:"execution,omitempty"`
+	Result        *TestResult        `json:"result,omitempty"`
+	Request       *RequestResult     `json:"request,omitempty"`
+	Variable      *ExecutionVariable `json:"variable,omitempty"`
+}
+
+// ExecutionVariable is a collection variable recorded for an execution, at
+// the start of the run (an input replays reuse) or at its end
+type ExecutionVariable struct {
+	ExecutionID int  `json:"execution_id"`
+	AtStart     bool `json:"at_start"`
+	CollectionVariable
+}
+
+// ImportSummary counts the records ingested by an import
+type ImportSummary struct {
+	Collections int `json:"collections"`
+	Executions  int `json:"executions"`
+	Results     int `json:"results"`
+	Requests    int `json:"requests"`
+	Variables   int `json:"variables"`
+}
+
+// Export streams every collection, execution, result, request and execution
+// variable to emit, reading rows
+// through cursors so the dataset is never held in memory. Every read sees the
+// same snapshot, so runs finishing mid-export are left out entirely rather
+// than leaving results whose execution wasn't exported.
+func (s *Storage) Export(emit func(ExportRecord) error) error {
+	tx, err := s.beginSnapshot("")
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The header cursor needs a connection of its own, which shares the
+	// snapshot through its exported ID
+	var snapshot string
+	if err := tx.QueryRow(`SELECT pg_export_snapshot()`).Scan(&snapshot); err != nil {
+		return fmt.Errorf("failed to export snapshot: %w", err)
+	}
+
+	now := time.Now()
+	if err := emit(ExportRecord{Type: RecordHeader, FormatVersion: ExportFormatVersion, ExportedAt: &now}); err != nil {
+		return err
+	}
+
+	collections, err := queryAllCollections(context.Background(), tx)
+	if err != nil {
+		return err
+	}
+	for i := range collections {
+		if err := emit(ExportRecord{Type: RecordCollection, Collection: &collections[i]}); err != nil {
+			return err
+		}
+	}
+
+	if err := exportExecutions(tx, emit); err != nil {
+		return err
+	}
+	if err := s.exportResults(tx, snapshot, emit); err != nil {
+		return err
+	}
+	if err := exportRequests(tx, emit); err != nil {
+		return err
+	}
+	return exportVariables(tx, emit)
+}
+
+// beginSnapshot starts a read-only REPEATABLE READ transaction exempt from
+// the statement timeout. A non-empty snapshot is the ID of another
+// transaction's exported snapshot, which the transaction then reads.
+func (s *Storage) beginSnapshot(snapshot string) (*sql.Tx, error) {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if snapshot != "" {
+		// Must come before any query of the transaction
+		if _, err := tx.Exec(`SET TRANSACTION SNAPSHOT ` + pq.QuoteLiteral(snapshot)); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to import snapshot: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`SET LOCAL statement_timeout = 0`); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to lift statement timeout: %w", err)
+	}
+	return tx, nil
+}
+
+// exportExecutions streams every execution in ID order
+func exportExecutions(tx *sql.Tx, emit func(ExportRecord) error) error {
+	rows, err := tx.Query(`SELECT ` + executionColumns + ` FROM test_executions ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to query executions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		execution, err := scanExecution(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan execution: %w", err)
+		}
+		if err := emit(ExportRecord{Type: RecordExecution, Execution: execution}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// exportRequests streams every request result in ID order
+func exportRequests(tx *sql.Tx, emit func(ExportRecord) error) error {
+	rows, err := tx.Query(`SELECT id, execution_id, request_name, sequence, snapshot, timings FROM request_results ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to query request results: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r RequestResult
+		if err := rows.Scan(&r.ID, &r.ExecutionID, &r.Name, &r.Sequence, &r.Snapshot, &r.Timings); err != nil {
+			return fmt.Errorf("failed to scan request result: %w", err)
+		}
+		if err := emit(ExportRecord{Type: RecordRequest, Request: &r}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// exportVariables streams every execution variable in ID order, so an
+// execution's variables import in the order Newman reported them
+func exportVariables(tx *sql.Tx, emit func(ExportRecord) error) error {
+	rows, err := tx.Query(`SELECT execution_id, at_start, name, value, overridden FROM execution_variables ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to query execution variables: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v ExecutionVariable
+		if err := rows.Scan(&v.ExecutionID, &v.AtStart, &v.Name, &v.Value, &v.Overridden); err != nil {
+			return fmt.Errorf("failed to scan execution variable: %w", err)
+		}
+		if err := emit(ExportRecord{Type: RecordVariable, Variable: &v}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// exportResults streams every result in ID order with its captured headers,
+// merging a second cursor over the headers table ordered the same way. Each
+// cursor needs its own connection, so the headers are read in a second
+// transaction sharing tx's snapshot.
+func (s *Storage) exportResults(tx *sql.Tx, snapshot string, emit func(ExportRecord) error) error {
+	headerTx, err := s.beginSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	defer headerTx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, execution_id, test_name, execution_name, url, method,
+		       status, status_code, response_time_ms, passed, error, sequence, detail, redirects,
+		       expected_failure, created_at
+		FROM test_results
+		ORDER BY id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query test results: %w", err)
+	}
+	defer rows.Close()
+
+	headerRows, err := headerTx.Query(`SELECT result_id, name, value FROM test_result_headers ORDER BY result_id, id`)
+	if err != nil {
+		return fmt.Errorf("failed to query test result headers: %w", err)
+	}
+	defer headerRows.Close()
+
+	var pendingID int
+	var pending *ResponseHeader
+	nextHeader := func() error {
+		pending = nil
+		if !headerRows.Next() {
+			return headerRows.Err()
+		}
+		var h ResponseHeader
+		if err := headerRows.Scan(&pendingID, &h.Name, &h.Value); err != nil {
+			return fmt.Errorf("failed to scan test result header: %w", err)
+		}
+		pending = &h
+		return nil
+	}
+	if err := nextHeader(); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var r TestResult
+		if err := rows.Scan(
+			&r.ID, &r.ExecutionID, &r.TestName, &r.ExecutionName, &r.URL, &r.Method,
+			&r.Status, &r.StatusCode, &r.ResponseTimeMs, &r.Passed, &r.Error, &r.Sequence, &r.Detail, &r.Redirects,
+			&r.ExpectedFailure, &r.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan test result: %w", err)
+		}
+
+		// Skip headers of results the cursor didn't return
+		for pending != nil && pendingID < r.ID {
+			if err := nextHeader(); err != nil {
+				return err
+			}
+		}
+		for pending != nil && pendingID == r.ID {
+			r.Headers = append(r.Headers, *pending)
+			if err := nextHeader(); err != nil {
+				return err
+			}
+		}
+
+		if err := emit(ExportRecord{Type: RecordResult, Result: &r}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Import ingests an NDJSON export in a single transaction. Collections are
+// matched to existing ones by composite key; executions and results get new
+// IDs, with references remapped. Any error rolls back the whole import.
+func (s *Storage) Import(r io.Reader) (*ImportSummary, error) {
+	tx, err := s.beginUnbounded()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var summary ImportSummary
+	collectionIDs := make(map[int]int)
+	executionIDs := make(map[int]int)
+	baselines := make(map[int]int) // new collection ID -> exported baseline execution ID
+
+	decoder := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var record ExportRecord
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("record %d: invalid JSON: %w", line, err)
+		}
+
+		switch {
+		case record.Type == RecordHeader:
+			if record.FormatVersion != ExportFormatVersion {
+				return nil, fmt.Errorf("record %d: unsupported format version %d", line, record.FormatVersion)
+			}
+
+		case record.Type == RecordCollection && record.Collection != nil:
+			c := record.Collection
+			id, err := importCollection(tx, c)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %w", line, err)
+			}
+			collectionIDs[c.ID] = id
+			if c.BaselineExecutionID != nil {
+				baselines[id] = *c.BaselineExecutionID
+			}
+			summary.Collections++
+
+		case record.Type == RecordExecution && record.Execution != nil:
+			e := record.Execution
+			collectionID, ok := collectionIDs[e.CollectionID]
+			if !ok {
+				return nil, fmt.Errorf("record %d: execution %d references unknown collection %d", line, e.ID, e.CollectionID)
+			}
+			oldID := e.ID
+			e.CollectionID = collectionID
+			// Replays are exported after their original; a replay whose
+			// original wasn't exported keeps no link
+			if e.ReplayOf != nil {
+				if replayOf, ok := executionIDs[*e.ReplayOf]; ok {
+					e.ReplayOf = &replayOf
+				} else {
+					e.ReplayOf = nil
+				}
+			}
+			if err := insertTestExecution(tx, e); err != nil {
+				return nil, fmt.Errorf("record %d: %w", line, err)
+			}
+			executionIDs[oldID] = e.ID
+			summary.Executions++
+
+		case record.Type == RecordResult && record.Result != nil:
+			result := record.Result
+			executionID, ok := executionIDs[result.ExecutionID]
+			if !ok {
+				return nil, fmt.Errorf("record %d: result %d references unknown execution %d", line, result.ID, result.ExecutionID)
+			}
+			result.ExecutionID = executionID
+			if err := insertTestResult(tx, result); err != nil {
+				return nil, fmt.Errorf("record %d: %w", line, err)
+			}
+			summary.Results++
+
+		case record.Type == RecordRequest && record.Request != nil:
+			request := record.Request
+			executionID, ok := executionIDs[request.ExecutionID]
+			if !ok {
+				return nil, fmt.Errorf("record %d: request %d references unknown execution %d", line, request.ID, request.ExecutionID)
+			}
+			request.ExecutionID = executionID
+			if err := insertRequestResult(tx, request); err != nil {
+				return nil, fmt.Errorf("record %d: %w", line, err)
+			}
+			summary.Requests++
+
+		case record.Type == RecordVariable && record.Variable != nil:
+			variable := record.Variable
+			executionID, ok := executionIDs[variable.ExecutionID]
+			if !ok {
+				return nil, fmt.Errorf("record %d: variable %q references unknown execution %d", line, variable.Name, variable.ExecutionID)
+			}
+			if _, err := tx.Exec(
+				`INSERT INTO execution_variables (execution_id, name, value, overridden, at_start) VALUES ($1, $2, $3, $4, $5)`,
+				executionID, variable.Name, variable.Value, variable.Overridden, variable.AtStart,
+			); err != nil {
+				return nil, fmt.Errorf("record %d: failed to import execution variable: %w", line, err)
+			}
+			summary.Variables++
+
+		default:
+			return nil, fmt.Errorf("record %d: unknown or empty record of type %q", line, record.Type)
+		}
+	}
+
+	// Baselines reference executions imported after their collection, so
+	// they are remapped last; existing baselines are kept
+	for collectionID, oldExecutionID := range baselines {
+		executionID, ok := executionIDs[oldExecutionID]
+		if !ok {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE collections SET baseline_execution_id = $2 WHERE id = $1 AND baseline_execution_id IS NULL`, collectionID, executionID); err != nil {
+			return nil, fmt.Errorf("failed to import baseline for collection %d: %w", collectionID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return &summary, nil
+}
+
+// importCollection inserts a collection, or reuses the existing collection
+// with the same composite key, returning its ID
+func importCollection(tx *sql.Tx, c *Collection) (int, error) {
+	query := `
+		INSERT INTO collections (name, file_path, composite_key, directory_name, environment_name, collection_name, annotations, owners, data_file, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (composite_key)
+		DO UPDATE SET updated_at = collections.updated_at
+		RETURNING id
+	`
+
+	var id int
+	err := tx.QueryRow(query, c.Name, c.FilePath, c.CompositeKey, c.DirectoryName, c.EnvironmentName, c.CollectionName, c.Annotations, c.Owners, c.DataFile, c.CreatedAt, c.UpdatedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import collection %s: %w", c.CompositeKey, err)
+	}
+	return id, nil
+}
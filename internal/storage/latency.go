@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LatencyAlert is an open alert on a collection whose rolling p95 response
+// time went over its p95_threshold_ms. It is kept until the p95 is back
+// under the threshold, so an alert pages once rather than every run.
+type LatencyAlert struct {
+	CollectionID int `json:"collection_id"`
+	// P95Ms is the rolling p95 that opened the alert
+	P95Ms       int       `json:"p95_ms"`
+	ThresholdMs int       `json:"threshold_ms"`
+	Since       time.Time `json:"since"`
+}
+
+// GetResponseTimeP95 computes the p95 response time of a collection's
+// requests over its last window executions, or nil if none were timed.
+// Each request counts once per execution however many tests it has, and
+// overridden and cancelled executions are left out.
+func (s *Storage) GetResponseTimeP95(collectionID, window int) (*int, error) {
+	var p95 sql.NullInt64
+	err := s.db.QueryRow(`
+		WITH recent AS (
+			SELECT id
+			FROM test_executions
+			WHERE collection_id = $1
+			  AND NOT overridden
+			  AND NOT cancelled
+			ORDER BY started_at DESC, id DESC
+			LIMIT $2
+		), requests AS (
+			SELECT DISTINCT ON (tr.execution_id, tr.execution_name) tr.response_time_ms
+			FROM test_results tr
+			JOIN recent r ON tr.execution_id = r.id
+			WHERE tr.response_time_ms IS NOT NULL
+			ORDER BY tr.execution_id, tr.execution_name
+		)
+		SELECT percentile_disc(0.95) WITHIN GROUP (ORDER BY response_time_ms)
+		FROM requests
+	`, collectionID, window).Scan(&p95)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute p95 response time: %w", err)
+	}
+	if !p95.Valid {
+		return nil, nil
+	}
+	ms := int(p95.Int64)
+	return &ms, nil
+}
+
+// OpenLatencyAlert records a latency alert unless the collection already has
+// one, reporting whether it was opened
+func (s *Storage) OpenLatencyAlert(alert *LatencyAlert) (bool, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO collection_latency_alerts (collection_id, p95_ms, threshold_ms, since)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (collection_id) DO NOTHING
+	`, alert.CollectionID, alert.P95Ms, alert.ThresholdMs, alert.Since)
+	if err != nil {
+		return false, fmt.Errorf("failed to open latency alert: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to open latency alert: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// CloseLatencyAlert removes a collection's latency alert, returning the
+// alert it closed or nil if it had none
+func (s *Storage) CloseLatencyAlert(collectionID int) (*LatencyAlert, error) {
+	var alert LatencyAlert
+	err := s.db.QueryRow(
+		`DELETE FROM collection_latency_alerts WHERE collection_id = $1 RETURNING collection_id, p95_ms, threshold_ms, since`,
+		collectionID,
+	).Scan(&alert.CollectionID, &alert.P95Ms, &alert.ThresholdMs, &alert.Since)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to close latency alert: %w", err)
+	}
+	return &alert, nil
+}
+
+// getLatencyAlertContext retrieves a collection's latency alert, or nil if it has none
+func (s *Storage) getLatencyAlertContext(ctx context.Context, collectionID int) (*LatencyAlert, error) {
+	var alert LatencyAlert
+	err := s.db.QueryRowContext(ctx,
+		`SELECT collection_id, p95_ms, threshold_ms, since FROM collection_latency_alerts WHERE collection_id = $1`,
+		collectionID,
+	).Scan(&alert.CollectionID, &alert.P95Ms, &alert.ThresholdMs, &alert.Since)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latency alert: %w", err)
+	}
+	return &alert, nil
+}
+
+// getLatencyAlerts retrieves all latency alerts keyed by collection ID
+func (s *Storage) getLatencyAlerts(ctx context.Context) (map[int]*LatencyAlert, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT collection_id, p95_ms, threshold_ms, since FROM collection_latency_alerts`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latency alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make(map[int]*LatencyAlert)
+	for rows.Next() {
+		var alert LatencyAlert
+		if err := rows.Scan(&alert.CollectionID, &alert.P95Ms, &alert.ThresholdMs, &alert.Since); err != nil {
+			return nil, fmt.Errorf("failed to scan latency alert: %w", err)
+		}
+		alerts[alert.CollectionID] = &alert
+	}
+	return alerts, rows.Err()
+}
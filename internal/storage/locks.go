@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+)
+
+// CollectionLocker takes Postgres advisory locks on collections so replicas
+// sharing a database don't run the same collection at once. A lock lives
+// as long as the connection that took it, so a crashed replica's locks are
+// released when the database drops its connections.
+type CollectionLocker struct {
+	// db is a pool of its own, since each held lock pins a connection for a
+	// whole execution and must not starve the storage pool
+	db *sql.DB
+}
+
+// NewCollectionLocker connects a locker holding at most maxLocks locks at once
+func NewCollectionLocker(connectionString string, maxLocks int) (*CollectionLocker, error) {
+	db, err := openDB(connectionString, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping lock database: %w", err)
+	}
+
+	db.SetMaxOpenConns(maxLocks)
+	db.SetMaxIdleConns(maxLocks)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	return &CollectionLocker{db: db}, nil
+}
+
+// Close releases every held lock by closing the locker's connections
+func (l *CollectionLocker) Close() error {
+	return l.db.Close()
+}
+
+// collectionLockKey maps a composite key to an advisory lock key
+func collectionLockKey(compositeKey string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scout:collection:" + compositeKey))
+	return int64(h.Sum64())
+}
+
+// TryLock takes the collection's lock without waiting. It reports false when
+// another replica holds it; otherwise unlock must be called once the
+// collection has run.
+func (l *CollectionLocker) TryLock(ctx context.Context, compositeKey string) (unlock func(), locked bool, err error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get lock connection: %w", err)
+	}
+
+	key := collectionLockKey(compositeKey)
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to lock collection: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			// Closing the connection below can't release the lock while it
+			// goes back to the pool, so drop the connection instead
+			log.Printf("Error unlocking collection %s, dropping its lock connection: %v", compositeKey, err)
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, true, nil
+}
+
+// GetLatestExecutionByCompositeKey retrieves the latest execution of the
+// collection with the given composite key, or nil if it has never run
+func (s *Storage) GetLatestExecutionByCompositeKey(compositeKey string) (*TestExecution, error) {
+	exec, err := scanExecution(s.db.QueryRow(`
+		SELECT `+executionColumns+`
+		FROM latest_test_executions
+		WHERE collection_id = (SELECT id FROM collections WHERE composite_key = $1)
+	`, compositeKey))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest execution: %w", err)
+	}
+	return exec, nil
+}
+
+// instanceLockName names the advisory lock every running Scout holds shared
+// and maintenance commands take exclusively, so the two never overlap
+const instanceLockName = "scout:instance"
+
+// instanceLockRetry is how often a starting Scout retries the instance lock
+// while a maintenance command holds it
+const instanceLockRetry = 5 * time.Second
+
+// instanceLockKey maps instanceLockName to an advisory lock key
+func instanceLockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(instanceLockName))
+	return int64(h.Sum64())
+}
+
+// ErrScoutRunning is returned by maintenance commands while a Scout instance
+// is using the database
+var ErrScoutRunning = errors.New("a Scout instance is running against this database; stop every instance first")
+
+// InstanceLock is the shared instance lock held by a running Scout
+type InstanceLock struct {
+	db   *sql.DB
+	conn *sql.Conn
+}
+
+// AcquireInstanceLock takes the shared instance lock on a connection of its
+// own, waiting while a maintenance command holds it exclusively. Any number
+// of instances, such as replicas, hold it at once.
+func AcquireInstanceLock(ctx context.Context, connectionString string) (*InstanceLock, error) {
+	db, err := openDB(connectionString, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open instance lock database: %w", err)
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to get instance lock connection: %w", err)
+	}
+
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock_shared($1)`, instanceLockKey()).Scan(&locked); err != nil {
+			conn.Close()
+			db.Close()
+			return nil, fmt.Errorf("failed to take instance lock: %w", err)
+		}
+		if locked {
+			return &InstanceLock{db: db, conn: conn}, nil
+		}
+
+		log.Printf("A maintenance command is running against the database, waiting %v", instanceLockRetry)
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(instanceLockRetry):
+		}
+	}
+}
+
+// Close releases the instance lock by closing its connection
+func (l *InstanceLock) Close() error {
+	l.conn.Close()
+	return l.db.Close()
+}
+
+// lockInstances takes the instance lock exclusively for the rest of tx,
+// failing with ErrScoutRunning while any Scout holds it
+func lockInstances(tx *sql.Tx) error {
+	var locked bool
+	if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, instanceLockKey()).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to take instance lock: %w", err)
+	}
+	if !locked {
+		return ErrScoutRunning
+	}
+	return nil
+}
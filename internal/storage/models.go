@@ -1,81 +1,627 @@
 package storage
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
 
 // Collection represents a Postman collection being monitored
 type Collection struct {
-	ID              int       `json:"id"`
-	Name            string    `json:"name"`
-	FilePath        string    `json:"file_path"`
-	CompositeKey    string    `json:"composite_key"`
-	DirectoryName   string    `json:"directory_name"`
-	EnvironmentName string    `json:"environment_name"`
-	CollectionName  string    `json:"collection_name"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int         `json:"id"`
+	Name            string      `json:"name"`
+	FilePath        string      `json:"file_path"`
+	CompositeKey    string      `json:"composite_key"`
+	DirectoryName   string      `json:"directory_name"`
+	EnvironmentName string      `json:"environment_name"`
+	CollectionName  string      `json:"collection_name"`
+	Annotations     Annotations `json:"annotations,omitempty"`
+	// Owners route notifications for failing tests to the teams that own them
+	Owners OwnerRules `json:"owners,omitempty"`
+	// DataFile is the iteration data file the collection runs with, set on
+	// each sibling of a collection that runs once per data file
+	DataFile *string `json:"data_file,omitempty"`
+	// BaselineExecutionID pins a known-good execution later runs are compared against
+	BaselineExecutionID *int      `json:"baseline_execution_id,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// InGracePeriod reports whether the collection was first seen less than
+// grace before t, so failures at t shouldn't alert
+func (c Collection) InGracePeriod(grace time.Duration, t time.Time) bool {
+	return grace > 0 && !c.CreatedAt.IsZero() && t.Before(c.CreatedAt.Add(grace))
+}
+
+// Annotations are free-form key/value pairs from a directory's scout.yaml,
+// such as a runbook link or owning team, stored as JSONB
+type Annotations map[string]string
+
+// Value implements driver.Valuer
+func (a Annotations) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan implements sql.Scanner
+func (a *Annotations) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into annotations", src)
+	}
+}
+
+// EnvironmentLayers names the shared layers and environment file merged for
+// an execution, lowest first, stored as JSONB
+type EnvironmentLayers []string
+
+// Value implements driver.Valuer
+func (l EnvironmentLayers) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements sql.Scanner
+func (l *EnvironmentLayers) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	default:
+		return fmt.Errorf("cannot scan %T into environment layers", src)
+	}
+}
+
+// NewmanArgs are the extra Newman flags an execution ran with, stored as JSONB
+type NewmanArgs []string
+
+// Value implements driver.Valuer
+func (a NewmanArgs) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+// Scan implements sql.Scanner
+func (a *NewmanArgs) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into newman args", src)
+	}
 }
 
 // TestExecution represents a single execution run of a collection
 type TestExecution struct {
-	ID             int       `json:"id"`
-	CollectionID   int       `json:"collection_id"`
-	CollectionName string    `json:"collection_name"`
-	StartedAt      time.Time `json:"started_at"`
-	CompletedAt    time.Time `json:"completed_at"`
-	DurationMs     int       `json:"duration_ms"`
-	TotalTests     int       `json:"total_tests"`
-	PassedTests    int       `json:"passed_tests"`
-	FailedTests    int       `json:"failed_tests"`
-	Error          *string   `json:"error,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID               int       `json:"id"`
+	CollectionID     int       `json:"collection_id"`
+	CollectionName   string    `json:"collection_name"`
+	StartedAt        time.Time `json:"started_at"`
+	CompletedAt      time.Time `json:"completed_at"`
+	DurationMs       int       `json:"duration_ms"`
+	TotalTests       int       `json:"total_tests"`
+	PassedTests      int       `json:"passed_tests"`
+	FailedTests      int       `json:"failed_tests"`
+	Error            *string   `json:"error,omitempty"`
+	ProxyUsed        bool      `json:"proxy_used"`
+	NodeVersion      *string   `json:"node_version,omitempty"`
+	NewmanVersion    *string   `json:"newman_version,omitempty"`
+	Overridden       bool      `json:"overridden"`
+	ErrorCategory    *string   `json:"error_category,omitempty"`
+	TriggerSource    string    `json:"trigger_source"`
+	ResultCount      int       `json:"result_count"`
+	Truncated        bool      `json:"truncated"`
+	OrderPosition    *int      `json:"order_position,omitempty"`
+	RequestsTotal    *int      `json:"requests_total,omitempty"`
+	TransferredBytes *int64    `json:"transferred_bytes,omitempty"`
+	Warning          bool      `json:"warning"`
+	PassThreshold    *float64  `json:"pass_threshold,omitempty"`
+	TestedVersion    *string   `json:"tested_version,omitempty"`
+	// ReportKey locates the raw report in the report store, when one is configured
+	ReportKey *string `json:"report_key,omitempty"`
+	// Cancelled runs were stopped through the API before Newman finished
+	Cancelled bool `json:"cancelled"`
+	// EnvironmentLayers lists what was merged into the run's environment when
+	// the directory has shared variables
+	EnvironmentLayers EnvironmentLayers `json:"environment_layers,omitempty"`
+	// NewmanArgs are the extra Newman flags from scout.yaml the run used
+	NewmanArgs NewmanArgs `json:"newman_args,omitempty"`
+	// ReplayOf is the execution whose inputs a replay re-ran
+	ReplayOf *int `json:"replay_of,omitempty"`
+	// Replica names the Scout replica that ran the execution, when several
+	// share the database
+	Replica   *string   `json:"replica,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Collection statuses derived from the latest execution
+const (
+	StatusPassing   = "passing"
+	StatusWarning   = "warning"
+	StatusFailing   = "failing"
+	StatusNoTests   = "no_tests"
+	StatusCancelled = "cancelled"
+	StatusNeverRun  = "never_run"
+)
+
+// StatusDisabled is the status of a collection whose file is named to
+// disable it, whatever its latest execution
+const StatusDisabled = "disabled"
+
+// Status derives a collection status from an execution; a nil execution has
+// never run. A passing run that exceeded its warn threshold is a warning. With
+// a pass threshold, a run passes when at least that percentage of tests passed.
+// A cancelled run is neither passing nor failing. A run that made no
+// assertions, usually a collection missing its test scripts, has no tests.
+func (e *TestExecution) Status() string {
+	if e == nil {
+		return StatusNeverRun
+	}
+	if e.Cancelled {
+		return StatusCancelled
+	}
+	if e.Error != nil || (e.FailedTests > 0 && !e.meetsPassThreshold()) {
+		return StatusFailing
+	}
+	if e.TotalTests == 0 {
+		return StatusNoTests
+	}
+	if e.Warning {
+		return StatusWarning
+	}
+	return StatusPassing
+}
+
+// meetsPassThreshold reports whether enough tests passed to meet the
+// execution's pass threshold; without one every test must pass
+func (e *TestExecution) meetsPassThreshold() bool {
+	if e.PassThreshold == nil || e.TotalTests == 0 {
+		return false
+	}
+	return float64(e.PassedTests)*100/float64(e.TotalTests) >= *e.PassThreshold
 }
 
+// ResultStatusTruncated marks the summary row stored when results were capped
+const ResultStatusTruncated = "truncated"
+
+// GraphQLTestName is the failed test the Newman script records for each
+// GraphQL response carrying errors
+const GraphQLTestName = "[scout] GraphQL response has no errors"
+
 // TestResult represents an individual test result within an execution
 type TestResult struct {
-	ID              int       `json:"id"`
-	ExecutionID     int       `json:"execution_id"`
-	TestName        string    `json:"test_name"`
-	ExecutionName   *string   `json:"execution_name,omitempty"`
-	URL             *string   `json:"url,omitempty"`
-	Method          *string   `json:"method,omitempty"`
-	Status          string    `json:"status"`
-	StatusCode      *int      `json:"status_code,omitempty"`
-	ResponseTimeMs  *int      `json:"response_time_ms,omitempty"`
-	Passed          bool      `json:"passed"`
-	Error           *string   `json:"error,omitempty"`
+	ID             int              `json:"id"`
+	ExecutionID    int              `json:"execution_id"`
+	TestName       string           `json:"test_name"`
+	ExecutionName  *string          `json:"execution_name,omitempty"`
+	URL            *string          `json:"url,omitempty"`
+	Method         *string          `json:"method,omitempty"`
+	Status         string           `json:"status"`
+	StatusCode     *int             `json:"status_code,omitempty"`
+	ResponseTimeMs *int             `json:"response_time_ms,omitempty"`
+	Passed         bool             `json:"passed"`
+	Error          *string          `json:"error,omitempty"`
+	Sequence       *int             `json:"sequence,omitempty"`
+	Headers        []ResponseHeader `json:"headers,omitempty"`
+	Detail         *AssertionDetail `json:"detail,omitempty"`
+	// Redirects lists the redirects followed before the final status code
+	Redirects Redirects `json:"redirects,omitempty"`
+	// ExpectedFailure marks a test expected to fail. Passed then reports
+	// whether it failed as expected; Error keeps the failed assertion's
+	// message, or says the test unexpectedly passed.
+	ExpectedFailure bool      `json:"expected_failure"`
 	CreatedAt       time.Time `json:"created_at"`
 }
 
+// ResultOrder selects how an execution's test results are sorted
+type ResultOrder string
+
+const (
+	// ResultOrderSequence sorts results in the order the tests ran; results
+	// stored before run order was recorded fall back to name order after them
+	ResultOrderSequence ResultOrder = "sequence"
+	// ResultOrderName sorts results alphabetically by test name
+	ResultOrderName ResultOrder = "name"
+)
+
+// ParseResultOrder validates a result order supplied by a client, defaulting to sequence
+func ParseResultOrder(value string) (ResultOrder, error) {
+	switch order := ResultOrder(value); order {
+	case "":
+		return ResultOrderSequence, nil
+	case ResultOrderSequence, ResultOrderName:
+		return order, nil
+	default:
+		return "", fmt.Errorf("invalid sort %q (expected sequence or name)", value)
+	}
+}
+
+// ResponseHeader is a captured response header for a test result
+type ResponseHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 // ExecutionWithResults combines execution data with its test results
 type ExecutionWithResults struct {
 	Execution TestExecution `json:"execution"`
 	Results   []TestResult  `json:"results"`
+	// Requests are the requests sent, with their response snapshots and timings
+	Requests []RequestResult `json:"requests"`
+	// CollectionVariables are the collection's own variables at the end of
+	// the run, with sensitive values masked
+	CollectionVariables []CollectionVariable `json:"collection_variables"`
+}
+
+// CollectionVariable is a collection-level variable recorded for an execution
+type CollectionVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Overridden is set when an environment variable of the same name took
+	// precedence, so requests didn't use this value
+	Overridden bool `json:"overridden"`
 }
 
 // EnvironmentInfo represents environment metadata for API responses
 type EnvironmentInfo struct {
-	Name     string `json:"name"`
-	FileName string `json:"file_name"`
-	Path     string `json:"path"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	FileName    string `json:"file_name"`
+	Path        string `json:"path"`
 }
 
 // EnvironmentGroup represents a group of collections with optional environment
 type EnvironmentGroup struct {
 	Environment *EnvironmentInfo   `json:"environment,omitempty"`
 	Directory   string             `json:"directory"`
+	DisplayName string             `json:"display_name,omitempty"`
 	Collections []CollectionResult `json:"collections"`
+	Rollup      *GroupRollup       `json:"rollup,omitempty"`
+}
+
+// GroupRollup summarizes a group's collections for a collapsed view
+type GroupRollup struct {
+	Total     int    `json:"total"`
+	Passing   int    `json:"passing"`
+	Warning   int    `json:"warning"`
+	Failing   int    `json:"failing"`
+	NoTests   int    `json:"no_tests"`
+	Cancelled int    `json:"cancelled"`
+	NeverRun  int    `json:"never_run"`
+	Disabled  int    `json:"disabled"`
+	Worst     string `json:"worst_status"`
+	// OldestLastRun is the least recent latest execution among collections
+	// that have run, so a stale collection shows at the group level
+	OldestLastRun *time.Time `json:"oldest_last_run,omitempty"`
+}
+
+// statusSeverity ranks statuses for a group's worst status, most severe highest
+var statusSeverity = map[string]int{
+	StatusDisabled:  0,
+	StatusPassing:   1,
+	StatusNeverRun:  2,
+	StatusCancelled: 3,
+	StatusNoTests:   4,
+	StatusWarning:   5,
+	StatusFailing:   6,
+}
+
+// newGroupRollup counts a group's collections by status
+func newGroupRollup(collections []CollectionResult) *GroupRollup {
+	rollup := &GroupRollup{Total: len(collections), Worst: StatusPassing}
+	if len(collections) == 0 {
+		rollup.Worst = StatusNeverRun
+	}
+
+	for _, cr := range collections {
+		status := cr.Status()
+		switch status {
+		case StatusPassing:
+			rollup.Passing++
+		case StatusWarning:
+			rollup.Warning++
+		case StatusFailing:
+			rollup.Failing++
+		case StatusNoTests:
+			rollup.NoTests++
+		case StatusCancelled:
+			rollup.Cancelled++
+		case StatusNeverRun:
+			rollup.NeverRun++
+		case StatusDisabled:
+			rollup.Disabled++
+		}
+		if statusSeverity[status] > statusSeverity[rollup.Worst] {
+			rollup.Worst = status
+		}
+
+		if cr.Execution != nil && (rollup.OldestLastRun == nil || cr.Execution.StartedAt.Before(*rollup.OldestLastRun)) {
+			startedAt := cr.Execution.StartedAt
+			rollup.OldestLastRun = &startedAt
+		}
+	}
+	return rollup
+}
+
+// ComputeRollups sets each group's rollup from its current collections
+func ComputeRollups(groups []EnvironmentGroup) {
+	for i := range groups {
+		groups[i].Rollup = newGroupRollup(groups[i].Collections)
+	}
+}
+
+// ValidStatus reports whether status is a known collection status
+func ValidStatus(status string) bool {
+	_, ok := statusSeverity[status]
+	return ok
+}
+
+// ResultFilter narrows grouped results; empty fields match everything
+type ResultFilter struct {
+	Statuses    []string // Collection statuses to keep
+	Directories []string // Directories to keep, by normalized or display name
+}
+
+// matchesDirectory reports whether the group is in one of the filter's directories
+func (f ResultFilter) matchesDirectory(group EnvironmentGroup) bool {
+	if len(f.Directories) == 0 {
+		return true
+	}
+	for _, directory := range f.Directories {
+		if directory == group.Directory || directory == group.DisplayName {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesStatus reports whether the collection has one of the filter's statuses
+func (f ResultFilter) matchesStatus(cr CollectionResult) bool {
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	status := cr.Status()
+	for _, s := range f.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterEnvironmentGroups returns the groups in the filter's directories with
+// only their collections matching its statuses, dropping groups left empty.
+// Rollups are kept as they are, so they still summarize the whole group.
+func FilterEnvironmentGroups(groups []EnvironmentGroup, filter ResultFilter) []EnvironmentGroup {
+	if len(filter.Statuses) == 0 && len(filter.Directories) == 0 {
+		return groups
+	}
+
+	filtered := []EnvironmentGroup{}
+	for _, group := range groups {
+		if !filter.matchesDirectory(group) {
+			continue
+		}
+		if len(filter.Statuses) > 0 {
+			collections := []CollectionResult{}
+			for _, cr := range group.Collections {
+				if filter.matchesStatus(cr) {
+					collections = append(collections, cr)
+				}
+			}
+			if len(collections) == 0 {
+				continue
+			}
+			group.Collections = collections
+		}
+		filtered = append(filtered, group)
+	}
+	return filtered
+}
+
+// environmentName returns the group's environment name, or "" if it has none
+func (g EnvironmentGroup) environmentName() string {
+	if g.Environment == nil {
+		return ""
+	}
+	return g.Environment.Name
+}
+
+// SortEnvironmentGroups orders groups by directory then environment name, and
+// the collections within each group by collection name, so API responses
+// have a stable layout
+func SortEnvironmentGroups(groups []EnvironmentGroup) {
+	sort.SliceStable(groups, func(a, b int) bool {
+		if groups[a].Directory != groups[b].Directory {
+			return groups[a].Directory < groups[b].Directory
+		}
+		return groups[a].environmentName() < groups[b].environmentName()
+	})
+
+	for _, group := range groups {
+		collections := group.Collections
+		sort.SliceStable(collections, func(a, b int) bool {
+			return collections[a].Collection.CollectionName < collections[b].Collection.CollectionName
+		})
+	}
+}
+
+// QualifyEnvironmentNames sets each environment's display name, prefixing it
+// with the directory when the same environment name appears in more than one
+// directory (e.g. two "prod" environments become "payments/prod" and "orders/prod")
+func QualifyEnvironmentNames(groups []EnvironmentGroup) {
+	directories := make(map[string]map[string]bool)
+	for _, group := range groups {
+		if group.Environment == nil {
+			continue
+		}
+		name := group.Environment.Name
+		if directories[name] == nil {
+			directories[name] = make(map[string]bool)
+		}
+		directories[name][group.Directory] = true
+	}
+
+	for _, group := range groups {
+		if group.Environment == nil {
+			continue
+		}
+		group.Environment.DisplayName = group.Environment.Name
+		if len(directories[group.Environment.Name]) > 1 {
+			group.Environment.DisplayName = group.Directory + "/" + group.Environment.Name
+		}
+	}
 }
 
 // LatestResults represents the latest test results for API responses
 type LatestResults struct {
 	EnvironmentGroups []EnvironmentGroup `json:"environment_groups"`
 	UpdatedAt         time.Time          `json:"updated_at"`
+	Timezone          string             `json:"timezone,omitempty"`
+	Maintenance       *MaintenanceState  `json:"maintenance,omitempty"`
+}
+
+// MaintenanceState describes whether scheduling is paused for maintenance
+type MaintenanceState struct {
+	Enabled   bool       `json:"enabled"`
+	Reason    string     `json:"reason,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Expired reports whether an enabled maintenance window has passed its end time
+func (m MaintenanceState) Expired(now time.Time) bool {
+	return m.Enabled && m.Until != nil && !now.Before(*m.Until)
 }
 
 // CollectionResult represents results for a single collection
 type CollectionResult struct {
-	Collection          Collection      `json:"collection"`
-	Execution           *TestExecution  `json:"execution,omitempty"`
-	LastSuccessExecution *TestExecution `json:"last_success_execution,omitempty"`
-	Results             []TestResult    `json:"results"`
+	Collection           Collection       `json:"collection"`
+	Execution            *TestExecution   `json:"execution,omitempty"`
+	LastSuccessExecution *TestExecution   `json:"last_success_execution,omitempty"`
+	Results              []TestResult     `json:"results"`
+	Requests             []RequestResult  `json:"requests"`
+	Ack                  *Acknowledgment  `json:"ack,omitempty"`
+	DurationTrend        *DurationTrend   `json:"duration_trend,omitempty"`
+	InGracePeriod        bool             `json:"in_grace_period"`
+	DivergedFromBaseline bool             `json:"diverged_from_baseline"`
+	Config               *EffectiveConfig `json:"config,omitempty"`
+	LatencyAlert         *LatencyAlert    `json:"latency_alert,omitempty"`
+	// Disabled collections are on disk but named not to run
+	Disabled bool `json:"disabled"`
+	// AssertionPassRates are the pass rates of the collection's assertions
+	// over its recent executions, by test name, attached for metrics
+	AssertionPassRates map[string]float64 `json:"-"`
+}
+
+// Status is the collection's status: disabled, or derived from its latest execution
+func (cr CollectionResult) Status() string {
+	if cr.Disabled {
+		return StatusDisabled
+	}
+	return cr.Execution.Status()
+}
+
+// Acknowledgment silences notifications for a collection's current failure
+// until the collection next passes
+type Acknowledgment struct {
+	CollectionID   int       `json:"collection_id"`
+	User           string    `json:"user"`
+	Reason         string    `json:"reason,omitempty"`
+	AcknowledgedAt time.Time `json:"acknowledged_at"`
+}
+
+// Uptime summarizes how often a collection's executions succeeded over a window
+type Uptime struct {
+	CollectionID        int       `json:"collection_id"`
+	Since               time.Time `json:"since"`
+	TotalRuns           int       `json:"total_runs"`
+	SuccessfulRuns      int       `json:"successful_runs"`
+	UptimePercent       float64   `json:"uptime_percent"`
+	IncludeNonScheduled bool      `json:"include_non_scheduled"`
+}
+
+// TestHistoryPoint is one execution's outcome for a single test. Present is
+// false when the test did not run in that execution, leaving a gap in the series.
+type TestHistoryPoint struct {
+	ExecutionID    int       `json:"execution_id"`
+	StartedAt      time.Time `json:"started_at"`
+	Present        bool      `json:"present"`
+	Passed         *bool     `json:"passed,omitempty"`
+	StatusCode     *int      `json:"status_code,omitempty"`
+	ResponseTimeMs *int      `json:"response_time_ms,omitempty"`
+}
+
+// TestHistory is an ordered time series for a single test in a collection
+type TestHistory struct {
+	CollectionID int                `json:"collection_id"`
+	TestName     string             `json:"test_name"`
+	Points       []TestHistoryPoint `json:"points"`
+}
+
+// SearchFilter narrows a search across the latest test results
+type SearchFilter struct {
+	TestName    string
+	URLContains string
+	Passed      *bool
+	Limit       int
+	Offset      int
+}
+
+// SearchResult is a single test result matched by a search, with its collection and execution context
+type SearchResult struct {
+	CollectionID    int        `json:"collection_id"`
+	CompositeKey    string     `json:"composite_key"`
+	CollectionName  string     `json:"collection_name"`
+	DirectoryName   string     `json:"directory_name"`
+	EnvironmentName string     `json:"environment_name"`
+	ExecutionID     int        `json:"execution_id"`
+	StartedAt       time.Time  `json:"started_at"`
+	Result          TestResult `json:"result"`
+}
+
+// Matrix shows the latest status of each collection across environments
+type Matrix struct {
+	Environments []string    `json:"environments"`
+	Rows         []MatrixRow `json:"rows"`
+}
+
+// MatrixRow is one collection's status in every environment; cells are null
+// for environments the collection does not exist in
+type MatrixRow struct {
+	Directory      string                 `json:"directory"`
+	CollectionName string                 `json:"collection_name"`
+	Cells          map[string]*MatrixCell `json:"cells"`
+}
+
+// MatrixCell is a collection's latest status in a single environment
+type MatrixCell struct {
+	CollectionID int        `json:"collection_id"`
+	ExecutionID  *int       `json:"execution_id,omitempty"`
+	Status       string     `json:"status"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
 }
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+// filterFixture has a passing and a failing collection in shop, a failing one
+// in the "Order Service" directory and a never-run one in billing
+func filterFixture() []EnvironmentGroup {
+	errMsg := "timeout"
+	passing := CollectionResult{
+		Collection: Collection{CompositeKey: "shop_env_cart"},
+		Execution:  &TestExecution{TotalTests: 2, PassedTests: 2},
+	}
+	failing := CollectionResult{
+		Collection: Collection{CompositeKey: "shop_env_orders"},
+		Execution:  &TestExecution{TotalTests: 2, PassedTests: 1, FailedTests: 1},
+	}
+	return []EnvironmentGroup{
+		{Directory: "shop", Collections: []CollectionResult{passing, failing}},
+		{
+			Directory:   "order_service",
+			DisplayName: "Order Service",
+			Collections: []CollectionResult{{
+				Collection: Collection{CompositeKey: "order_service_env_api"},
+				Execution:  &TestExecution{Error: &errMsg},
+			}},
+		},
+		{Directory: "billing", Collections: []CollectionResult{{Collection: Collection{CompositeKey: "billing_env_invoices"}}}},
+	}
+}
+
+// groupKeys lists each group's directory and collection keys
+func groupKeys(groups []EnvironmentGroup) map[string][]string {
+	keys := make(map[string][]string)
+	for _, group := range groups {
+		keys[group.Directory] = []string{}
+		for _, cr := range group.Collections {
+			keys[group.Directory] = append(keys[group.Directory], cr.Collection.CompositeKey)
+		}
+	}
+	return keys
+}
+
+func TestFilterEnvironmentGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter ResultFilter
+		want   map[string][]string
+	}{
+		{
+			name:   "no filter",
+			filter: ResultFilter{},
+			want: map[string][]string{
+				"shop":          {"shop_env_cart", "shop_env_orders"},
+				"order_service": {"order_service_env_api"},
+				"billing":       {"billing_env_invoices"},
+			},
+		},
+		{
+			name:   "status drops groups left empty",
+			filter: ResultFilter{Statuses: []string{StatusFailing}},
+			want: map[string][]string{
+				"shop":          {"shop_env_orders"},
+				"order_service": {"order_service_env_api"},
+			},
+		},
+		{
+			name:   "several statuses",
+			filter: ResultFilter{Statuses: []string{StatusPassing, StatusNeverRun}},
+			want: map[string][]string{
+				"shop":    {"shop_env_cart"},
+				"billing": {"billing_env_invoices"},
+			},
+		},
+		{
+			name:   "directory by normalized name",
+			filter: ResultFilter{Directories: []string{"order_service"}},
+			want:   map[string][]string{"order_service": {"order_service_env_api"}},
+		},
+		{
+			name:   "directory by display name",
+			filter: ResultFilter{Directories: []string{"Order Service"}},
+			want:   map[string][]string{"order_service": {"order_service_env_api"}},
+		},
+		{
+			name:   "directory without status keeps empty groups",
+			filter: ResultFilter{Directories: []string{"billing", "shop"}},
+			want: map[string][]string{
+				"shop":    {"shop_env_cart", "shop_env_orders"},
+				"billing": {"billing_env_invoices"},
+			},
+		},
+		{
+			name:   "status and directory",
+			filter: ResultFilter{Statuses: []string{StatusFailing}, Directories: []string{"shop", "billing"}},
+			want:   map[string][]string{"shop": {"shop_env_orders"}},
+		},
+		{
+			name:   "status matching nothing in the directory",
+			filter: ResultFilter{Statuses: []string{StatusPassing}, Directories: []string{"order_service"}},
+			want:   map[string][]string{},
+		},
+		{
+			name:   "unknown directory",
+			filter: ResultFilter{Directories: []string{"missing"}},
+			want:   map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := filterFixture()
+			ComputeRollups(groups)
+			filtered := FilterEnvironmentGroups(groups, tt.filter)
+
+			if got := groupKeys(filtered); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterEnvironmentGroups() = %v, want %v", got, tt.want)
+			}
+			if filtered == nil {
+				t.Error("FilterEnvironmentGroups() = nil, want an empty slice so the API encodes []")
+			}
+			// Rollups still summarize the whole group
+			for _, group := range filtered {
+				if group.Directory == "shop" && (group.Rollup == nil || group.Rollup.Total != 2) {
+					t.Errorf("shop rollup = %+v, want the unfiltered total of 2", group.Rollup)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterEnvironmentGroupsDisabled(t *testing.T) {
+	groups := []EnvironmentGroup{{
+		Directory: "shop",
+		Collections: []CollectionResult{
+			{Collection: Collection{CompositeKey: "shop_env_cart"}, Execution: &TestExecution{TotalTests: 1, PassedTests: 1}},
+			{Collection: Collection{CompositeKey: "shop_env_legacy"}, Execution: &TestExecution{TotalTests: 1, PassedTests: 1}, Disabled: true},
+		},
+	}}
+
+	got := groupKeys(FilterEnvironmentGroups(groups, ResultFilter{Statuses: []string{StatusDisabled}}))
+	want := map[string][]string{"shop": {"shop_env_legacy"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterEnvironmentGroups() = %v, want %v", got, want)
+	}
+}
+
+func TestQualifyEnvironmentNames(t *testing.T) {
+	groups := []EnvironmentGroup{
+		{Directory: "orders", Environment: &EnvironmentInfo{Name: "prod"}},
+		{Directory: "payments", Environment: &EnvironmentInfo{Name: "prod"}},
+		{Directory: "payments", Environment: &EnvironmentInfo{Name: "staging"}},
+		{Directory: "shop"},
+	}
+
+	QualifyEnvironmentNames(groups)
+
+	want := []struct{ name, displayName string }{
+		{"prod", "orders/prod"},
+		{"prod", "payments/prod"},
+		{"staging", "staging"},
+	}
+	for i, w := range want {
+		env := groups[i].Environment
+		if env.DisplayName != w.displayName {
+			t.Errorf("%s %s display name = %q, want %q", groups[i].Directory, env.Name, env.DisplayName, w.displayName)
+		}
+		// Only the display name is qualified; keys and metrics use the name
+		if env.Name != w.name {
+			t.Errorf("%s environment name = %q, want %q", groups[i].Directory, env.Name, w.name)
+		}
+	}
+	if groups[3].Environment != nil {
+		t.Errorf("group without an environment got %+v", groups[3].Environment)
+	}
+}
+
+func TestSortEnvironmentGroups(t *testing.T) {
+	env := func(name string) *EnvironmentInfo { return &EnvironmentInfo{Name: name} }
+	collection := func(key, name string) CollectionResult {
+		return CollectionResult{Collection: Collection{CompositeKey: key, CollectionName: name}}
+	}
+	build := func() []EnvironmentGroup {
+		return []EnvironmentGroup{
+			{Directory: "shop", Environment: env("staging"), Collections: []CollectionResult{collection("shop_staging_orders", "orders"), collection("shop_staging_cart", "cart")}},
+			{Directory: "billing"},
+			{Directory: "shop", Environment: env("prod"), Collections: []CollectionResult{
+				collection("shop_prod_orders", "orders"),
+				// Same collection name, so the input order must be kept
+				collection("shop_prod_cart_second", "cart"),
+				collection("shop_prod_cart_first", "cart"),
+			}},
+			{Directory: "shop"},
+		}
+	}
+	layout := func(groups []EnvironmentGroup) []string {
+		var keys []string
+		for _, group := range groups {
+			keys = append(keys, group.Directory+"/"+group.environmentName())
+			for _, cr := range group.Collections {
+				keys = append(keys, "  "+cr.Collection.CompositeKey)
+			}
+		}
+		return keys
+	}
+
+	want := []string{
+		"billing/",
+		"shop/",
+		"shop/prod",
+		"  shop_prod_cart_second",
+		"  shop_prod_cart_first",
+		"  shop_prod_orders",
+		"shop/staging",
+		"  shop_staging_cart",
+		"  shop_staging_orders",
+	}
+
+	groups := build()
+	SortEnvironmentGroups(groups)
+	if got := layout(groups); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SortEnvironmentGroups() layout = %q, want %q", got, want)
+	}
+
+	// Sorting again, or sorting the groups from a different order, changes nothing
+	SortEnvironmentGroups(groups)
+	if got := layout(groups); !reflect.DeepEqual(got, want) {
+		t.Errorf("second sort layout = %q, want %q", got, want)
+	}
+	reversed := build()
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	SortEnvironmentGroups(reversed)
+	if got := layout(reversed); !reflect.DeepEqual(got, want) {
+		t.Errorf("layout from reversed groups = %q, want %q", got, want)
+	}
+}
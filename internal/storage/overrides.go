@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Config sources reported by EffectiveConfig
+const (
+	ConfigSourceDefault = "default"
+	ConfigSourceFile    = "file"
+	ConfigSourceAPI     = "api"
+)
+
+// CollectionOverride holds runtime settings set through the API, which win
+// over the collection's scout.yaml. A nil field is not overridden.
+type CollectionOverride struct {
+	CollectionID  int            `json:"collection_id"`
+	Interval      *time.Duration `json:"interval,omitempty"`
+	Paused        *bool          `json:"paused,omitempty"`
+	PassThreshold *float64       `json:"pass_threshold,omitempty"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// Empty reports whether the override no longer overrides anything
+func (o *CollectionOverride) Empty() bool {
+	return o.Interval == nil && o.Paused == nil && o.PassThreshold == nil
+}
+
+// EffectiveConfig is a collection's runtime settings after layering API
+// overrides over its scout.yaml and the global defaults
+type EffectiveConfig struct {
+	Interval      time.Duration
+	Paused        bool
+	PassThreshold *float64
+	// Sources maps each setting to where its value came from
+	Sources map[string]string
+}
+
+// OverriddenByAPI reports whether any setting comes from an API override
+func (c EffectiveConfig) OverriddenByAPI() bool {
+	for _, source := range c.Sources {
+		if source == ConfigSourceAPI {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON renders the interval as a duration string
+func (c EffectiveConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Interval        string            `json:"interval"`
+		Paused          bool              `json:"paused"`
+		PassThreshold   *float64          `json:"pass_threshold,omitempty"`
+		Sources         map[string]string `json:"sources"`
+		OverriddenByAPI bool              `json:"overridden_by_api"`
+	}{c.Interval.String(), c.Paused, c.PassThreshold, c.Sources, c.OverriddenByAPI()})
+}
+
+// GetCollectionOverride retrieves a collection's API override, or nil if it has none
+func (s *Storage) GetCollectionOverride(collectionID int) (*CollectionOverride, error) {
+	row := s.db.QueryRow(`
+		SELECT collection_id, interval_ms, paused, pass_threshold, updated_at
+		FROM collection_overrides WHERE collection_id = $1
+	`, collectionID)
+	o, err := scanOverride(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection override: %w", err)
+	}
+	return o, nil
+}
+
+// GetCollectionOverrides retrieves every API override keyed by collection ID
+func (s *Storage) GetCollectionOverrides() (map[int]*CollectionOverride, error) {
+	rows, err := s.db.Query(`SELECT collection_id, interval_ms, paused, pass_threshold, updated_at FROM collection_overrides`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[int]*CollectionOverride)
+	for rows.Next() {
+		o, err := scanOverride(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection override: %w", err)
+		}
+		overrides[o.CollectionID] = o
+	}
+	return overrides, rows.Err()
+}
+
+// SaveCollectionOverride stores a collection's API override, deleting it once
+// it no longer overrides anything
+func (s *Storage) SaveCollectionOverride(o *CollectionOverride) error {
+	if o.Empty() {
+		if _, err := s.db.Exec(`DELETE FROM collection_overrides WHERE collection_id = $1`, o.CollectionID); err != nil {
+			return fmt.Errorf("failed to clear collection override: %w", err)
+		}
+		return nil
+	}
+
+	var intervalMs *int64
+	if o.Interval != nil {
+		ms := o.Interval.Milliseconds()
+		intervalMs = &ms
+	}
+	query := `
+		INSERT INTO collection_overrides (collection_id, interval_ms, paused, pass_threshold, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (collection_id) DO UPDATE SET
+			interval_ms = EXCLUDED.interval_ms, paused = EXCLUDED.paused,
+			pass_threshold = EXCLUDED.pass_threshold, updated_at = EXCLUDED.updated_at
+	`
+	if _, err := s.db.Exec(query, o.CollectionID, intervalMs, o.Paused, o.PassThreshold, o.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save collection override: %w", err)
+	}
+	return nil
+}
+
+// scanOverride scans a collection_overrides row
+func scanOverride(row rowScanner) (*CollectionOverride, error) {
+	var o CollectionOverride
+	var intervalMs sql.NullInt64
+	if err := row.Scan(&o.CollectionID, &intervalMs, &o.Paused, &o.PassThreshold, &o.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if intervalMs.Valid {
+		interval := time.Duration(intervalMs.Int64) * time.Millisecond
+		o.Interval = &interval
+	}
+	return &o, nil
+}
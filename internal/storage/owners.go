@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OwnerRule routes notifications for failing tests whose name matches Pattern
+// to Owner, through its own Slack or webhook destination when one is set
+type OwnerRule struct {
+	// Pattern is a glob matched against the whole test name; * matches any
+	// run of characters and ? a single character
+	Pattern string `json:"pattern"`
+	Owner   string `json:"owner"`
+	// Webhook URLs are credentials, so they are stored but never returned by the API
+	SlackWebhookURL string `json:"-"`
+	WebhookURL      string `json:"-"`
+}
+
+// HasDestination reports whether the rule notifies somewhere other than the
+// globally configured notifiers
+func (r OwnerRule) HasDestination() bool {
+	return r.SlackWebhookURL != "" || r.WebhookURL != ""
+}
+
+// Matches reports whether testName matches the rule's pattern
+func (r OwnerRule) Matches(testName string) bool {
+	return MatchTestName(r.Pattern, testName)
+}
+
+// MatchTestName reports whether testName matches a glob matched against the
+// whole name; * matches any run of characters and ? a single character
+func MatchTestName(pattern, testName string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	matched, err := regexp.MatchString(b.String(), testName)
+	return err == nil && matched
+}
+
+// OwnerRules are a collection's test ownership rules from its directory's
+// scout.yaml, evaluated in order, stored as JSONB
+type OwnerRules []OwnerRule
+
+// Match returns the first rule matching testName, or nil when none does
+func (rules OwnerRules) Match(testName string) *OwnerRule {
+	for i := range rules {
+		if rules[i].Matches(testName) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// HasDestinations reports whether any rule has its own notification destination
+func (rules OwnerRules) HasDestinations() bool {
+	for _, rule := range rules {
+		if rule.HasDestination() {
+			return true
+		}
+	}
+	return false
+}
+
+// storedOwnerRule is an OwnerRule as persisted, including its webhook URLs
+type storedOwnerRule struct {
+	Pattern         string `json:"pattern"`
+	Owner           string `json:"owner"`
+	SlackWebhookURL string `json:"slack_webhook_url,omitempty"`
+	WebhookURL      string `json:"webhook_url,omitempty"`
+}
+
+// Value implements driver.Valuer
+func (rules OwnerRules) Value() (driver.Value, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	stored := make([]storedOwnerRule, len(rules))
+	for i, rule := range rules {
+		stored[i] = storedOwnerRule(rule)
+	}
+	return json.Marshal(stored)
+}
+
+// Scan implements sql.Scanner
+func (rules *OwnerRules) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*rules = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into owner rules", src)
+	}
+
+	var stored []storedOwnerRule
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	*rules = make(OwnerRules, len(stored))
+	for i, rule := range stored {
+		(*rules)[i] = OwnerRule(rule)
+	}
+	return nil
+}
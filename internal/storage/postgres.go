@@ -1,11 +1,12 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
-
-	_ "github.com/lib/pq"
 )
 
 // Storage provides database operations for Scout
@@ -13,9 +14,10 @@ type Storage struct {
 	db *sql.DB
 }
 
-// NewStorage creates a new Storage instance
-func NewStorage(connectionString string) (*Storage, error) {
-	db, err := sql.Open("postgres", connectionString)
+// NewStorage creates a new Storage instance. A positive statementTimeout
+// bounds every statement except those of migrations, export and import.
+func NewStorage(connectionString string, statementTimeout time.Duration) (*Storage, error) {
+	db, err := openDB(connectionString, statementTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -33,25 +35,38 @@ func NewStorage(connectionString string) (*Storage, error) {
 	return &Storage{db: db}, nil
 }
 
+// PoolStats summarizes connection pool usage, including time spent waiting for a connection
+func (s *Storage) PoolStats() map[string]interface{} {
+	stats := s.db.Stats()
+	return map[string]interface{}{
+		"max_open":         stats.MaxOpenConnections,
+		"open":             stats.OpenConnections,
+		"in_use":           stats.InUse,
+		"idle":             stats.Idle,
+		"wait_count":       stats.WaitCount,
+		"wait_duration_ms": stats.WaitDuration.Milliseconds(),
+	}
+}
+
 // Close closes the database connection
 func (s *Storage) Close() error {
 	return s.db.Close()
 }
 
 // UpsertCollection inserts or updates a collection
-func (s *Storage) UpsertCollection(name, filePath, compositeKey, directoryName, environmentName, collectionName string) (*Collection, error) {
+func (s *Storage) UpsertCollection(name, filePath, compositeKey, directoryName, environmentName, collectionName string, annotations Annotations, owners OwnerRules, dataFile *string) (*Collection, error) {
 	query := `
-		INSERT INTO collections (name, file_path, composite_key, directory_name, environment_name, collection_name, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO collections (name, file_path, composite_key, directory_name, environment_name, collection_name, annotations, owners, data_file, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		ON CONFLICT (composite_key)
-		DO UPDATE SET name = EXCLUDED.name, updated_at = EXCLUDED.updated_at
-		RETURNING id, name, file_path, composite_key, directory_name, environment_name, collection_name, created_at, updated_at
+		DO UPDATE SET name = EXCLUDED.name, file_path = EXCLUDED.file_path, annotations = EXCLUDED.annotations, owners = EXCLUDED.owners, data_file = EXCLUDED.data_file, updated_at = EXCLUDED.updated_at
+		RETURNING id, name, file_path, composite_key, directory_name, environment_name, collection_name, annotations, owners, data_file, baseline_execution_id, created_at, updated_at
 	`
 
 	now := time.Now()
 	var c Collection
-	err := s.db.QueryRow(query, name, filePath, compositeKey, directoryName, environmentName, collectionName, now, now).Scan(
-		&c.ID, &c.Name, &c.FilePath, &c.CompositeKey, &c.DirectoryName, &c.EnvironmentName, &c.CollectionName, &c.CreatedAt, &c.UpdatedAt,
+	err := s.db.QueryRow(query, name, filePath, compositeKey, directoryName, environmentName, collectionName, annotations, owners, dataFile, now, now).Scan(
+		&c.ID, &c.Name, &c.FilePath, &c.CompositeKey, &c.DirectoryName, &c.EnvironmentName, &c.CollectionName, &c.Annotations, &c.Owners, &c.DataFile, &c.BaselineExecutionID, &c.CreatedAt, &c.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert collection: %w", err)
@@ -78,11 +93,68 @@ func (s *Storage) GetCollectionByPath(filePath string) (*Collection, error) {
 	return &c, nil
 }
 
+// GetCollectionByID retrieves a collection by ID
+func (s *Storage) GetCollectionByID(id int) (*Collection, error) {
+	query := `SELECT id, name, file_path, composite_key, directory_name, environment_name, collection_name, annotations, owners, data_file, baseline_execution_id, created_at, updated_at FROM collections WHERE id = $1`
+
+	var c Collection
+	err := s.db.QueryRow(query, id).Scan(
+		&c.ID, &c.Name, &c.FilePath, &c.CompositeKey, &c.DirectoryName, &c.EnvironmentName, &c.CollectionName, &c.Annotations, &c.Owners, &c.DataFile, &c.BaselineExecutionID, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	return &c, nil
+}
+
+// GetCollectionByCompositeKey retrieves a collection by composite key, or nil if none has it
+func (s *Storage) GetCollectionByCompositeKey(compositeKey string) (*Collection, error) {
+	return s.GetCollectionByCompositeKeyContext(context.Background(), compositeKey)
+}
+
+// GetCollectionByCompositeKeyContext is GetCollectionByCompositeKey bounded by ctx
+func (s *Storage) GetCollectionByCompositeKeyContext(ctx context.Context, compositeKey string) (*Collection, error) {
+	query := `SELECT id, name, file_path, composite_key, directory_name, environment_name, collection_name, annotations, owners, data_file, baseline_execution_id, created_at, updated_at FROM collections WHERE composite_key = $1`
+
+	var c Collection
+	err := s.db.QueryRowContext(ctx, query, compositeKey).Scan(
+		&c.ID, &c.Name, &c.FilePath, &c.CompositeKey, &c.DirectoryName, &c.EnvironmentName, &c.CollectionName, &c.Annotations, &c.Owners, &c.DataFile, &c.BaselineExecutionID, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	return &c, nil
+}
+
 // GetAllCollections retrieves all collections
 func (s *Storage) GetAllCollections() ([]Collection, error) {
-	query := `SELECT id, name, file_path, composite_key, directory_name, environment_name, collection_name, created_at, updated_at FROM collections ORDER BY directory_name, environment_name, collection_name`
+	return s.GetAllCollectionsContext(context.Background())
+}
+
+// GetAllCollectionsContext is GetAllCollections bounded by ctx
+func (s *Storage) GetAllCollectionsContext(ctx context.Context) ([]Collection, error) {
+	return queryAllCollections(ctx, s.db)
+}
+
+// rowsQuerier runs queries on the database or within a transaction
+type rowsQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryAllCollections retrieves all collections through q, so transactions
+// can read them from their own snapshot
+func queryAllCollections(ctx context.Context, q rowsQuerier) ([]Collection, error) {
+	query := `SELECT id, name, file_path, composite_key, directory_name, environment_name, collection_name, annotations, owners, data_file, baseline_execution_id, created_at, updated_at FROM collections ORDER BY directory_name, environment_name, collection_name`
 
-	rows, err := s.db.Query(query)
+	rows, err := q.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query collections: %w", err)
 	}
@@ -91,7 +163,7 @@ func (s *Storage) GetAllCollections() ([]Collection, error) {
 	var collections []Collection
 	for rows.Next() {
 		var c Collection
-		if err := rows.Scan(&c.ID, &c.Name, &c.FilePath, &c.CompositeKey, &c.DirectoryName, &c.EnvironmentName, &c.CollectionName, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.FilePath, &c.CompositeKey, &c.DirectoryName, &c.EnvironmentName, &c.CollectionName, &c.Annotations, &c.Owners, &c.DataFile, &c.BaselineExecutionID, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan collection: %w", err)
 		}
 		collections = append(collections, c)
@@ -100,17 +172,53 @@ func (s *Storage) GetAllCollections() ([]Collection, error) {
 	return collections, rows.Err()
 }
 
+// executionColumns lists the test_executions columns read by scanExecution, in order
+const executionColumns = `id, collection_id, collection_name, started_at, completed_at,
+		       duration_ms, total_tests, passed_tests, failed_tests, error, proxy_used,
+		       node_version, newman_version, overridden, error_category, trigger_source,
+		       result_count, truncated, order_position, requests_total, transferred_bytes, warning, pass_threshold,
+		       tested_version, report_key, cancelled, environment_layers, newman_args, replay_of, replica, created_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanExecution scans a row selected with executionColumns into a TestExecution
+func scanExecution(row rowScanner) (*TestExecution, error) {
+	var e TestExecution
+	err := row.Scan(
+		&e.ID, &e.CollectionID, &e.CollectionName, &e.StartedAt, &e.CompletedAt,
+		&e.DurationMs, &e.TotalTests, &e.PassedTests, &e.FailedTests, &e.Error, &e.ProxyUsed,
+		&e.NodeVersion, &e.NewmanVersion, &e.Overridden, &e.ErrorCategory, &e.TriggerSource,
+		&e.ResultCount, &e.Truncated, &e.OrderPosition, &e.RequestsTotal, &e.TransferredBytes, &e.Warning, &e.PassThreshold,
+		&e.TestedVersion, &e.ReportKey, &e.Cancelled, &e.EnvironmentLayers, &e.NewmanArgs, &e.ReplayOf, &e.Replica, &e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
 // CreateTestExecution creates a new test execution record
 func (s *Storage) CreateTestExecution(exec *TestExecution) error {
+	return insertTestExecution(s.db, exec)
+}
+
+// insertTestExecution inserts a test execution record
+func insertTestExecution(q execQuerier, exec *TestExecution) error {
 	query := `
 		INSERT INTO test_executions (
 			collection_id, collection_name, started_at, completed_at,
-			duration_ms, total_tests, passed_tests, failed_tests, error
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			duration_ms, total_tests, passed_tests, failed_tests, error, proxy_used,
+			node_version, newman_version, overridden, error_category, trigger_source,
+			result_count, truncated, order_position, requests_total, transferred_bytes, warning, pass_threshold,
+			tested_version, cancelled, environment_layers, newman_args, replay_of, replica
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28)
 		RETURNING id, created_at
 	`
 
-	err := s.db.QueryRow(
+	err := q.QueryRow(
 		query,
 		exec.CollectionID,
 		exec.CollectionName,
@@ -121,6 +229,25 @@ func (s *Storage) CreateTestExecution(exec *TestExecution) error {
 		exec.PassedTests,
 		exec.FailedTests,
 		exec.Error,
+		exec.ProxyUsed,
+		exec.NodeVersion,
+		exec.NewmanVersion,
+		exec.Overridden,
+		exec.ErrorCategory,
+		exec.TriggerSource,
+		exec.ResultCount,
+		exec.Truncated,
+		exec.OrderPosition,
+		exec.RequestsTotal,
+		exec.TransferredBytes,
+		exec.Warning,
+		exec.PassThreshold,
+		exec.TestedVersion,
+		exec.Cancelled,
+		exec.EnvironmentLayers,
+		exec.NewmanArgs,
+		exec.ReplayOf,
+		exec.Replica,
 	).Scan(&exec.ID, &exec.CreatedAt)
 
 	if err != nil {
@@ -130,17 +257,54 @@ func (s *Storage) CreateTestExecution(exec *TestExecution) error {
 	return nil
 }
 
+// execQuerier is satisfied by both *sql.DB and *sql.Tx
+type execQuerier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // CreateTestResult creates a new test result record
 func (s *Storage) CreateTestResult(result *TestResult) error {
+	return insertTestResult(s.db, result)
+}
+
+// CreateTestResults stores an execution's test results in a single transaction,
+// so the whole batch holds one pooled connection instead of acquiring one per row
+func (s *Storage) CreateTestResults(results []TestResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range results {
+		if err := insertTestResult(tx, &results[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit test results: %w", err)
+	}
+	return nil
+}
+
+// insertTestResult inserts a test result and its captured headers
+func insertTestResult(q execQuerier, result *TestResult) error {
 	query := `
 		INSERT INTO test_results (
 			execution_id, test_name, execution_name, url, method,
-			status, status_code, response_time_ms, passed, error
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			status, status_code, response_time_ms, passed, error, sequence, detail, redirects,
+			expected_failure
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id, created_at
 	`
 
-	err := s.db.QueryRow(
+	err := q.QueryRow(
 		query,
 		result.ExecutionID,
 		result.TestName,
@@ -152,25 +316,42 @@ func (s *Storage) CreateTestResult(result *TestResult) error {
 		result.ResponseTimeMs,
 		result.Passed,
 		result.Error,
+		result.Sequence,
+		result.Detail,
+		result.Redirects,
+		result.ExpectedFailure,
 	).Scan(&result.ID, &result.CreatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create test result: %w", err)
 	}
 
+	for _, header := range result.Headers {
+		if _, err := q.Exec(
+			`INSERT INTO test_result_headers (result_id, name, value) VALUES ($1, $2, $3)`,
+			result.ID, header.Name, header.Value,
+		); err != nil {
+			return fmt.Errorf("failed to create test result header: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // GetLatestExecutions retrieves the latest execution for each collection
 func (s *Storage) GetLatestExecutions() ([]TestExecution, error) {
+	return s.GetLatestExecutionsContext(context.Background())
+}
+
+// GetLatestExecutionsContext is GetLatestExecutions bounded by ctx
+func (s *Storage) GetLatestExecutionsContext(ctx context.Context) ([]TestExecution, error) {
 	query := `
-		SELECT id, collection_id, collection_name, started_at, completed_at,
-		       duration_ms, total_tests, passed_tests, failed_tests, error, created_at
+		SELECT ` + executionColumns + `
 		FROM latest_test_executions
 		ORDER BY collection_name
 	`
 
-	rows, err := s.db.Query(query)
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query latest executions: %w", err)
 	}
@@ -178,38 +359,43 @@ func (s *Storage) GetLatestExecutions() ([]TestExecution, error) {
 
 	var executions []TestExecution
 	for rows.Next() {
-		var e TestExecution
-		if err := rows.Scan(
-			&e.ID, &e.CollectionID, &e.CollectionName, &e.StartedAt, &e.CompletedAt,
-			&e.DurationMs, &e.TotalTests, &e.PassedTests, &e.FailedTests, &e.Error, &e.CreatedAt,
-		); err != nil {
+		e, err := scanExecution(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan execution: %w", err)
 		}
-		executions = append(executions, e)
+		executions = append(executions, *e)
 	}
 
 	return executions, rows.Err()
 }
 
+// SetReportKey records where an execution's raw report was stored
+func (s *Storage) SetReportKey(executionID int, key string) error {
+	if _, err := s.db.Exec(`UPDATE test_executions SET report_key = $1 WHERE id = $2`, key, executionID); err != nil {
+		return fmt.Errorf("failed to set report key: %w", err)
+	}
+	return nil
+}
+
 // GetLastSuccessfulExecution retrieves the last successful execution for a collection
 func (s *Storage) GetLastSuccessfulExecution(collectionID int) (*TestExecution, error) {
+	return s.GetLastSuccessfulExecutionContext(context.Background(), collectionID)
+}
+
+// GetLastSuccessfulExecutionContext is GetLastSuccessfulExecution bounded by ctx
+func (s *Storage) GetLastSuccessfulExecutionContext(ctx context.Context, collectionID int) (*TestExecution, error) {
 	query := `
-		SELECT id, collection_id, collection_name, started_at, completed_at,
-		       duration_ms, total_tests, passed_tests, failed_tests, error, created_at
+		SELECT ` + executionColumns + `
 		FROM test_executions
 		WHERE collection_id = $1
 		  AND failed_tests = 0
 		  AND total_tests > 0
+		  AND NOT overridden
 		ORDER BY started_at DESC
 		LIMIT 1
 	`
 
-	var e TestExecution
-	err := s.db.QueryRow(query, collectionID).Scan(
-		&e.ID, &e.CollectionID, &e.CollectionName, &e.StartedAt, &e.CompletedAt,
-		&e.DurationMs, &e.TotalTests, &e.PassedTests, &e.FailedTests, &e.Error, &e.CreatedAt,
-	)
-
+	e, err := scanExecution(s.db.QueryRowContext(ctx, query, collectionID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // No successful execution found
@@ -217,20 +403,196 @@ func (s *Storage) GetLastSuccessfulExecution(collectionID int) (*TestExecution,
 		return nil, fmt.Errorf("failed to query last successful execution: %w", err)
 	}
 
-	return &e, nil
+	return e, nil
+}
+
+// GetPreviousExecution retrieves the latest non-overridden, non-cancelled
+// execution of a collection before the given execution, or nil if there is none
+func (s *Storage) GetPreviousExecution(collectionID, executionID int) (*TestExecution, error) {
+	query := `
+		SELECT ` + executionColumns + `
+		FROM test_executions
+		WHERE collection_id = $1
+		  AND id < $2
+		  AND NOT overridden
+		  AND NOT cancelled
+		ORDER BY started_at DESC
+		LIMIT 1
+	`
+
+	e, err := scanExecution(s.db.QueryRow(query, collectionID, executionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query previous execution: %w", err)
+	}
+
+	return e, nil
+}
+
+// GetExecutionByID retrieves a single execution by ID
+func (s *Storage) GetExecutionByID(executionID int) (*TestExecution, error) {
+	return s.GetExecutionByIDContext(context.Background(), executionID)
+}
+
+// GetExecutionByIDContext is GetExecutionByID bounded by ctx
+func (s *Storage) GetExecutionByIDContext(ctx context.Context, executionID int) (*TestExecution, error) {
+	query := `SELECT ` + executionColumns + ` FROM test_executions WHERE id = $1`
+
+	e, err := scanExecution(s.db.QueryRowContext(ctx, query, executionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution: %w", err)
+	}
+
+	return e, nil
+}
+
+// GetExecutionWithResults retrieves an execution and its test results, or nil if not found
+func (s *Storage) GetExecutionWithResults(executionID int, order ResultOrder) (*ExecutionWithResults, error) {
+	return s.GetExecutionWithResultsContext(context.Background(), executionID, order)
+}
+
+// GetExecutionWithResultsContext is GetExecutionWithResults bounded by ctx
+func (s *Storage) GetExecutionWithResultsContext(ctx context.Context, executionID int, order ResultOrder) (*ExecutionWithResults, error) {
+	execution, err := s.GetExecutionByIDContext(ctx, executionID)
+	if err != nil || execution == nil {
+		return nil, err
+	}
+
+	results, err := s.GetTestResultsByExecutionIDContext(ctx, executionID, order)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []TestResult{}
+	}
+
+	headers, err := s.getResultHeadersByExecutionID(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Headers = headers[results[i].ID]
+	}
+
+	requests, err := s.GetRequestResultsByExecutionIDContext(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := s.getExecutionVariables(ctx, executionID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExecutionWithResults{
+		Execution:           *execution,
+		Results:             results,
+		Requests:            requests,
+		CollectionVariables: variables,
+	}, nil
+}
+
+// GetExecutionVariables returns an execution's collection variables as
+// recorded at the end of the run
+func (s *Storage) GetExecutionVariables(executionID int) ([]CollectionVariable, error) {
+	return s.getExecutionVariables(context.Background(), executionID, false)
+}
+
+// GetExecutionInputs returns an execution's collection variables as recorded
+// at the start of the run, empty for executions stored before they were
+func (s *Storage) GetExecutionInputs(executionID int) ([]CollectionVariable, error) {
+	return s.getExecutionVariables(context.Background(), executionID, true)
+}
+
+// CreateExecutionVariables records an execution's collection variables at the
+// end of the run, and its inputs: the variables at the start of the run
+func (s *Storage) CreateExecutionVariables(executionID int, variables, inputs []CollectionVariable) error {
+	if len(variables) == 0 && len(inputs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert := func(v CollectionVariable, atStart bool) error {
+		if _, err := tx.Exec(
+			`INSERT INTO execution_variables (execution_id, name, value, overridden, at_start) VALUES ($1, $2, $3, $4, $5)`,
+			executionID, v.Name, v.Value, v.Overridden, atStart,
+		); err != nil {
+			return fmt.Errorf("failed to insert execution variable: %w", err)
+		}
+		return nil
+	}
+	for _, v := range inputs {
+		if err := insert(v, true); err != nil {
+			return err
+		}
+	}
+	for _, v := range variables {
+		if err := insert(v, false); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit execution variables: %w", err)
+	}
+	return nil
+}
+
+// getExecutionVariables returns an execution's collection variables at the
+// start or end of the run, in the order Newman reported them
+func (s *Storage) getExecutionVariables(ctx context.Context, executionID int, atStart bool) ([]CollectionVariable, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT name, value, overridden FROM execution_variables WHERE execution_id = $1 AND at_start = $2 ORDER BY id`,
+		executionID, atStart,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query execution variables: %w", err)
+	}
+	defer rows.Close()
+
+	variables := []CollectionVariable{}
+	for rows.Next() {
+		var v CollectionVariable
+		if err := rows.Scan(&v.Name, &v.Value, &v.Overridden); err != nil {
+			return nil, fmt.Errorf("failed to scan execution variable: %w", err)
+		}
+		variables = append(variables, v)
+	}
+
+	return variables, rows.Err()
 }
 
 // GetTestResultsByExecutionID retrieves all test results for a given execution
-func (s *Storage) GetTestResultsByExecutionID(executionID int) ([]TestResult, error) {
+func (s *Storage) GetTestResultsByExecutionID(executionID int, order ResultOrder) ([]TestResult, error) {
+	return s.GetTestResultsByExecutionIDContext(context.Background(), executionID, order)
+}
+
+// GetTestResultsByExecutionIDContext is GetTestResultsByExecutionID bounded by ctx
+func (s *Storage) GetTestResultsByExecutionIDContext(ctx context.Context, executionID int, order ResultOrder) ([]TestResult, error) {
+	orderBy := "sequence NULLS LAST, test_name, id"
+	if order == ResultOrderName {
+		orderBy = "test_name, id"
+	}
+
 	query := `
 		SELECT id, execution_id, test_name, execution_name, url, method,
-		       status, status_code, response_time_ms, passed, error, created_at
+		       status, status_code, response_time_ms, passed, error, sequence, detail, redirects,
+		       expected_failure, created_at
 		FROM test_results
 		WHERE execution_id = $1
-		ORDER BY test_name
-	`
+		ORDER BY ` + orderBy
 
-	rows, err := s.db.Query(query, executionID)
+	rows, err := s.db.QueryContext(ctx, query, executionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query test results: %w", err)
 	}
@@ -241,7 +603,8 @@ func (s *Storage) GetTestResultsByExecutionID(executionID int) ([]TestResult, er
 		var r TestResult
 		if err := rows.Scan(
 			&r.ID, &r.ExecutionID, &r.TestName, &r.ExecutionName, &r.URL, &r.Method,
-			&r.Status, &r.StatusCode, &r.ResponseTimeMs, &r.Passed, &r.Error, &r.CreatedAt,
+			&r.Status, &r.StatusCode, &r.ResponseTimeMs, &r.Passed, &r.Error, &r.Sequence, &r.Detail, &r.Redirects,
+			&r.ExpectedFailure, &r.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan test result: %w", err)
 		}
@@ -251,14 +614,208 @@ func (s *Storage) GetTestResultsByExecutionID(executionID int) ([]TestResult, er
 	return results, rows.Err()
 }
 
+// getResultHeadersByExecutionID retrieves captured response headers for an
+// execution's results, keyed by result ID
+func (s *Storage) getResultHeadersByExecutionID(ctx context.Context, executionID int) (map[int][]ResponseHeader, error) {
+	query := `
+		SELECT h.result_id, h.name, h.value
+		FROM test_result_headers h
+		JOIN test_results tr ON tr.id = h.result_id
+		WHERE tr.execution_id = $1
+		ORDER BY h.id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query test result headers: %w", err)
+	}
+	defer rows.Close()
+
+	headers := make(map[int][]ResponseHeader)
+	for rows.Next() {
+		var resultID int
+		var h ResponseHeader
+		if err := rows.Scan(&resultID, &h.Name, &h.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan test result header: %w", err)
+		}
+		headers[resultID] = append(headers[resultID], h)
+	}
+
+	return headers, rows.Err()
+}
+
+// SearchTestResults finds test results in each collection's latest execution.
+// The query is driven from latest_test_executions so substring matching only
+// scans the current results via the execution_id index.
+func (s *Storage) SearchTestResults(filter SearchFilter) ([]SearchResult, error) {
+	return s.SearchTestResultsContext(context.Background(), filter)
+}
+
+// SearchTestResultsContext is SearchTestResults bounded by ctx
+func (s *Storage) SearchTestResultsContext(ctx context.Context, filter SearchFilter) ([]SearchResult, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+
+	if filter.TestName != "" {
+		args = append(args, "%"+escapeLike(filter.TestName)+"%")
+		conditions = append(conditions, fmt.Sprintf("tr.test_name ILIKE $%d", len(args)))
+	}
+	if filter.URLContains != "" {
+		args = append(args, "%"+escapeLike(filter.URLContains)+"%")
+		conditions = append(conditions, fmt.Sprintf("tr.url ILIKE $%d", len(args)))
+	}
+	if filter.Passed != nil {
+		args = append(args, *filter.Passed)
+		conditions = append(conditions, fmt.Sprintf("tr.passed = $%d", len(args)))
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT c.id, c.composite_key, c.collection_name, c.directory_name, c.environment_name,
+		       le.id, le.started_at,
+		       tr.id, tr.execution_id, tr.test_name, tr.execution_name, tr.url, tr.method,
+		       tr.status, tr.status_code, tr.response_time_ms, tr.passed, tr.error, tr.expected_failure, tr.created_at
+		FROM latest_test_executions le
+		JOIN collections c ON c.id = le.collection_id
+		JOIN test_results tr ON tr.execution_id = le.id
+		WHERE %s
+		ORDER BY c.composite_key, tr.test_name, tr.id
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search test results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var sr SearchResult
+		r := &sr.Result
+		if err := rows.Scan(
+			&sr.CollectionID, &sr.CompositeKey, &sr.CollectionName, &sr.DirectoryName, &sr.EnvironmentName,
+			&sr.ExecutionID, &sr.StartedAt,
+			&r.ID, &r.ExecutionID, &r.TestName, &r.ExecutionName, &r.URL, &r.Method,
+			&r.Status, &r.StatusCode, &r.ResponseTimeMs, &r.Passed, &r.Error, &r.ExpectedFailure, &r.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		results = append(results, sr)
+	}
+
+	return results, rows.Err()
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// collectionResult builds a collection's result from its latest execution,
+// with the last successful run and baseline divergence
+func (s *Storage) collectionResult(ctx context.Context, col Collection, exec *TestExecution, ack *Acknowledgment) (*CollectionResult, error) {
+	cr := &CollectionResult{
+		Collection: col,
+		Execution:  exec,
+		Results:    []TestResult{},
+		Requests:   []RequestResult{},
+		Ack:        ack,
+	}
+
+	// Get last successful execution for this collection
+	lastSuccess, err := s.GetLastSuccessfulExecutionContext(ctx, col.ID)
+	if err != nil {
+		return nil, err
+	}
+	cr.LastSuccessExecution = lastSuccess
+
+	// Get test results for this execution
+	testResults, err := s.GetTestResultsByExecutionIDContext(ctx, exec.ID, ResultOrderSequence)
+	if err != nil {
+		return nil, err
+	}
+	cr.Results = testResults
+
+	cr.Requests, err = s.GetRequestResultsByExecutionIDContext(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare against the pinned baseline, unless the latest run is the baseline
+	if baselineID := col.BaselineExecutionID; baselineID != nil && *baselineID != exec.ID {
+		baseline, err := s.GetExecutionWithResultsContext(ctx, *baselineID, ResultOrderSequence)
+		if err != nil {
+			return nil, err
+		}
+		if baseline != nil {
+			cr.DivergedFromBaseline = DiffExecutions(*baseline, ExecutionWithResults{Execution: *exec, Results: testResults}).Diverged()
+		}
+	}
+
+	return cr, nil
+}
+
+// GetLatestResultByCompositeKey retrieves the latest execution and results of
+// the collection with the given composite key. It returns nil if no such
+// collection exists, and a result without an execution if it never ran.
+func (s *Storage) GetLatestResultByCompositeKey(compositeKey string) (*CollectionResult, error) {
+	return s.GetLatestResultByCompositeKeyContext(context.Background(), compositeKey)
+}
+
+// GetLatestResultByCompositeKeyContext is GetLatestResultByCompositeKey bounded by ctx
+func (s *Storage) GetLatestResultByCompositeKeyContext(ctx context.Context, compositeKey string) (*CollectionResult, error) {
+	col, err := s.GetCollectionByCompositeKeyContext(ctx, compositeKey)
+	if err != nil || col == nil {
+		return nil, err
+	}
+
+	exec, err := scanExecution(s.db.QueryRowContext(ctx, `SELECT `+executionColumns+` FROM latest_test_executions WHERE collection_id = $1`, col.ID))
+	if err == sql.ErrNoRows {
+		return &CollectionResult{Collection: *col, Results: []TestResult{}, Requests: []RequestResult{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest execution: %w", err)
+	}
+
+	ack, err := s.GetAckContext(ctx, col.ID)
+	if err != nil {
+		return nil, err
+	}
+	cr, err := s.collectionResult(ctx, *col, exec, ack)
+	if err != nil {
+		return nil, err
+	}
+	cr.LatencyAlert, err = s.getLatencyAlertContext(ctx, col.ID)
+	if err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
 // GetLatestResults retrieves the latest execution and results for all collections
 func (s *Storage) GetLatestResults() (*LatestResults, error) {
-	collections, err := s.GetAllCollections()
+	return s.GetLatestResultsContext(context.Background())
+}
+
+// GetLatestResultsContext is GetLatestResults bounded by ctx
+func (s *Storage) GetLatestResultsContext(ctx context.Context) (*LatestResults, error) {
+	collections, err := s.GetAllCollectionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	executions, err := s.GetLatestExecutionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	acks, err := s.getAcks(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	executions, err := s.GetLatestExecutions()
+	latencyAlerts, err := s.getLatencyAlerts(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -284,27 +841,12 @@ func (s *Storage) GetLatestResults() (*LatestResults, error) {
 			continue // Skip if collection not found
 		}
 
-		cr := CollectionResult{
-			Collection: *matchingCol,
-			Execution:  &exec,
-			Results:    []TestResult{},
-		}
-
-		// Get last successful execution for this collection
-		lastSuccess, err := s.GetLastSuccessfulExecution(exec.CollectionID)
-		if err != nil {
-			return nil, err
-		}
-		cr.LastSuccessExecution = lastSuccess
-
-		// Get test results for this execution
-		testResults, err := s.GetTestResultsByExecutionID(exec.ID)
+		cr, err := s.collectionResult(ctx, *matchingCol, &exec, acks[exec.CollectionID])
 		if err != nil {
 			return nil, err
 		}
-		cr.Results = testResults
-
-		collectionResults = append(collectionResults, cr)
+		cr.LatencyAlert = latencyAlerts[exec.CollectionID]
+		collectionResults = append(collectionResults, *cr)
 	}
 
 	// Group collection results by directory and environment
@@ -343,6 +885,9 @@ func (s *Storage) GetLatestResults() (*LatestResults, error) {
 
 		envGroups = append(envGroups, group)
 	}
+	SortEnvironmentGroups(envGroups)
+	QualifyEnvironmentNames(envGroups)
+	ComputeRollups(envGroups)
 
 	results := &LatestResults{
 		EnvironmentGroups: envGroups,
@@ -352,18 +897,121 @@ func (s *Storage) GetLatestResults() (*LatestResults, error) {
 	return results, nil
 }
 
+// GetMatrix builds a collection-by-environment grid of latest statuses
+func (s *Storage) GetMatrix() (*Matrix, error) {
+	return s.GetMatrixContext(context.Background())
+}
+
+// GetMatrixContext is GetMatrix bounded by ctx
+func (s *Storage) GetMatrixContext(ctx context.Context) (*Matrix, error) {
+	query := `
+		SELECT c.id, c.directory_name, c.environment_name, c.collection_name,
+		       le.id, le.started_at, le.total_tests, le.passed_tests, le.failed_tests, le.error, le.warning, le.pass_threshold
+		FROM collections c
+		LEFT JOIN latest_test_executions le ON le.collection_id = c.id
+		ORDER BY c.directory_name, c.collection_name, c.environment_name
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query matrix: %w", err)
+	}
+	defer rows.Close()
+
+	type rowKey struct {
+		directory  string
+		collection string
+	}
+
+	matrix := &Matrix{Environments: []string{}, Rows: []MatrixRow{}}
+	rowIndex := make(map[rowKey]int)
+	envSeen := make(map[string]bool)
+
+	for rows.Next() {
+		var (
+			collectionID         int
+			directory, env, name string
+			executionID          sql.NullInt64
+			startedAt            sql.NullTime
+			totalTests           sql.NullInt64
+			passedTests          sql.NullInt64
+			failedTests          sql.NullInt64
+			executionError       *string
+			warning              sql.NullBool
+			passThreshold        *float64
+		)
+		if err := rows.Scan(&collectionID, &directory, &env, &name, &executionID, &startedAt,
+			&totalTests, &passedTests, &failedTests, &executionError, &warning, &passThreshold); err != nil {
+			return nil, fmt.Errorf("failed to scan matrix row: %w", err)
+		}
+
+		cell := &MatrixCell{CollectionID: collectionID, Status: StatusNeverRun}
+		if executionID.Valid {
+			id := int(executionID.Int64)
+			started := startedAt.Time
+			cell.ExecutionID = &id
+			cell.StartedAt = &started
+			cell.Status = (&TestExecution{
+				TotalTests:    int(totalTests.Int64),
+				PassedTests:   int(passedTests.Int64),
+				FailedTests:   int(failedTests.Int64),
+				Error:         executionError,
+				Warning:       warning.Bool,
+				PassThreshold: passThreshold,
+			}).Status()
+		}
+
+		key := rowKey{directory: directory, collection: name}
+		idx, ok := rowIndex[key]
+		if !ok {
+			idx = len(matrix.Rows)
+			rowIndex[key] = idx
+			matrix.Rows = append(matrix.Rows, MatrixRow{
+				Directory:      directory,
+				CollectionName: name,
+				Cells:          map[string]*MatrixCell{},
+			})
+		}
+		matrix.Rows[idx].Cells[env] = cell
+
+		if !envSeen[env] {
+			envSeen[env] = true
+			matrix.Environments = append(matrix.Environments, env)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every row gets a cell per environment; blanks are null
+	sort.Strings(matrix.Environments)
+	for _, row := range matrix.Rows {
+		for _, env := range matrix.Environments {
+			if _, ok := row.Cells[env]; !ok {
+				row.Cells[env] = nil
+			}
+		}
+	}
+
+	return matrix, nil
+}
+
 // GetExecutionHistory retrieves execution history for a collection
 func (s *Storage) GetExecutionHistory(collectionID int, limit int) ([]TestExecution, error) {
+	return s.GetExecutionHistoryContext(context.Background(), collectionID, limit)
+}
+
+// GetExecutionHistoryContext is GetExecutionHistory bounded by ctx
+func (s *Storage) GetExecutionHistoryContext(ctx context.Context, collectionID int, limit int) ([]TestExecution, error) {
 	query := `
-		SELECT id, collection_id, collection_name, started_at, completed_at,
-		       duration_ms, total_tests, passed_tests, failed_tests, error, created_at
+		SELECT ` + executionColumns + `
 		FROM test_executions
 		WHERE collection_id = $1
 		ORDER BY started_at DESC
 		LIMIT $2
 	`
 
-	rows, err := s.db.Query(query, collectionID, limit)
+	rows, err := s.db.QueryContext(ctx, query, collectionID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query execution history: %w", err)
 	}
@@ -371,22 +1019,348 @@ func (s *Storage) GetExecutionHistory(collectionID int, limit int) ([]TestExecut
 
 	var executions []TestExecution
 	for rows.Next() {
-		var e TestExecution
-		if err := rows.Scan(
-			&e.ID, &e.CollectionID, &e.CollectionName, &e.StartedAt, &e.CompletedAt,
-			&e.DurationMs, &e.TotalTests, &e.PassedTests, &e.FailedTests, &e.Error, &e.CreatedAt,
-		); err != nil {
+		e, err := scanExecution(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan execution: %w", err)
 		}
-		executions = append(executions, e)
+		executions = append(executions, *e)
 	}
 
 	return executions, rows.Err()
 }
 
+// GetTestHistory retrieves a single test's outcome across a collection's last
+// limit executions, oldest first. Executions where the test did not run are
+// included as points with Present set to false.
+func (s *Storage) GetTestHistory(collectionID int, testName string, limit int) (*TestHistory, error) {
+	return s.GetTestHistoryContext(context.Background(), collectionID, testName, limit)
+}
+
+// GetTestHistoryContext is GetTestHistory bounded by ctx
+func (s *Storage) GetTestHistoryContext(ctx context.Context, collectionID int, testName string, limit int) (*TestHistory, error) {
+	query := `
+		SELECT te.id, te.started_at, tr.passed, tr.status_code, tr.response_time_ms
+		FROM (
+			SELECT id, started_at
+			FROM test_executions
+			WHERE collection_id = $1
+			ORDER BY started_at DESC
+			LIMIT $3
+		) te
+		LEFT JOIN LATERAL (
+			SELECT passed, status_code, response_time_ms
+			FROM test_results
+			WHERE execution_id = te.id AND test_name = $2
+			ORDER BY id
+			LIMIT 1
+		) tr ON TRUE
+		ORDER BY te.started_at ASC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, collectionID, testName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query test history: %w", err)
+	}
+	defer rows.Close()
+
+	history := &TestHistory{
+		CollectionID: collectionID,
+		TestName:     testName,
+		Points:       []TestHistoryPoint{},
+	}
+	for rows.Next() {
+		var p TestHistoryPoint
+		if err := rows.Scan(&p.ExecutionID, &p.StartedAt, &p.Passed, &p.StatusCode, &p.ResponseTimeMs); err != nil {
+			return nil, fmt.Errorf("failed to scan test history: %w", err)
+		}
+		p.Present = p.Passed != nil
+		history.Points = append(history.Points, p)
+	}
+
+	return history, rows.Err()
+}
+
+// GetDurationTrend computes the duration trend over a collection's last window
+// executions. Overridden and errored runs are excluded since their durations
+// aren't comparable.
+func (s *Storage) GetDurationTrend(collectionID, window int, factor float64) (*DurationTrend, error) {
+	return s.GetDurationTrendContext(context.Background(), collectionID, window, factor)
+}
+
+// GetDurationTrendContext is GetDurationTrend bounded by ctx
+func (s *Storage) GetDurationTrendContext(ctx context.Context, collectionID, window int, factor float64) (*DurationTrend, error) {
+	query := `
+		SELECT id, started_at, duration_ms
+		FROM (
+			SELECT id, started_at, duration_ms
+			FROM test_executions
+			WHERE collection_id = $1
+			  AND NOT overridden
+			  AND error IS NULL
+			ORDER BY started_at DESC
+			LIMIT $2
+		) recent
+		ORDER BY started_at ASC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, collectionID, window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duration trend: %w", err)
+	}
+	defer rows.Close()
+
+	var points []DurationPoint
+	for rows.Next() {
+		var p DurationPoint
+		if err := rows.Scan(&p.ExecutionID, &p.StartedAt, &p.DurationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan duration trend: %w", err)
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newDurationTrend(collectionID, window, factor, points), nil
+}
+
+// GetAllDurationTrends is GetDurationTrend for every collection in one query,
+// by collection ID. Collections without comparable executions get an empty trend.
+func (s *Storage) GetAllDurationTrends(window int, factor float64) (map[int]*DurationTrend, error) {
+	query := `
+		SELECT c.id, recent.id, recent.started_at, recent.duration_ms
+		FROM collections c
+		LEFT JOIN LATERAL (
+			SELECT id, started_at, duration_ms
+			FROM test_executions
+			WHERE collection_id = c.id
+			  AND NOT overridden
+			  AND error IS NULL
+			ORDER BY started_at DESC
+			LIMIT $1
+		) recent ON true
+		ORDER BY c.id, recent.started_at ASC
+	`
+
+	rows, err := s.db.Query(query, window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duration trends: %w", err)
+	}
+	defer rows.Close()
+
+	points := make(map[int][]DurationPoint)
+	for rows.Next() {
+		var collectionID int
+		var executionID, durationMs sql.NullInt64
+		var startedAt sql.NullTime
+		if err := rows.Scan(&collectionID, &executionID, &startedAt, &durationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan duration trend: %w", err)
+		}
+		if !executionID.Valid {
+			points[collectionID] = nil
+			continue
+		}
+		points[collectionID] = append(points[collectionID], DurationPoint{
+			ExecutionID: int(executionID.Int64),
+			StartedAt:   startedAt.Time,
+			DurationMs:  int(durationMs.Int64),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	trends := make(map[int]*DurationTrend, len(points))
+	for collectionID, p := range points {
+		trends[collectionID] = newDurationTrend(collectionID, window, factor, p)
+	}
+	return trends, nil
+}
+
+// GetUptime computes the success rate of a collection's executions since a point in time.
+// Runs started manually or via the API are excluded unless includeNonScheduled is set;
+// runs with variable overrides and cancelled runs are always excluded.
+func (s *Storage) GetUptime(collectionID int, since time.Time, includeNonScheduled bool) (*Uptime, error) {
+	return s.GetUptimeContext(context.Background(), collectionID, since, includeNonScheduled)
+}
+
+// GetUptimeContext is GetUptime bounded by ctx
+func (s *Storage) GetUptimeContext(ctx context.Context, collectionID int, since time.Time, includeNonScheduled bool) (*Uptime, error) {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE failed_tests = 0 AND total_tests > 0)
+		FROM test_executions
+		WHERE collection_id = $1
+		  AND started_at >= $2
+		  AND NOT overridden
+		  AND NOT cancelled
+		  AND ($3 OR trigger_source IN ('scheduled', 'startup'))
+	`
+
+	uptime := &Uptime{
+		CollectionID:        collectionID,
+		Since:               since,
+		IncludeNonScheduled: includeNonScheduled,
+	}
+	err := s.db.QueryRowContext(ctx, query, collectionID, since, includeNonScheduled).Scan(&uptime.TotalRuns, &uptime.SuccessfulRuns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query uptime: %w", err)
+	}
+
+	if uptime.TotalRuns > 0 {
+		uptime.UptimePercent = float64(uptime.SuccessfulRuns) / float64(uptime.TotalRuns) * 100
+	}
+
+	return uptime, nil
+}
+
+// AckCollection records an acknowledgment, replacing any existing one
+func (s *Storage) AckCollection(ack *Acknowledgment) error {
+	query := `
+		INSERT INTO collection_acks (collection_id, acked_by, reason, acked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (collection_id) DO UPDATE SET
+			acked_by = EXCLUDED.acked_by, reason = EXCLUDED.reason, acked_at = EXCLUDED.acked_at
+	`
+	if _, err := s.db.Exec(query, ack.CollectionID, ack.User, ack.Reason, ack.AcknowledgedAt); err != nil {
+		return fmt.Errorf("failed to acknowledge collection: %w", err)
+	}
+	return nil
+}
+
+// GetAck retrieves a collection's acknowledgment, or nil if it has none
+func (s *Storage) GetAck(collectionID int) (*Acknowledgment, error) {
+	return s.GetAckContext(context.Background(), collectionID)
+}
+
+// GetAckContext is GetAck bounded by ctx
+func (s *Storage) GetAckContext(ctx context.Context, collectionID int) (*Acknowledgment, error) {
+	var ack Acknowledgment
+	err := s.db.QueryRowContext(ctx,
+		`SELECT collection_id, acked_by, reason, acked_at FROM collection_acks WHERE collection_id = $1`,
+		collectionID,
+	).Scan(&ack.CollectionID, &ack.User, &ack.Reason, &ack.AcknowledgedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get acknowledgment: %w", err)
+	}
+	return &ack, nil
+}
+
+// ClearAck removes a collection's acknowledgment, reporting whether one existed
+func (s *Storage) ClearAck(collectionID int) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM collection_acks WHERE collection_id = $1`, collectionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to clear acknowledgment: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to clear acknowledgment: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// SetBaseline pins an execution as a collection's baseline; nil clears it
+func (s *Storage) SetBaseline(collectionID int, executionID *int) error {
+	if _, err := s.db.Exec(`UPDATE collections SET baseline_execution_id = $2 WHERE id = $1`, collectionID, executionID); err != nil {
+		return fmt.Errorf("failed to set baseline: %w", err)
+	}
+	return nil
+}
+
+// getAcks retrieves all acknowledgments keyed by collection ID
+func (s *Storage) getAcks(ctx context.Context) (map[int]*Acknowledgment, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT collection_id, acked_by, reason, acked_at FROM collection_acks`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query acknowledgments: %w", err)
+	}
+	defer rows.Close()
+
+	acks := make(map[int]*Acknowledgment)
+	for rows.Next() {
+		var ack Acknowledgment
+		if err := rows.Scan(&ack.CollectionID, &ack.User, &ack.Reason, &ack.AcknowledgedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan acknowledgment: %w", err)
+		}
+		acks[ack.CollectionID] = &ack
+	}
+	return acks, rows.Err()
+}
+
+// GetMaintenance retrieves the persisted maintenance state; disabled if never set
+func (s *Storage) GetMaintenance() (*MaintenanceState, error) {
+	var m MaintenanceState
+	err := s.db.QueryRow(`SELECT enabled, reason, until, updated_at FROM maintenance_mode WHERE id = 1`).Scan(
+		&m.Enabled, &m.Reason, &m.Until, &m.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &MaintenanceState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance state: %w", err)
+	}
+	return &m, nil
+}
+
+// SetMaintenance persists the maintenance state
+func (s *Storage) SetMaintenance(m *MaintenanceState) error {
+	query := `
+		INSERT INTO maintenance_mode (id, enabled, reason, until, updated_at)
+		VALUES (1, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			enabled = EXCLUDED.enabled, reason = EXCLUDED.reason,
+			until = EXCLUDED.until, updated_at = EXCLUDED.updated_at
+	`
+	if _, err := s.db.Exec(query, m.Enabled, m.Reason, m.Until, m.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to set maintenance state: %w", err)
+	}
+	return nil
+}
+
+// SelfCheck verifies the database accepts writes by writing a token and
+// reading it back
+func (s *Storage) SelfCheck() error {
+	token := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	_, err := s.db.Exec(`
+		INSERT INTO scout_self_check (id, token, checked_at)
+		VALUES (1, $1, $2)
+		ON CONFLICT (id) DO UPDATE SET token = EXCLUDED.token, checked_at = EXCLUDED.checked_at
+	`, token, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to write self-check row: %w", err)
+	}
+
+	var readBack string
+	if err := s.db.QueryRow(`SELECT token FROM scout_self_check WHERE id = 1`).Scan(&readBack); err != nil {
+		return fmt.Errorf("failed to read self-check row: %w", err)
+	}
+	if readBack != token {
+		return fmt.Errorf("self-check read back %q, expected %q", readBack, token)
+	}
+
+	return nil
+}
+
 // RunMigrations runs database migrations
 func (s *Storage) RunMigrations(migrationsPath string) error {
-	// Read and execute migration files
+	tx, err := s.beginUnbounded()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := migrate(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrate brings the schema up to date within tx
+func migrate(tx *sql.Tx) error {
 	upSQL := `
 -- Collections table
 CREATE TABLE IF NOT EXISTS collections (
@@ -406,6 +1380,10 @@ ALTER TABLE collections ADD COLUMN IF NOT EXISTS composite_key VARCHAR(512);
 ALTER TABLE collections ADD COLUMN IF NOT EXISTS directory_name VARCHAR(255);
 ALTER TABLE collections ADD COLUMN IF NOT EXISTS environment_name VARCHAR(255);
 ALTER TABLE collections ADD COLUMN IF NOT EXISTS collection_name VARCHAR(255);
+ALTER TABLE collections ADD COLUMN IF NOT EXISTS annotations JSONB;
+ALTER TABLE collections ADD COLUMN IF NOT EXISTS owners JSONB;
+-- Iteration data file of a collection run once per data file; siblings share file_path
+ALTER TABLE collections ADD COLUMN IF NOT EXISTS data_file TEXT;
 
 -- Add unique constraint on composite_key if it doesn't exist
 DO $$
@@ -442,6 +1420,31 @@ CREATE TABLE IF NOT EXISTS test_executions (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Add new columns to existing test_executions table
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS proxy_used BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS node_version VARCHAR(64);
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS newman_version VARCHAR(64);
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS overridden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS error_category VARCHAR(50);
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS trigger_source VARCHAR(20) NOT NULL DEFAULT 'scheduled';
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS result_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS truncated BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS order_position INTEGER;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS requests_total INTEGER;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS transferred_bytes BIGINT;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS warning BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS pass_threshold DOUBLE PRECISION;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS tested_version VARCHAR(128);
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS report_key TEXT;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS cancelled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS environment_layers JSONB;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS newman_args JSONB;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS replay_of INTEGER REFERENCES test_executions(id) ON DELETE SET NULL;
+ALTER TABLE test_executions ADD COLUMN IF NOT EXISTS replica TEXT;
+
+-- Known-good execution a collection's runs are compared against; cleared when retention deletes it
+ALTER TABLE collections ADD COLUMN IF NOT EXISTS baseline_execution_id INTEGER REFERENCES test_executions(id) ON DELETE SET NULL;
+
 CREATE INDEX IF NOT EXISTS idx_test_executions_collection_id ON test_executions(collection_id);
 CREATE INDEX IF NOT EXISTS idx_test_executions_started_at ON test_executions(started_at DESC);
 
@@ -461,16 +1464,109 @@ CREATE TABLE IF NOT EXISTS test_results (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- latest_test_results selects tr.*, so it is dropped before test_results
+-- changes and rebuilt below; CREATE OR REPLACE can't insert columns ahead
+-- of collection_id, and a dropped column would still be referenced
+DROP VIEW IF EXISTS latest_test_results;
+
+-- Add new columns to existing test_results table
+ALTER TABLE test_results ADD COLUMN IF NOT EXISTS sequence INTEGER;
+ALTER TABLE test_results ADD COLUMN IF NOT EXISTS detail JSONB;
+ALTER TABLE test_results ADD COLUMN IF NOT EXISTS redirects JSONB;
+ALTER TABLE test_results ADD COLUMN IF NOT EXISTS expected_failure BOOLEAN NOT NULL DEFAULT false;
+-- Snapshots and timings are stored once per request in request_results
+ALTER TABLE test_results DROP COLUMN IF EXISTS snapshot;
+ALTER TABLE test_results DROP COLUMN IF EXISTS timings;
+
 CREATE INDEX IF NOT EXISTS idx_test_results_execution_id ON test_results(execution_id);
 CREATE INDEX IF NOT EXISTS idx_test_results_test_name ON test_results(test_name);
 
+-- Captured response headers per test result
+CREATE TABLE IF NOT EXISTS test_result_headers (
+    id SERIAL PRIMARY KEY,
+    result_id INTEGER NOT NULL REFERENCES test_results(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    value TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_test_result_headers_result_id ON test_result_headers(result_id);
+
+-- Details of each request sent, recorded once per request whether or not it
+-- made any assertions
+CREATE TABLE IF NOT EXISTS request_results (
+    id SERIAL PRIMARY KEY,
+    execution_id INTEGER NOT NULL REFERENCES test_executions(id) ON DELETE CASCADE,
+    request_name TEXT NOT NULL,
+    sequence INTEGER NOT NULL,
+    snapshot JSONB
+);
+
+ALTER TABLE request_results ADD COLUMN IF NOT EXISTS timings JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_request_results_execution_id ON request_results(execution_id);
+
+-- Collection variables as resolved at the end of each execution, masked
+CREATE TABLE IF NOT EXISTS execution_variables (
+    id SERIAL PRIMARY KEY,
+    execution_id INTEGER NOT NULL REFERENCES test_executions(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    value TEXT NOT NULL,
+    overridden BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- Variables as the execution started, which a replay starts from
+ALTER TABLE execution_variables ADD COLUMN IF NOT EXISTS at_start BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_execution_variables_execution_id ON execution_variables(execution_id);
+
+-- Acknowledged failures; cleared when the collection next passes
+CREATE TABLE IF NOT EXISTS collection_acks (
+    collection_id INTEGER PRIMARY KEY REFERENCES collections(id) ON DELETE CASCADE,
+    acked_by VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    acked_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Open p95 latency alerts; cleared when the p95 is back under its threshold
+CREATE TABLE IF NOT EXISTS collection_latency_alerts (
+    collection_id INTEGER PRIMARY KEY REFERENCES collections(id) ON DELETE CASCADE,
+    p95_ms INTEGER NOT NULL,
+    threshold_ms INTEGER NOT NULL,
+    since TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Runtime settings set through the API, layered over scout.yaml
+CREATE TABLE IF NOT EXISTS collection_overrides (
+    collection_id INTEGER PRIMARY KEY REFERENCES collections(id) ON DELETE CASCADE,
+    interval_ms BIGINT,
+    paused BOOLEAN,
+    pass_threshold DOUBLE PRECISION,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Maintenance mode; a single row that survives restarts
+CREATE TABLE IF NOT EXISTS maintenance_mode (
+    id INTEGER PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT NOT NULL DEFAULT '',
+    until TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Startup self-check table; holds a single row rewritten on each start
+CREATE TABLE IF NOT EXISTS scout_self_check (
+    id INTEGER PRIMARY KEY,
+    token VARCHAR(64) NOT NULL,
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
 -- Latest results views
 CREATE OR REPLACE VIEW latest_test_executions AS
 SELECT DISTINCT ON (collection_id) *
 FROM test_executions
 ORDER BY collection_id, started_at DESC;
 
-CREATE OR REPLACE VIEW latest_test_results AS
+CREATE VIEW latest_test_results AS
 SELECT DISTINCT ON (tr.test_name, te.collection_id)
     tr.*,
     te.collection_id,
@@ -481,10 +1577,8 @@ JOIN test_executions te ON tr.execution_id = te.id
 ORDER BY tr.test_name, te.collection_id, te.started_at DESC;
 	`
 
-	_, err := s.db.Exec(upSQL)
-	if err != nil {
+	if _, err := tx.Exec(upSQL); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testStorage connects to the database named by SCOUT_TEST_DATABASE_URL and
+// migrates it, skipping the test when none is set. Tests must use their own
+// composite keys and clean up after themselves.
+func testStorage(t *testing.T) *Storage {
+	t.Helper()
+	url := os.Getenv("SCOUT_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("SCOUT_TEST_DATABASE_URL is not set")
+	}
+	s, err := NewStorage(url, 0)
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.RunMigrations(""); err != nil {
+		t.Fatalf("RunMigrations() error = %v", err)
+	}
+	return s
+}
+
+// deleteCollection removes a test collection and everything recorded for it
+func deleteCollection(t *testing.T, s *Storage, compositeKey string) {
+	t.Helper()
+	if _, err := s.db.Exec(`DELETE FROM collections WHERE composite_key = $1`, compositeKey); err != nil {
+		t.Errorf("deleting collection %s: %v", compositeKey, err)
+	}
+}
+
+func TestUpsertCollectionKeepsCreatedAt(t *testing.T) {
+	s := testStorage(t)
+	key := fmt.Sprintf("test_env_upsert_%d", time.Now().UnixNano())
+	t.Cleanup(func() { deleteCollection(t, s, key) })
+
+	first, err := s.UpsertCollection("upsert.postman_collection.json", "/collections/test/upsert.postman_collection.json", key, "test", "env", "upsert", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("UpsertCollection() error = %v", err)
+	}
+
+	// Postgres keeps microseconds, so a short wait is enough to tell the times apart
+	time.Sleep(10 * time.Millisecond)
+
+	// Re-discovery on the next scan, with the file moved
+	second, err := s.UpsertCollection("upsert.postman_collection.json", "/collections/moved/upsert.postman_collection.json", key, "test", "env", "upsert", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("UpsertCollection() error = %v", err)
+	}
+
+	if second.ID != first.ID {
+		t.Errorf("re-discovery created collection %d, want the existing %d", second.ID, first.ID)
+	}
+	if !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("created_at changed from %v to %v", first.CreatedAt, second.CreatedAt)
+	}
+	if !second.UpdatedAt.After(first.UpdatedAt) {
+		t.Errorf("updated_at = %v, want it after %v", second.UpdatedAt, first.UpdatedAt)
+	}
+	if second.FilePath != "/collections/moved/upsert.postman_collection.json" {
+		t.Errorf("file_path = %q, want the new path", second.FilePath)
+	}
+}
+
+func TestGetAllMatchesPerCollectionQueries(t *testing.T) {
+	s := testStorage(t)
+	suffix := time.Now().UnixNano()
+
+	// More runs than the window, runs whose error keeps them out of the
+	// trend, and no runs at all
+	fixtures := []struct {
+		name    string
+		runs    int
+		errored int
+	}{
+		{"steady", 5, 0},
+		{"erroring", 3, 1},
+		{"never_run", 0, 0},
+	}
+
+	var ids []int
+	for _, f := range fixtures {
+		name := f.name
+		key := fmt.Sprintf("test_env_%s_%d", name, suffix)
+		t.Cleanup(func() { deleteCollection(t, s, key) })
+		c, err := s.UpsertCollection(name+".postman_collection.json", "/collections/test/"+name+".postman_collection.json", key, "test", "env", name, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("UpsertCollection() error = %v", err)
+		}
+		ids = append(ids, c.ID)
+
+		for run := 0; run < f.runs; run++ {
+			exec := &TestExecution{
+				CollectionID:  c.ID,
+				StartedAt:     time.Now().Add(time.Duration(run-10) * time.Minute),
+				DurationMs:    100 * (run + 1),
+				TotalTests:    2,
+				PassedTests:   1 + run%2,
+				FailedTests:   1 - run%2,
+				TriggerSource: "scheduled",
+			}
+			if run < f.errored {
+				msg := "connection refused"
+				exec.Error = &msg
+			}
+			if err := s.CreateTestExecution(exec); err != nil {
+				t.Fatalf("CreateTestExecution() error = %v", err)
+			}
+			err := s.CreateTestResults([]TestResult{
+				{ExecutionID: exec.ID, TestName: "status is 200", Status: "passed", Passed: true},
+				{ExecutionID: exec.ID, TestName: "has items", Status: "failed", Passed: run%2 == 1},
+			})
+			if err != nil {
+				t.Fatalf("CreateTestResults() error = %v", err)
+			}
+		}
+	}
+
+	const window, factor = 3, 1.5
+	trends, err := s.GetAllDurationTrends(window, factor)
+	if err != nil {
+		t.Fatalf("GetAllDurationTrends() error = %v", err)
+	}
+	rates, err := s.GetAllAssertionPassRates(window)
+	if err != nil {
+		t.Fatalf("GetAllAssertionPassRates() error = %v", err)
+	}
+
+	for _, id := range ids {
+		wantTrend, err := s.GetDurationTrend(id, window, factor)
+		if err != nil {
+			t.Fatalf("GetDurationTrend(%d) error = %v", id, err)
+		}
+		if got := trends[id]; got == nil || len(got.Points) != len(wantTrend.Points) || got.LatestMs != wantTrend.LatestMs || got.RollingAverageMs != wantTrend.RollingAverageMs {
+			t.Errorf("collection %d: grouped trend = %+v, want %+v", id, got, wantTrend)
+		}
+
+		wantRates, err := s.GetAssertionPassRates(id, window)
+		if err != nil {
+			t.Fatalf("GetAssertionPassRates(%d) error = %v", id, err)
+		}
+		if len(rates[id]) != len(wantRates) {
+			t.Errorf("collection %d: grouped pass rates = %v, want %v", id, rates[id], wantRates)
+		}
+		for name, rate := range wantRates {
+			if rates[id][name] != rate {
+				t.Errorf("collection %d %q: grouped pass rate = %v, want %v", id, name, rates[id][name], rate)
+			}
+		}
+	}
+}
+
+func TestRunMigrationsAcrossColumnAddition(t *testing.T) {
+	s := testStorage(t)
+
+	// Put the database back to before test_results gained its newest
+	// column, with the view built over the narrower table
+	_, err := s.db.Exec(`
+DROP VIEW IF EXISTS latest_test_results;
+ALTER TABLE test_results DROP COLUMN IF EXISTS detail;
+CREATE VIEW latest_test_results AS
+SELECT DISTINCT ON (tr.test_name, te.collection_id)
+    tr.*,
+    te.collection_id,
+    te.collection_name,
+    te.started_at as execution_started_at
+FROM test_results tr
+JOIN test_executions te ON tr.execution_id = te.id
+ORDER BY tr.test_name, te.collection_id, te.started_at DESC;`)
+	if err != nil {
+		t.Fatalf("restoring the old schema: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := s.RunMigrations(""); err != nil {
+			t.Fatalf("RunMigrations() run %d error = %v", i+1, err)
+		}
+	}
+
+	var n int
+	err = s.db.QueryRow(`SELECT COUNT(*) FROM information_schema.columns WHERE table_name = 'latest_test_results' AND column_name = 'detail'`).Scan(&n)
+	if err != nil {
+		t.Fatalf("reading the view's columns: %v", err)
+	}
+	if n != 1 {
+		t.Error("latest_test_results lacks the added detail column")
+	}
+}
+
+func TestExportImportKeepsExecutionVariables(t *testing.T) {
+	s := testStorage(t)
+	key := fmt.Sprintf("test_env_variables_%d", time.Now().UnixNano())
+	t.Cleanup(func() { deleteCollection(t, s, key) })
+
+	c, err := s.UpsertCollection("variables.postman_collection.json", "/collections/test/variables.postman_collection.json", key, "test", "env", "variables", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("UpsertCollection() error = %v", err)
+	}
+	exec := &TestExecution{CollectionID: c.ID, StartedAt: time.Now(), TriggerSource: "scheduled"}
+	if err := s.CreateTestExecution(exec); err != nil {
+		t.Fatalf("CreateTestExecution() error = %v", err)
+	}
+	inputs := []CollectionVariable{{Name: "token", Value: "before"}, {Name: "baseUrl", Value: "http://api", Overridden: true}}
+	if err := s.CreateExecutionVariables(exec.ID, []CollectionVariable{{Name: "token", Value: "after"}}, inputs); err != nil {
+		t.Fatalf("CreateExecutionVariables() error = %v", err)
+	}
+
+	// Keep only this test's records, which import back into the same collection
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	err = s.Export(func(r ExportRecord) error {
+		switch {
+		case r.Type == RecordHeader,
+			r.Collection != nil && r.Collection.ID == c.ID,
+			r.Execution != nil && r.Execution.ID == exec.ID,
+			r.Variable != nil && r.Variable.ExecutionID == exec.ID:
+			return encoder.Encode(r)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	summary, err := s.Import(&buf)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if summary.Executions != 1 || summary.Variables != 3 {
+		t.Fatalf("Import() summary = %+v, want 1 execution and 3 variables", summary)
+	}
+
+	var imported int
+	if err := s.db.QueryRow(`SELECT MAX(id) FROM test_executions WHERE collection_id = $1`, c.ID).Scan(&imported); err != nil {
+		t.Fatalf("finding the imported execution: %v", err)
+	}
+	got, err := s.GetExecutionInputs(imported)
+	if err != nil {
+		t.Fatalf("GetExecutionInputs() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, inputs) {
+		t.Errorf("imported inputs = %+v, want %+v", got, inputs)
+	}
+}
+
+func TestRecomputeKeysDryRunLeavesSchemaAlone(t *testing.T) {
+	s := testStorage(t)
+	t.Cleanup(func() {
+		if err := s.RunMigrations(""); err != nil {
+			t.Errorf("restoring the schema: %v", err)
+		}
+	})
+
+	// Put the database back to before collections gained data_file
+	if _, err := s.db.Exec(`ALTER TABLE collections DROP COLUMN IF EXISTS data_file`); err != nil {
+		t.Fatalf("restoring the old schema: %v", err)
+	}
+
+	identity := func(c Collection) string { return c.CompositeKey }
+	if _, err := s.RecomputeKeys(identity, false); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("RecomputeKeys() dry run error = %v, want ErrSchemaBehind", err)
+	}
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM information_schema.columns WHERE table_name = 'collections' AND column_name = 'data_file'`).Scan(&n); err != nil {
+		t.Fatalf("reading the table's columns: %v", err)
+	}
+	if n != 0 {
+		t.Error("dry run migrated the schema")
+	}
+
+	if _, err := s.RecomputeKeys(identity, true); err != nil {
+		t.Fatalf("RecomputeKeys() apply error = %v", err)
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM information_schema.columns WHERE table_name = 'collections' AND column_name = 'data_file'`).Scan(&n); err != nil {
+		t.Fatalf("reading the table's columns: %v", err)
+	}
+	if n != 1 {
+		t.Error("applying didn't migrate the schema")
+	}
+}
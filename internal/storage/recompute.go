@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// ErrSchemaBehind is returned by a RecomputeKeys dry run when the database
+// schema predates this version of Scout, since a dry run doesn't migrate it
+var ErrSchemaBehind = errors.New("the database schema is older than this version of Scout; start Scout or rerun with -apply to migrate it")
+
+// Postgres error codes for a missing column and a missing table
+const (
+	undefinedColumnCode = "42703"
+	undefinedTableCode  = "42P01"
+)
+
+// KeyChange is a collection whose composite key changes during a rekey
+type KeyChange struct {
+	CollectionID int
+	OldKey       string
+	NewKey       string
+}
+
+// KeyMerge is a set of collections that share a composite key once it is
+// recomputed, as when a key collision merged two files' rows or a strategy
+// change left a stale row behind; they are merged into one
+type KeyMerge struct {
+	Key string
+	// KeptID is the collection that remains, the one already holding Key
+	// when there is one, so the scheduler keeps writing to it
+	KeptID int
+	// MergedIDs are the collections deleted once their executions move
+	MergedIDs []int
+	// Executions counts the executions moved to KeptID
+	Executions int
+}
+
+// KeyPlan is what RecomputeKeys changes: merges first, then key updates of
+// the remaining collections
+type KeyPlan struct {
+	Merges  []KeyMerge
+	Changes []KeyChange
+}
+
+// Empty reports whether the plan changes nothing
+func (p *KeyPlan) Empty() bool {
+	return len(p.Merges) == 0 && len(p.Changes) == 0
+}
+
+// RecomputeKeys recomputes every collection's composite key with rekey.
+// Collections ending up with the same key are merged into one, moving their
+// executions and keeping the first baseline set, and every other collection
+// whose key differs is rekeyed. When apply is true the schema is migrated
+// and the plan carried out in a single transaction; otherwise the plan is
+// only reported and nothing is written, failing with ErrSchemaBehind when
+// the schema needs migrating first. Either way it fails with ErrScoutRunning
+// while a Scout instance uses the database.
+// Running it again after applying finds nothing to change.
+func (s *Storage) RecomputeKeys(rekey func(Collection) string, apply bool) (*KeyPlan, error) {
+	tx, err := s.beginUnbounded()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockInstances(tx); err != nil {
+		return nil, err
+	}
+	if apply {
+		if err := migrate(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read under the lock, so no instance changes collections after the plan
+	collections, err := queryAllCollections(context.Background(), tx)
+	var pqErr *pq.Error
+	if !apply && errors.As(err, &pqErr) && (pqErr.Code == undefinedColumnCode || pqErr.Code == undefinedTableCode) {
+		return nil, ErrSchemaBehind
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string][]Collection)
+	var keys []string
+	for _, c := range collections {
+		key := rekey(c)
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], c)
+	}
+	sort.Strings(keys)
+
+	plan := &KeyPlan{}
+	for _, key := range keys {
+		group := byKey[key]
+		kept := keptCollection(group, key)
+		if len(group) > 1 {
+			merge := KeyMerge{Key: key, KeptID: kept.ID}
+			for _, c := range group {
+				if c.ID == kept.ID {
+					continue
+				}
+				merge.MergedIDs = append(merge.MergedIDs, c.ID)
+				var count int
+				if err := tx.QueryRow(`SELECT COUNT(*) FROM test_executions WHERE collection_id = $1`, c.ID).Scan(&count); err != nil {
+					return nil, fmt.Errorf("failed to count executions of collection %d: %w", c.ID, err)
+				}
+				merge.Executions += count
+			}
+			plan.Merges = append(plan.Merges, merge)
+		}
+		if kept.CompositeKey != key {
+			plan.Changes = append(plan.Changes, KeyChange{CollectionID: kept.ID, OldKey: kept.CompositeKey, NewKey: key})
+		}
+	}
+
+	if !apply {
+		return plan, nil
+	}
+
+	for _, merge := range plan.Merges {
+		for _, id := range merge.MergedIDs {
+			if _, err := tx.Exec(`UPDATE test_executions SET collection_id = $1 WHERE collection_id = $2`, merge.KeptID, id); err != nil {
+				return nil, fmt.Errorf("failed to move executions of collection %d: %w", id, err)
+			}
+			if _, err := tx.Exec(`
+				UPDATE collections SET baseline_execution_id = (SELECT baseline_execution_id FROM collections WHERE id = $2)
+				WHERE id = $1 AND baseline_execution_id IS NULL
+			`, merge.KeptID, id); err != nil {
+				return nil, fmt.Errorf("failed to move baseline of collection %d: %w", id, err)
+			}
+			// Acks, latency alerts and overrides of the merged collection go with it
+			if _, err := tx.Exec(`DELETE FROM collections WHERE id = $1`, id); err != nil {
+				return nil, fmt.Errorf("failed to delete merged collection %d: %w", id, err)
+			}
+		}
+	}
+
+	// Move changed keys out of the way first so swapped keys don't trip the unique constraint
+	for _, change := range plan.Changes {
+		if _, err := tx.Exec(`UPDATE collections SET composite_key = $1 WHERE id = $2`,
+			fmt.Sprintf("__rekey_%d", change.CollectionID), change.CollectionID); err != nil {
+			return nil, fmt.Errorf("failed to rekey collection %d: %w", change.CollectionID, err)
+		}
+	}
+	for _, change := range plan.Changes {
+		if _, err := tx.Exec(`UPDATE collections SET composite_key = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+			change.NewKey, change.CollectionID); err != nil {
+			return nil, fmt.Errorf("failed to rekey collection %d: %w", change.CollectionID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit recomputed keys: %w", err)
+	}
+	return plan, nil
+}
+
+// keptCollection picks the collection of group that survives a merge: the
+// one already holding key, else the most recently updated
+func keptCollection(group []Collection, key string) Collection {
+	kept := group[0]
+	for _, c := range group[1:] {
+		switch {
+		case kept.CompositeKey == key:
+		case c.CompositeKey == key, c.UpdatedAt.After(kept.UpdatedAt):
+			kept = c
+		}
+	}
+	return kept
+}
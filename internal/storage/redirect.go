@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Redirect is one redirect followed while sending a result's request
+type Redirect struct {
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+// Redirects is the chain of redirects before a request's final response,
+// stored as JSONB; requests that weren't redirected store NULL
+type Redirects []Redirect
+
+// Value implements driver.Valuer
+func (r Redirects) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan implements sql.Scanner
+func (r *Redirects) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("cannot scan %T into redirects", src)
+	}
+}
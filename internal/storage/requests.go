@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// RequestResult is one request sent during an execution. Details of the
+// response itself are recorded here once per request, whether or not the
+// request made any assertions.
+type RequestResult struct {
+	ID          int    `json:"id"`
+	ExecutionID int    `json:"execution_id"`
+	Name        string `json:"name"`
+	// Sequence is the request's position in the run, telling apart requests
+	// of the same name sent in different iterations
+	Sequence int `json:"sequence"`
+	// Snapshot is the response body snapshot, when the directory snapshots
+	// the request
+	Snapshot *ResponseSnapshot `json:"snapshot,omitempty"`
+	// Timings is the request's DNS, connect, TLS, first byte and download
+	// breakdown, when Newman measured it
+	Timings *RequestTimings `json:"timings,omitempty"`
+}
+
+// CreateRequestResults stores an execution's request results in a single transaction
+func (s *Storage) CreateRequestResults(requests []RequestResult) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range requests {
+		if err := insertRequestResult(tx, &requests[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit request results: %w", err)
+	}
+	return nil
+}
+
+// insertRequestResult inserts a request result
+func insertRequestResult(q execQuerier, request *RequestResult) error {
+	err := q.QueryRow(
+		`INSERT INTO request_results (execution_id, request_name, sequence, snapshot, timings) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		request.ExecutionID, request.Name, request.Sequence, request.Snapshot, request.Timings,
+	).Scan(&request.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create request result: %w", err)
+	}
+	return nil
+}
+
+// GetRequestResultsByExecutionIDContext returns an execution's request
+// results in the order the requests were sent
+func (s *Storage) GetRequestResultsByExecutionIDContext(ctx context.Context, executionID int) ([]RequestResult, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, execution_id, request_name, sequence, snapshot, timings
+		FROM request_results
+		WHERE execution_id = $1
+		ORDER BY sequence, id
+	`, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request results: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []RequestResult{}
+	for rows.Next() {
+		var r RequestResult
+		if err := rows.Scan(&r.ID, &r.ExecutionID, &r.Name, &r.Sequence, &r.Snapshot, &r.Timings); err != nil {
+			return nil, fmt.Errorf("failed to scan request result: %w", err)
+		}
+		requests = append(requests, r)
+	}
+	return requests, rows.Err()
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// PruneExecutions deletes a collection's executions started before cutoff,
+// with their results, always keeping its minKeep most recent executions. It
+// returns how many executions were deleted.
+func (s *Storage) PruneExecutions(collectionID int, cutoff time.Time, minKeep int) (int64, error) {
+	// Pruning a long history is a bulk delete, so it isn't bound by the
+	// statement timeout
+	tx, err := s.beginUnbounded()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		DELETE FROM test_executions
+		WHERE collection_id = $1 AND started_at < $2
+		AND id NOT IN (
+			SELECT id FROM test_executions
+			WHERE collection_id = $1
+			ORDER BY started_at DESC, id DESC
+			LIMIT $3
+		)
+	`, collectionID, cutoff, minKeep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune executions: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune executions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit pruned executions: %w", err)
+	}
+	return deleted, nil
+}
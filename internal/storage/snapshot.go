@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ResponseSnapshot is the hashed response body of a request flagged for
+// snapshotting, stored as JSONB with the request's result
+type ResponseSnapshot struct {
+	// Hash is the SHA-256 of the body with ignored fields removed
+	Hash string `json:"hash"`
+	// Body is the normalized body, when the directory stores bodies
+	Body *string `json:"body,omitempty"`
+	// Changed is set when the hash differs from the request's previous snapshot
+	Changed bool `json:"changed"`
+	// PreviousHash is the hash of the previous snapshot, if there was one
+	PreviousHash *string `json:"previous_hash,omitempty"`
+}
+
+// Value implements driver.Valuer
+func (s ResponseSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan implements sql.Scanner
+func (s *ResponseSnapshot) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into response snapshot", src)
+	}
+}
+
+// snapshotWindow is how many of a collection's recent executions are
+// searched for a request's previous snapshot
+const snapshotWindow = 50
+
+// GetPreviousSnapshots returns the latest snapshot hash of each request of a
+// collection, by request name, from its recent executions other than
+// executionID. Requests that weren't snapshotted in those executions are absent.
+func (s *Storage) GetPreviousSnapshots(collectionID, executionID int) (map[string]string, error) {
+	rows, err := s.db.Query(`
+		WITH recent AS (
+			SELECT id, started_at
+			FROM test_executions
+			WHERE collection_id = $1 AND id <> $2 AND NOT cancelled
+			ORDER BY started_at DESC, id DESC
+			LIMIT $3
+		)
+		SELECT DISTINCT ON (rr.request_name) rr.request_name, rr.snapshot->>'hash'
+		FROM request_results rr
+		JOIN recent r ON rr.execution_id = r.id
+		WHERE rr.snapshot IS NOT NULL
+		ORDER BY rr.request_name, r.started_at DESC, r.id DESC, rr.sequence DESC
+	`, collectionID, executionID, snapshotWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query previous snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var name, hash string
+		if err := rows.Scan(&name, &hash); err != nil {
+			return nil, fmt.Errorf("failed to scan previous snapshot: %w", err)
+		}
+		hashes[name] = hash
+	}
+	return hashes, rows.Err()
+}
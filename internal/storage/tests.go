@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TestSeen is a distinct test name seen in a collection's recent executions
+type TestSeen struct {
+	Name       string    `json:"name"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	Executions int       `json:"executions"`
+	// Missing is set when the test ran earlier in the window but not in the
+	// latest execution, often a deleted assertion
+	Missing bool `json:"missing"`
+}
+
+// CollectionTests lists the distinct tests of a collection's last Window
+// executions. Overridden, cancelled and errored executions are left out, so
+// a run that couldn't reach its requests doesn't make every test missing.
+type CollectionTests struct {
+	CollectionID      int        `json:"collection_id"`
+	Window            int        `json:"window"`
+	LatestExecutionID *int       `json:"latest_execution_id"`
+	Tests             []TestSeen `json:"tests"`
+	Missing           int        `json:"missing"`
+}
+
+// GetCollectionTests lists the distinct test names of a collection's last
+// window executions with when each was first and last seen
+func (s *Storage) GetCollectionTests(collectionID, window int) (*CollectionTests, error) {
+	return s.GetCollectionTestsContext(context.Background(), collectionID, window)
+}
+
+// GetCollectionTestsContext is GetCollectionTests bounded by ctx
+func (s *Storage) GetCollectionTestsContext(ctx context.Context, collectionID, window int) (*CollectionTests, error) {
+	tests := &CollectionTests{CollectionID: collectionID, Window: window, Tests: []TestSeen{}}
+
+	var latestID int
+	var latestTruncated bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, truncated
+		FROM test_executions
+		WHERE collection_id = $1
+		  AND NOT overridden
+		  AND NOT cancelled
+		  AND error IS NULL
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`, collectionID).Scan(&latestID, &latestTruncated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tests, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest execution: %w", err)
+	}
+	tests.LatestExecutionID = &latestID
+
+	// Synthetic rows recorded by Scout rather than the collection are skipped
+	rows, err := s.db.QueryContext(ctx, `
+		WITH recent AS (
+			SELECT id, started_at
+			FROM test_executions
+			WHERE collection_id = $1
+			  AND NOT overridden
+			  AND NOT cancelled
+			  AND error IS NULL
+			ORDER BY started_at DESC, id DESC
+			LIMIT $2
+		)
+		SELECT tr.test_name, MIN(r.started_at), MAX(r.started_at), COUNT(DISTINCT r.id),
+		       BOOL_OR(r.id = $3)
+		FROM test_results tr
+		JOIN recent r ON tr.execution_id = r.id
+		WHERE tr.status <> $4 AND tr.test_name <> $5
+		GROUP BY tr.test_name
+		ORDER BY tr.test_name
+	`, collectionID, window, latestID, ResultStatusTruncated, GraphQLTestName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection tests: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t TestSeen
+		var inLatest bool
+		if err := rows.Scan(&t.Name, &t.FirstSeen, &t.LastSeen, &t.Executions, &inLatest); err != nil {
+			return nil, fmt.Errorf("failed to scan collection test: %w", err)
+		}
+		// A truncated latest execution didn't store every test, so absence
+		// from it proves nothing
+		t.Missing = !inLatest && !latestTruncated
+		if t.Missing {
+			tests.Missing++
+		}
+		tests.Tests = append(tests.Tests, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tests, nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// queryCanceledCode is the Postgres error code for statements cancelled by
+// statement_timeout or by a client cancel request
+const queryCanceledCode = "57014"
+
+// timeoutConnector sets statement_timeout on every new connection, so no
+// single statement can hold a pooled connection indefinitely
+type timeoutConnector struct {
+	driver.Connector
+	timeout time.Duration
+}
+
+// Connect implements driver.Connector
+func (c timeoutConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, errors.New("driver connection does not support statement timeouts")
+	}
+	statement := fmt.Sprintf("SET statement_timeout = %d", c.timeout.Milliseconds())
+	if _, err := execer.ExecContext(ctx, statement, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+	return conn, nil
+}
+
+// openDB opens a connection pool, applying statementTimeout to each
+// connection when it is positive
+func openDB(connectionString string, statementTimeout time.Duration) (*sql.DB, error) {
+	connector, err := pq.NewConnector(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	if statementTimeout <= 0 {
+		return sql.OpenDB(connector), nil
+	}
+	return sql.OpenDB(timeoutConnector{Connector: connector, timeout: statementTimeout}), nil
+}
+
+// beginUnbounded starts a transaction exempt from the statement timeout, for
+// bulk operations (migrations, export, import) expected to run long
+func (s *Storage) beginUnbounded() (*sql.Tx, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err := tx.Exec(`SET LOCAL statement_timeout = 0`); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to lift statement timeout: %w", err)
+	}
+	return tx, nil
+}
+
+// IsTimeout reports whether err came from a query stopped by the statement
+// timeout or by its context's deadline
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	// A client cancel shares the code; only the message tells them apart
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == queryCanceledCode &&
+		strings.Contains(pqErr.Message, "statement timeout")
+}
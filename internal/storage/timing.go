@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// RequestTimings breaks a request's response time down into the phases
+// Newman measures, stored as JSONB with each of the request's results. A
+// phase is nil when it didn't happen, such as DNS, connect and TLS on a
+// reused connection, or TLS over plain HTTP.
+type RequestTimings struct {
+	DNSMs       *float64 `json:"dns_ms,omitempty"`
+	ConnectMs   *float64 `json:"connect_ms,omitempty"`
+	TLSMs       *float64 `json:"tls_ms,omitempty"`
+	FirstByteMs *float64 `json:"first_byte_ms,omitempty"`
+	DownloadMs  *float64 `json:"download_ms,omitempty"`
+}
+
+// Value implements driver.Valuer
+func (t RequestTimings) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// Scan implements sql.Scanner
+func (t *RequestTimings) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("cannot scan %T into request timings", src)
+	}
+}
@@ -0,0 +1,78 @@
+package storage
+
+import "time"
+
+// DurationPoint is one execution's duration in a trend
+type DurationPoint struct {
+	ExecutionID int       `json:"execution_id"`
+	StartedAt   time.Time `json:"started_at"`
+	DurationMs  int       `json:"duration_ms"`
+}
+
+// DurationTrend summarizes how a collection's run duration is changing.
+// The latest run is a regression when it exceeds the average of the
+// preceding runs by more than Factor.
+type DurationTrend struct {
+	CollectionID     int             `json:"collection_id"`
+	Window           int             `json:"window"`
+	Points           []DurationPoint `json:"points"`
+	SlopeMsPerRun    float64         `json:"slope_ms_per_run"`
+	RollingAverageMs float64         `json:"rolling_average_ms"`
+	LatestMs         int             `json:"latest_ms"`
+	Factor           float64         `json:"factor"`
+	Regression       bool            `json:"regression"`
+}
+
+// newDurationTrend computes the slope and regression flag for points ordered oldest first
+func newDurationTrend(collectionID, window int, factor float64, points []DurationPoint) *DurationTrend {
+	trend := &DurationTrend{
+		CollectionID: collectionID,
+		Window:       window,
+		Points:       points,
+		Factor:       factor,
+	}
+	if len(points) == 0 {
+		trend.Points = []DurationPoint{}
+		return trend
+	}
+
+	latest := points[len(points)-1]
+	trend.LatestMs = latest.DurationMs
+	trend.SlopeMsPerRun = slope(points)
+
+	// The baseline excludes the latest run so a single slow run stands out
+	if baseline := points[:len(points)-1]; len(baseline) > 0 {
+		total := 0
+		for _, p := range baseline {
+			total += p.DurationMs
+		}
+		trend.RollingAverageMs = float64(total) / float64(len(baseline))
+		trend.Regression = factor > 0 && trend.RollingAverageMs > 0 &&
+			float64(latest.DurationMs) > trend.RollingAverageMs*factor
+	}
+
+	return trend
+}
+
+// slope fits a least-squares line to duration by run index
+func slope(points []DurationPoint) float64 {
+	n := float64(len(points))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, p := range points {
+		x, y := float64(i), float64(p.DurationMs)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
@@ -0,0 +1,16 @@
+package version
+
+import "runtime"
+
+// Build information, injected at build time via -ldflags, e.g.
+// -X github.com/josepht96/scout/internal/version.Version=v1.0.1
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// GoVersion returns the Go version the binary was built with
+func GoVersion() string {
+	return runtime.Version()
+}
@@ -0,0 +1,259 @@
+package watcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxArchiveBytes bounds the total size extracted from a collections archive,
+// so a corrupt or malicious archive can't fill the disk
+const maxArchiveBytes = 512 << 20
+
+// IsArchive reports whether path names a collections archive (.zip, .tar.gz or .tgz)
+func IsArchive(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".zip") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// collectionArchive extracts a collections archive to a stable path,
+// re-extracting when the archive on disk is replaced. The stable path is a
+// symlink to the latest extraction, so a scan always sees a whole extraction.
+type collectionArchive struct {
+	path     string
+	root     string // Temporary directory holding every extraction
+	dir      string // Symlink to the latest extraction, scanned like a collections directory
+	mu       sync.Mutex
+	current  string // Extraction dir links to
+	previous string // Extraction replaced last, kept for scans and runs still reading it
+	modTime  time.Time
+	size     int64
+	hash     string
+}
+
+// NewArchiveWatcher creates a watcher for collections packaged in a .zip or
+// .tar.gz archive. The archive root is treated like COLLECTIONS_DIR: its
+// subdirectories are collection groups. It is extracted to a temporary
+// directory and re-extracted whenever its contents change. Close removes
+// the extracted files.
+func NewArchiveWatcher(archivePath string) (*CollectionWatcher, error) {
+	root, err := os.MkdirTemp("", "scout-archive-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+
+	archive := &collectionArchive{
+		path: archivePath,
+		root: root,
+		dir:  filepath.Join(root, "collections"),
+	}
+	if err := archive.refresh(); err != nil {
+		os.RemoveAll(root)
+		return nil, err
+	}
+
+	return &CollectionWatcher{
+		directory: archive.dir,
+		archive:   archive,
+	}, nil
+}
+
+// refresh re-extracts the archive if it changed since the last extraction.
+// Modification time and size are checked first; the hash decides, so touching
+// the archive without changing it doesn't re-extract.
+func (a *collectionArchive) refresh() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat collections archive: %w", err)
+	}
+	if info.ModTime().Equal(a.modTime) && info.Size() == a.size {
+		return nil
+	}
+
+	hash, err := hashFile(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to read collections archive: %w", err)
+	}
+	if hash == a.hash {
+		a.modTime, a.size = info.ModTime(), info.Size()
+		return nil
+	}
+
+	// Extract to a new directory, then swap the link over to it in a single
+	// rename; if anything fails the link still points at the last extraction
+	extraction, err := os.MkdirTemp(a.root, "extraction-")
+	if err != nil {
+		return fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+	if err := extractArchive(a.path, extraction); err != nil {
+		os.RemoveAll(extraction)
+		return fmt.Errorf("failed to extract %s: %w", a.path, err)
+	}
+	if err := a.link(extraction); err != nil {
+		os.RemoveAll(extraction)
+		return fmt.Errorf("failed to replace extracted collections: %w", err)
+	}
+
+	// Keep the extraction just replaced for readers that resolved the link
+	// before the swap, and remove the one before it
+	if a.previous != "" {
+		os.RemoveAll(a.previous)
+	}
+	a.previous, a.current = a.current, extraction
+
+	if a.hash == "" {
+		log.Printf("Extracted collections archive %s to %s", a.path, a.dir)
+	} else {
+		log.Printf("Collections archive %s changed, re-extracted", a.path)
+	}
+	a.modTime, a.size, a.hash = info.ModTime(), info.Size(), hash
+	return nil
+}
+
+// link atomically points dir at extraction, by renaming a new symlink over it
+func (a *collectionArchive) link(extraction string) error {
+	next := a.dir + ".next"
+	os.Remove(next)
+	if err := os.Symlink(filepath.Base(extraction), next); err != nil {
+		return err
+	}
+	if err := os.Rename(next, a.dir); err != nil {
+		os.Remove(next)
+		return err
+	}
+	return nil
+}
+
+// close removes every extraction
+func (a *collectionArchive) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return os.RemoveAll(a.root)
+}
+
+// hashFile returns the hex SHA-256 of a file's contents
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// extractArchive extracts a .zip or .tar.gz archive into dir
+func extractArchive(archivePath, dir string) error {
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		return extractZip(archivePath, dir)
+	}
+	return extractTarGz(archivePath, dir)
+}
+
+// extractZip extracts the regular files of a zip archive into dir
+func extractZip(archivePath, dir string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var remaining int64 = maxArchiveBytes
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		written, err := writeArchiveFile(dir, file.Name, rc, remaining)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		remaining -= written
+	}
+	return nil
+}
+
+// extractTarGz extracts the regular files of a gzipped tar archive into dir
+func extractTarGz(archivePath, dir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	var remaining int64 = maxArchiveBytes
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		written, err := writeArchiveFile(dir, header.Name, reader, remaining)
+		if err != nil {
+			return err
+		}
+		remaining -= written
+	}
+}
+
+// writeArchiveFile writes one archive entry below dir, rejecting names that
+// would escape it and entries that exceed the remaining size budget
+func writeArchiveFile(dir, name string, r io.Reader, remaining int64) (int64, error) {
+	clean := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return 0, fmt.Errorf("archive entry %q is outside the archive root", name)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(clean))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	written, err := io.Copy(out, io.LimitReader(r, remaining+1))
+	if err != nil {
+		return written, err
+	}
+	if written > remaining {
+		return written, fmt.Errorf("archive exceeds %d bytes when extracted", maxArchiveBytes)
+	}
+	return written, nil
+}
@@ -0,0 +1,122 @@
+package watcher
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeZip writes a zip archive holding each named file with its contents
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, contents := range files {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteArchiveFileRejectsEscapingEntries(t *testing.T) {
+	names := []string{
+		"../escape.json",
+		"..",
+		"/etc/escape.json",
+		"group/../../escape.json",
+		`..\escape.json`,
+		`group\..\..\escape.json`,
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "extraction")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := writeArchiveFile(dir, name, strings.NewReader("{}"), maxArchiveBytes)
+			if err == nil || !strings.Contains(err.Error(), "outside the archive root") {
+				t.Fatalf("writeArchiveFile(%q) error = %v, want outside the archive root", name, err)
+			}
+			if _, err := os.Stat(filepath.Join(parent, "escape.json")); !os.IsNotExist(err) {
+				t.Errorf("writeArchiveFile(%q) wrote outside the extraction directory", name)
+			}
+		})
+	}
+}
+
+func TestWriteArchiveFileSizeCap(t *testing.T) {
+	dir := t.TempDir()
+
+	written, err := writeArchiveFile(dir, "group/fits.json", strings.NewReader("12345"), 5)
+	if err != nil || written != 5 {
+		t.Fatalf("writeArchiveFile() at the cap = %d, %v, want 5, nil", written, err)
+	}
+
+	_, err = writeArchiveFile(dir, "group/over.json", strings.NewReader("123456"), 5)
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("writeArchiveFile() over the cap error = %v, want exceeds", err)
+	}
+}
+
+func TestArchiveRefreshSwapsExtraction(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "collections.zip")
+	writeZip(t, archivePath, map[string]string{"team/smoke.postman_collection.json": "v1"})
+
+	w, err := NewArchiveWatcher(archivePath)
+	if err != nil {
+		t.Fatalf("NewArchiveWatcher() error = %v", err)
+	}
+	root := w.archive.root
+	collection := filepath.Join(w.directory, "team", "smoke.postman_collection.json")
+
+	// A reader that resolved the link before the swap keeps its files
+	before, err := filepath.EvalSymlinks(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeZip(t, archivePath, map[string]string{"team/smoke.postman_collection.json": "v2, a longer file"})
+	if err := w.archive.refresh(); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	if got, _ := os.ReadFile(collection); string(got) != "v2, a longer file" {
+		t.Errorf("collection after refresh = %q, want the new contents", got)
+	}
+	if got, _ := os.ReadFile(before); string(got) != "v1" {
+		t.Errorf("previous extraction = %q, want it kept until the next swap", got)
+	}
+
+	// A broken archive leaves the last extraction in place
+	if err := os.WriteFile(archivePath, []byte("not a zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.archive.refresh(); err == nil {
+		t.Fatal("refresh() of a broken archive succeeded")
+	}
+	if got, _ := os.ReadFile(collection); string(got) != "v2, a longer file" {
+		t.Errorf("collection after a failed refresh = %q, want the last extraction", got)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("Close() left %s behind", root)
+	}
+}
@@ -4,14 +4,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/josepht96/scout/internal/secrets"
 )
 
 // CollectionWatcher watches a directory for Postman collection files
 type CollectionWatcher struct {
 	directory string
+	sources   SourceResolver
+	archive   *collectionArchive
+}
+
+// SourceResolver maps a directory's remote source to a locally cached
+// collection file. It must not block on the network.
+type SourceResolver interface {
+	Resolve(directory string, source RemoteSource) (path string, ok bool)
 }
 
 // NewCollectionWatcher creates a new collection watcher
@@ -21,30 +32,122 @@ func NewCollectionWatcher(directory string) *CollectionWatcher {
 	}
 }
 
+// Close releases the watcher's resources, removing the files extracted from
+// a collections archive
+func (w *CollectionWatcher) Close() error {
+	if w.archive == nil {
+		return nil
+	}
+	return w.archive.close()
+}
+
+// SetSourceResolver enables remote collection sources declared in scout.yaml
+func (w *CollectionWatcher) SetSourceResolver(resolver SourceResolver) {
+	w.sources = resolver
+}
+
 // CollectionFile represents a discovered collection file
 type CollectionFile struct {
 	Name     string
 	Path     string
 	FullPath string
+	// DataFile is the iteration data file this copy of the collection runs
+	// with, and Variant names it within the collection; see expandDataFiles
+	DataFile string
+	Variant  string
+	// Disabled collections are listed but never run; see isDisabledFile
+	Disabled bool
 }
 
 // EnvironmentFile represents a discovered Postman environment file
 type EnvironmentFile struct {
-	Name     string // Environment name from JSON
-	FileName string // Actual filename
-	Path     string
-	FullPath string
+	Name      string // Environment name from JSON, or the file name when encrypted
+	FileName  string // Actual filename
+	Path      string
+	FullPath  string
+	Encrypted bool // Decrypted by the executor before each run
+}
+
+// environmentSuffix ends the name of every environment file, before any .enc
+const environmentSuffix = ".postman_environment.json"
+
+// EnvironmentBaseName returns an environment file's name without its
+// .postman_environment.json and .enc suffixes
+func EnvironmentBaseName(fileName string) string {
+	name := fileName
+	if strings.HasSuffix(strings.ToLower(name), secrets.EncryptedExtension) {
+		name = name[:len(name)-len(secrets.EncryptedExtension)]
+	}
+	return strings.TrimSuffix(name, environmentSuffix)
+}
+
+// SharedEnvironmentName is the base name of a directory's shared environment
+// file, whose variables are merged beneath each of its other environments
+// rather than run as an environment of its own
+const SharedEnvironmentName = "shared"
+
+// isEnvironmentFile reports whether a file name is a plain or encrypted environment file
+func isEnvironmentFile(fileName string) bool {
+	lower := strings.ToLower(fileName)
+	return strings.HasSuffix(lower, environmentSuffix) || strings.HasSuffix(lower, environmentSuffix+secrets.EncryptedExtension)
+}
+
+// environmentNames returns the lower-cased base names of a directory's
+// environment files that run as environments, leaving out the shared one
+func environmentNames(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subdirectory: %w", err)
+	}
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		if !isEnvironmentFile(entry.Name()) || isVolumeInternal(entry.Name()) {
+			continue
+		}
+		name := strings.ToLower(EnvironmentBaseName(entry.Name()))
+		if name != SharedEnvironmentName {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// DefaultDirectoryName is the group name for collections placed directly in
+// the collections directory rather than in a subdirectory
+const DefaultDirectoryName = "default"
+
+// collectionSuffix ends the name of a collection file exported from Postman
+const collectionSuffix = ".postman_collection.json"
+
+// isRootCollectionFile reports whether a file in the root of the collections
+// directory is a collection. Unlike subdirectories, which treat any other
+// .json file as a collection, the root only picks up exported collections,
+// since it may hold Scout's own config file and other JSON.
+func isRootCollectionFile(fileName string) bool {
+	return strings.HasSuffix(strings.ToLower(EnabledFileName(fileName)), collectionSuffix)
 }
 
 // CollectionGroup represents a group of collections with an optional environment
 type CollectionGroup struct {
-	Directory    string
-	Environment  *EnvironmentFile
-	Collections  []CollectionFile
+	Directory   string // Normalized directory name used in composite keys
+	DisplayName string // Directory name as it appears on disk
+	Environment *EnvironmentFile
+	// SharedEnvironment is the directory's shared.postman_environment.json,
+	// layered beneath Environment before execution
+	SharedEnvironment *EnvironmentFile
+	Collections       []CollectionFile
+	Config            DirectoryConfig
 }
 
 // ScanGroups scans subdirectories for collections and environment files, grouping them
 func (w *CollectionWatcher) ScanGroups() ([]CollectionGroup, error) {
+	// Pick up a replaced archive; on failure keep scanning the last good extraction
+	if w.archive != nil {
+		if err := w.archive.refresh(); err != nil {
+			log.Printf("Warning: %v; using the previously extracted collections", err)
+		}
+	}
+
 	// Check if directory exists
 	if _, err := os.Stat(w.directory); os.IsNotExist(err) {
 		return nil, fmt.Errorf("directory does not exist: %s", w.directory)
@@ -57,22 +160,41 @@ func (w *CollectionWatcher) ScanGroups() ([]CollectionGroup, error) {
 	}
 
 	var groups []CollectionGroup
+	hasRootFiles := false
+	hasDefaultDir := false
 
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue // Skip files in root directory
+		if isVolumeInternal(entry.Name()) {
+			continue // Kubernetes volume internals; the files are read through ..data
+		}
+		if !isDirEntry(w.directory, entry) {
+			if isRootCollectionFile(entry.Name()) {
+				hasRootFiles = true
+			}
+			continue // Root files are scanned below as the default group
+		}
+		if strings.EqualFold(entry.Name(), DefaultDirectoryName) {
+			hasDefaultDir = true
 		}
 
-		// Validate directory name does not contain spaces
-		if strings.Contains(entry.Name(), " ") {
-			log.Printf("Error: Collection directory name contains spaces: '%s'. Directory names must not contain spaces. Skipping this directory.", entry.Name())
-			continue
+		// Directory names with spaces are normalized for composite keys
+		dirName := NormalizeDirectoryName(entry.Name())
+		if dirName != entry.Name() {
+			log.Printf("Warning: Collection directory name contains spaces: '%s'. Using normalized name '%s' for composite keys.", entry.Name(), dirName)
 		}
 
 		subdir := filepath.Join(w.directory, entry.Name())
 
-		// Scan this subdirectory
-		subdirGroups, err := w.scanSubdirectory(subdir, entry.Name())
+		// Scan this subdirectory, with its scout.yaml from the same volume update
+		var subdirGroups []CollectionGroup
+		err := scanVolume(subdir, func(dataDir string) error {
+			config, err := loadDirectoryConfig(dataDir)
+			if err != nil {
+				return err
+			}
+			subdirGroups, err = w.scanSubdirectory(subdir, dataDir, dirName, entry.Name(), config)
+			return err
+		})
 		if err != nil {
 			// Log error but continue with other directories
 			fmt.Printf("Warning: failed to scan subdirectory %s: %v\n", subdir, err)
@@ -82,31 +204,65 @@ func (w *CollectionWatcher) ScanGroups() ([]CollectionGroup, error) {
 		groups = append(groups, subdirGroups...)
 	}
 
+	// Files directly in the root form an implicit default group, without a
+	// scout.yaml since the root may hold Scout's own config file
+	if hasRootFiles {
+		if hasDefaultDir {
+			log.Printf("Warning: ignoring collections in the root of %s: a %q subdirectory already exists", w.directory, DefaultDirectoryName)
+		} else {
+			var rootGroups []CollectionGroup
+			err := scanVolume(w.directory, func(dataDir string) error {
+				var err error
+				rootGroups, err = w.scanSubdirectory(w.directory, dataDir, DefaultDirectoryName, DefaultDirectoryName, DirectoryConfig{})
+				return err
+			})
+			if err != nil {
+				fmt.Printf("Warning: failed to scan %s: %v\n", w.directory, err)
+			} else if len(rootGroups) > 0 {
+				log.Printf("Found %d collection(s) in the root of %s, grouping them under %q",
+					len(rootGroups[0].Collections), w.directory, DefaultDirectoryName)
+				groups = append(groups, rootGroups...)
+			}
+		}
+	}
+
 	return groups, nil
 }
 
-// scanSubdirectory scans a single subdirectory and creates groups
-func (w *CollectionWatcher) scanSubdirectory(subdirPath, subdirName string) ([]CollectionGroup, error) {
+// scanSubdirectory scans a single subdirectory and creates groups. Files are
+// listed and read from dataDir, which differs from subdirPath when the
+// subdirectory is a Kubernetes volume, but their paths stay under subdirPath
+// so executions follow the volume's updates.
+func (w *CollectionWatcher) scanSubdirectory(subdirPath, dataDir, subdirName, displayName string, config DirectoryConfig) ([]CollectionGroup, error) {
 	// Find all .json files in this subdirectory
-	entries, err := os.ReadDir(subdirPath)
+	entries, err := os.ReadDir(dataDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read subdirectory: %w", err)
 	}
 
 	var environmentFiles []EnvironmentFile
+	var sharedEnvironment *EnvironmentFile
 	var collectionFiles []CollectionFile
+	var dataFiles []dataFile
 
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if isVolumeInternal(entry.Name()) || isDirEntry(dataDir, entry) {
 			continue // Don't recurse into subdirectories
 		}
 
 		filename := entry.Name()
-		if !strings.HasSuffix(strings.ToLower(filename), ".json") {
+		if !strings.HasSuffix(strings.ToLower(filename), ".json") && !isEnvironmentFile(filename) {
 			continue
 		}
 
 		filePath := filepath.Join(subdirPath, filename)
+		if dataDir != subdirPath {
+			// The kubelet links new files into the volume just after the
+			// swap; until then they're picked up by the next scan
+			if _, err := os.Lstat(filePath); err != nil {
+				continue
+			}
+		}
 		absPath, err := filepath.Abs(filePath)
 		if err != nil {
 			continue
@@ -118,23 +274,59 @@ func (w *CollectionWatcher) scanSubdirectory(subdirPath, subdirName string) ([]C
 		}
 
 		// Check if this is an environment file
-		if strings.Contains(strings.ToLower(filename), ".postman_environment.json") {
-			envFile, err := w.parseEnvironmentFile(absPath, filename, relPath)
+		if isEnvironmentFile(filename) {
+			envFile, err := w.parseEnvironmentFile(filepath.Join(dataDir, filename), absPath, filename, relPath)
 			if err != nil {
 				fmt.Printf("Warning: failed to parse environment file %s: %v\n", filename, err)
 				continue
 			}
+			if strings.EqualFold(EnvironmentBaseName(filename), SharedEnvironmentName) {
+				sharedEnvironment = envFile
+				continue
+			}
 			environmentFiles = append(environmentFiles, *envFile)
+		} else if isDataFile(filename) {
+			dataFiles = append(dataFiles, dataFile{name: filename, fullPath: absPath})
+		} else if subdirPath == w.directory && !isRootCollectionFile(filename) {
+			continue // Other JSON in the root isn't a collection
 		} else {
 			// It's a collection file
 			collectionFiles = append(collectionFiles, CollectionFile{
 				Name:     filename,
 				Path:     relPath,
 				FullPath: absPath,
+				Disabled: isDisabledFile(filename),
 			})
 		}
 	}
 
+	// Add remote sources that have a cached copy available
+	for _, source := range config.Sources {
+		if w.sources == nil {
+			log.Printf("Warning: ignoring remote source %s in %s: remote sources are not enabled", source.Name, subdirPath)
+			continue
+		}
+		path, ok := w.sources.Resolve(subdirName, source)
+		if !ok {
+			continue // Not fetched yet
+		}
+		collectionFiles = append(collectionFiles, CollectionFile{
+			Name:     source.Name + ".postman_collection.json",
+			Path:     source.URL,
+			FullPath: path,
+		})
+	}
+
+	collectionFiles = dropShadowedDisabled(collectionFiles, subdirPath)
+	collectionFiles = expandDataFiles(collectionFiles, dataFiles, subdirPath)
+
+	if len(config.Environments) > 0 {
+		environmentFiles = selectEnvironments(environmentFiles, config.Environments, subdirPath)
+		if len(environmentFiles) == 0 {
+			return nil, nil
+		}
+	}
+
 	// Create groups based on environment files
 	var groups []CollectionGroup
 
@@ -142,9 +334,12 @@ func (w *CollectionWatcher) scanSubdirectory(subdirPath, subdirName string) ([]C
 		// Create a group for each environment file
 		for _, envFile := range environmentFiles {
 			group := CollectionGroup{
-				Directory:   subdirName,
-				Environment: &envFile,
-				Collections: collectionFiles,
+				Directory:         subdirName,
+				DisplayName:       displayName,
+				Environment:       &envFile,
+				SharedEnvironment: sharedEnvironment,
+				Collections:       collectionFiles,
+				Config:            config,
 			}
 			groups = append(groups, group)
 		}
@@ -152,9 +347,12 @@ func (w *CollectionWatcher) scanSubdirectory(subdirPath, subdirName string) ([]C
 		// No environment file - create an ungrouped group
 		if len(collectionFiles) > 0 {
 			group := CollectionGroup{
-				Directory:   subdirName,
-				Environment: nil,
-				Collections: collectionFiles,
+				Directory:         subdirName,
+				DisplayName:       displayName,
+				Environment:       nil,
+				SharedEnvironment: sharedEnvironment,
+				Collections:       collectionFiles,
+				Config:            config,
 			}
 			groups = append(groups, group)
 		}
@@ -163,13 +361,63 @@ func (w *CollectionWatcher) scanSubdirectory(subdirPath, subdirName string) ([]C
 	return groups, nil
 }
 
-// parseEnvironmentFile parses a Postman environment file to extract the name
-func (w *CollectionWatcher) parseEnvironmentFile(fullPath, filename, relPath string) (*EnvironmentFile, error) {
-	data, err := os.ReadFile(fullPath)
+// selectEnvironments keeps the environment files named in selected, matched
+// case-insensitively by file name without .postman_environment.json, and warns
+// about selected names that match no file, as when it failed to parse
+func selectEnvironments(files []EnvironmentFile, selected []string, dir string) []EnvironmentFile {
+	byName := make(map[string]EnvironmentFile, len(files))
+	for _, file := range files {
+		byName[strings.ToLower(EnvironmentBaseName(file.FileName))] = file
+	}
+
+	var kept []EnvironmentFile
+	keep := make(map[string]bool, len(selected))
+	for _, name := range selected {
+		file, ok := byName[strings.ToLower(name)]
+		if !ok {
+			log.Printf("Warning: environment %s listed in %s has no environment file in %s", name, DirectoryConfigFileName, dir)
+			continue
+		}
+		kept = append(kept, file)
+		keep[file.FileName] = true
+	}
+
+	for _, file := range files {
+		if !keep[file.FileName] {
+			log.Printf("Skipping environment file %s in %s: not listed in environments", file.FileName, dir)
+		}
+	}
+	return kept
+}
+
+// NormalizeDirectoryName escapes a directory name for use in composite keys.
+// Percent-encoding keeps "Payments Team" distinct from a "Payments_Team" sibling.
+func NormalizeDirectoryName(name string) string {
+	if !strings.ContainsAny(name, " %") {
+		return name
+	}
+	return url.PathEscape(name)
+}
+
+// parseEnvironmentFile parses the Postman environment file at readPath, known
+// as fullPath, to extract the name. Encrypted files are named after the file,
+// since they are only decrypted for execution.
+func (w *CollectionWatcher) parseEnvironmentFile(readPath, fullPath, filename, relPath string) (*EnvironmentFile, error) {
+	data, err := os.ReadFile(readPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	if secrets.IsEncrypted(filename, data) {
+		return &EnvironmentFile{
+			Name:      EnvironmentBaseName(filename),
+			FileName:  filename,
+			Path:      relPath,
+			FullPath:  fullPath,
+			Encrypted: true,
+		}, nil
+	}
+
 	var envData struct {
 		Name string `json:"name"`
 	}
@@ -179,7 +427,7 @@ func (w *CollectionWatcher) parseEnvironmentFile(fullPath, filename, relPath str
 	}
 
 	if envData.Name == "" {
-		envData.Name = strings.TrimSuffix(filename, ".postman_environment.json")
+		envData.Name = EnvironmentBaseName(filename)
 	}
 
 	return &EnvironmentFile{
@@ -0,0 +1,150 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// writeFiles creates each file, and its directory, under root
+func writeFiles(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// collectionNames lists a group's collection file names, sorted
+func collectionNames(group CollectionGroup) []string {
+	var names []string
+	for _, col := range group.Collections {
+		names = append(names, col.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestScanGroupsRootCollections(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"smoke.postman_collection.json",
+		"_legacy.postman_collection.json",
+		// Other JSON in the root is not a collection
+		"scout.json",
+		"package.json",
+		"shop/orders.postman_collection.json",
+	)
+
+	groups, err := NewCollectionWatcher(root).ScanGroups()
+	if err != nil {
+		t.Fatalf("ScanGroups() error = %v", err)
+	}
+
+	byDirectory := make(map[string]CollectionGroup)
+	for _, group := range groups {
+		byDirectory[group.Directory] = group
+	}
+	if len(byDirectory) != 2 {
+		t.Fatalf("got groups %v, want default and shop", byDirectory)
+	}
+
+	got := collectionNames(byDirectory[DefaultDirectoryName])
+	want := []string{"_legacy.postman_collection.json", "smoke.postman_collection.json"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("default group collections = %q, want %q", got, want)
+	}
+	if got := collectionNames(byDirectory["shop"]); len(got) != 1 || got[0] != "orders.postman_collection.json" {
+		t.Errorf("shop collections = %q, want only orders", got)
+	}
+}
+
+func TestScanGroupsRootWithoutCollections(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "scout.json", "shop/orders.postman_collection.json")
+
+	groups, err := NewCollectionWatcher(root).ScanGroups()
+	if err != nil {
+		t.Fatalf("ScanGroups() error = %v", err)
+	}
+	if len(groups) != 1 || groups[0].Directory != "shop" {
+		t.Errorf("got groups %v, want only shop", groups)
+	}
+}
+
+func TestScanGroupsRootIgnoredBesideDefaultDirectory(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "smoke.postman_collection.json", "default/orders.postman_collection.json")
+
+	groups, err := NewCollectionWatcher(root).ScanGroups()
+	if err != nil {
+		t.Fatalf("ScanGroups() error = %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want only the default subdirectory", len(groups))
+	}
+	if got := collectionNames(groups[0]); len(got) != 1 || got[0] != "orders.postman_collection.json" {
+		t.Errorf("default group collections = %q, want only the subdirectory's orders", got)
+	}
+}
+
+func TestNormalizeDirectoryName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"payments", "payments"},
+		{"Payments_Team", "Payments_Team"},
+		{"Payments Team", "Payments%20Team"},
+		// An escaped-looking name is escaped too, so it can't collide with a spaced one
+		{"Payments%20Team", "Payments%2520Team"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeDirectoryName(tt.name); got != tt.want {
+			t.Errorf("NormalizeDirectoryName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScanGroupsDirectoryWithSpaces(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"Payments Team/smoke.postman_collection.json",
+		"Payments_Team/smoke.postman_collection.json",
+	)
+
+	// Directories must be found, and keyed the same way, on every scan
+	var scans [][]CollectionGroup
+	for i := 0; i < 2; i++ {
+		groups, err := NewCollectionWatcher(root).ScanGroups()
+		if err != nil {
+			t.Fatalf("ScanGroups() error = %v", err)
+		}
+		scans = append(scans, groups)
+	}
+
+	for _, groups := range scans {
+		displayNames := make(map[string]string)
+		for _, group := range groups {
+			if len(group.Collections) != 1 {
+				t.Errorf("%q has %d collections, want 1", group.DisplayName, len(group.Collections))
+			}
+			displayNames[group.Directory] = group.DisplayName
+		}
+		want := map[string]string{
+			"Payments%20Team": "Payments Team",
+			"Payments_Team":   "Payments_Team",
+		}
+		if len(displayNames) != len(want) {
+			t.Fatalf("got directories %v, want %v", displayNames, want)
+		}
+		for directory, displayName := range want {
+			if displayNames[directory] != displayName {
+				t.Errorf("directory %q displays as %q, want %q", directory, displayNames[directory], displayName)
+			}
+		}
+	}
+}
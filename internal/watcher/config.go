@@ -0,0 +1,381 @@
+package watcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DirectoryConfigFileName is the name of the optional per-directory config file
+const DirectoryConfigFileName = "scout.yaml"
+
+// ProxyConfig holds proxy settings for collections in a directory
+type ProxyConfig struct {
+	HTTP    string `yaml:"http"`
+	HTTPS   string `yaml:"https"`
+	NoProxy string `yaml:"no_proxy"`
+}
+
+// AuthConfig is collection-level auth injected into every collection in a
+// directory at run time, replacing any auth saved in the collection. Token
+// and Password may be secret references such as {{env:API_TOKEN}}.
+type AuthConfig struct {
+	Type     string `yaml:"type"` // bearer or basic
+	Token    string `yaml:"token"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// RemoteSource is a collection fetched over HTTP(S) instead of read from disk
+type RemoteSource struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// OwnerRule assigns tests whose name matches a glob pattern to an owner,
+// optionally notified through their own Slack or webhook URL
+type OwnerRule struct {
+	Pattern         string `yaml:"pattern"`
+	Owner           string `yaml:"owner"`
+	SlackWebhookURL string `yaml:"slack_webhook_url"`
+	WebhookURL      string `yaml:"webhook_url"`
+}
+
+// SnapshotConfig flags requests, by name glob, whose response bodies are
+// hashed each run so a change in content is detected. Ignore lists JSON
+// paths of intentionally dynamic fields, such as $.generatedAt or
+// $.items[*].updatedAt, left out of the hash.
+type SnapshotConfig struct {
+	Requests  []string `yaml:"requests"`
+	Ignore    []string `yaml:"ignore"`
+	StoreBody bool     `yaml:"store_body"`
+}
+
+// snapshotPathPattern matches the JSON paths the Newman script can ignore:
+// $ followed by .key, .*, [index] or [*] segments
+var snapshotPathPattern = regexp.MustCompile(`^\$(\.[^.\[\]]+|\[(\d+|\*)\])+$`)
+
+// validate checks that snapshots name requests and ignore valid JSON paths
+func (c SnapshotConfig) validate() error {
+	if len(c.Requests) == 0 {
+		return fmt.Errorf("requests must list at least one request name")
+	}
+	for _, request := range c.Requests {
+		if request == "" {
+			return fmt.Errorf("requests entries must be non-empty")
+		}
+	}
+	for _, path := range c.Ignore {
+		if !snapshotPathPattern.MatchString(path) {
+			return fmt.Errorf("ignore path %q must look like $.key, $.items[*].key or $.items[0]", path)
+		}
+	}
+	return nil
+}
+
+// Priority is a collection's tier, deciding the order collections are
+// submitted to the worker pool each cycle
+type Priority string
+
+// Priority tiers, highest first
+const (
+	PriorityCritical Priority = "critical"
+	PriorityHigh     Priority = "high"
+	PriorityNormal   Priority = "normal"
+	PriorityLow      Priority = "low"
+)
+
+// Rank orders priorities; higher ranks run first and unset means normal
+func (p Priority) Rank() int {
+	switch p {
+	case PriorityCritical:
+		return 3
+	case PriorityHigh:
+		return 2
+	case PriorityLow:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// validate checks that a priority is one of the known tiers or unset
+func (p Priority) validate() error {
+	switch p {
+	case "", PriorityCritical, PriorityHigh, PriorityNormal, PriorityLow:
+		return nil
+	default:
+		return fmt.Errorf("unknown priority %q (expected critical, high, normal or low)", p)
+	}
+}
+
+// DirectoryConfig holds optional settings loaded from a directory's scout.yaml
+type DirectoryConfig struct {
+	Proxy          *ProxyConfig   `yaml:"proxy"`
+	Auth           *AuthConfig    `yaml:"auth"`
+	Sequential     bool           `yaml:"sequential"`
+	CaptureHeaders []string       `yaml:"capture_headers"`
+	Sources        []RemoteSource `yaml:"sources"`
+	// Order lists collection file names that run one at a time, in this
+	// order, before the directory's remaining collections
+	Order []string `yaml:"order"`
+	// OrderBail skips the remaining ordered collections after one fails
+	OrderBail bool `yaml:"order_bail"`
+	// Annotations are free-form key/value pairs, such as a runbook link or
+	// owning team, stored with each collection and included in notifications
+	Annotations map[string]string `yaml:"annotations"`
+	// WarnResponseTimeMs marks a run whose tests all pass as a warning when
+	// any request took longer than this; 0 disables
+	WarnResponseTimeMs int `yaml:"warn_response_time_ms"`
+	// P95ThresholdMs alerts once when the p95 response time over the last
+	// P95Window runs goes over it, and again once it's back under; 0
+	// disables. P95Window defaults to 20 runs.
+	P95ThresholdMs int `yaml:"p95_threshold_ms"`
+	P95Window      int `yaml:"p95_window"`
+	// Priority is the tier of the directory's collections; Priorities
+	// overrides it for individual collection file names
+	Priority   Priority            `yaml:"priority"`
+	Priorities map[string]Priority `yaml:"priorities"`
+	// Environments restricts which of the directory's environment files are
+	// run, by file name without .postman_environment.json; empty runs them all
+	Environments []string `yaml:"environments"`
+	// Interval runs the directory's collections less often than the global
+	// interval; Paused stops scheduling them; PassThreshold is the percentage
+	// of tests that must pass for a run to pass. The API can override each.
+	Interval      time.Duration `yaml:"interval"`
+	Paused        bool          `yaml:"paused"`
+	PassThreshold *float64      `yaml:"pass_threshold"`
+	// Owners route failing tests to the teams owning them, first match wins;
+	// unmatched tests go to the collection's owner annotation
+	Owners []OwnerRule `yaml:"owners"`
+	// Shared holds variables merged beneath every environment in the
+	// directory, below the shared environment file
+	Shared map[string]string `yaml:"shared"`
+	// HostGroup names the downstream host the directory's collections call;
+	// collections sharing a host group are limited to a combined concurrency
+	// across all directories. HostGroups overrides it for individual
+	// collection file names.
+	HostGroup  string            `yaml:"host_group"`
+	HostGroups map[string]string `yaml:"host_groups"`
+	// GraphQLPaths are URL paths, such as /graphql, of GraphQL endpoints the
+	// collections call. Their responses fail when they carry errors, as do
+	// responses of requests detected as GraphQL by body or content type.
+	GraphQLPaths []string `yaml:"graphql_paths"`
+	// Retention overrides the global execution history retention for the
+	// directory's collections (0 = use the global retention)
+	Retention time.Duration `yaml:"retention"`
+	// ExpectedFailures are glob patterns of test names expected to fail, such
+	// as negative tests of rejected requests; they pass when they fail
+	ExpectedFailures []string `yaml:"expected_failures"`
+	// Snapshots flags requests whose response body changes are detected
+	Snapshots *SnapshotConfig `yaml:"snapshots"`
+	// NewmanArgs are extra Newman run flags, such as ["--timeout-request",
+	// "5000"], limited to an allowlist; see validateNewmanArgs
+	NewmanArgs []string `yaml:"newman_args"`
+}
+
+// PriorityOf returns the priority of the named collection file
+func (c DirectoryConfig) PriorityOf(name string) Priority {
+	if p, ok := c.Priorities[name]; ok {
+		return p
+	}
+	return c.Priority
+}
+
+// HostGroupOf returns the host group of the named collection file, or "" if
+// it has none
+func (c DirectoryConfig) HostGroupOf(name string) string {
+	if g, ok := c.HostGroups[name]; ok {
+		return g
+	}
+	return c.HostGroup
+}
+
+// loadDirectoryConfig reads scout.yaml from a subdirectory if present.
+// A missing file yields an empty config; unknown keys, and environments
+// with no environment file, are rejected to catch typos.
+func loadDirectoryConfig(subdirPath string) (DirectoryConfig, error) {
+	var config DirectoryConfig
+
+	data, err := os.ReadFile(filepath.Join(subdirPath, DirectoryConfigFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, fmt.Errorf("failed to read %s: %w", DirectoryConfigFileName, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return config, fmt.Errorf("failed to parse %s: %w", DirectoryConfigFileName, err)
+	}
+
+	for _, source := range config.Sources {
+		if err := source.validate(); err != nil {
+			return config, fmt.Errorf("invalid source in %s: %w", DirectoryConfigFileName, err)
+		}
+	}
+
+	if config.Snapshots != nil {
+		if err := config.Snapshots.validate(); err != nil {
+			return config, fmt.Errorf("invalid snapshots in %s: %w", DirectoryConfigFileName, err)
+		}
+	}
+
+	if config.Auth != nil {
+		if err := config.Auth.validate(); err != nil {
+			return config, fmt.Errorf("invalid auth in %s: %w", DirectoryConfigFileName, err)
+		}
+	}
+
+	for i, rule := range config.Owners {
+		if err := rule.validate(); err != nil {
+			return config, fmt.Errorf("invalid owners entry %d in %s: %w", i+1, DirectoryConfigFileName, err)
+		}
+	}
+
+	if config.Interval < 0 {
+		return config, fmt.Errorf("invalid interval in %s: must not be negative", DirectoryConfigFileName)
+	}
+	if config.PassThreshold != nil && (*config.PassThreshold < 0 || *config.PassThreshold > 100) {
+		return config, fmt.Errorf("invalid pass_threshold in %s: must be between 0 and 100", DirectoryConfigFileName)
+	}
+	if config.WarnResponseTimeMs < 0 {
+		return config, fmt.Errorf("invalid warn_response_time_ms in %s: must not be negative", DirectoryConfigFileName)
+	}
+	if config.P95ThresholdMs < 0 {
+		return config, fmt.Errorf("invalid p95_threshold_ms in %s: must not be negative", DirectoryConfigFileName)
+	}
+	if config.P95Window < 0 {
+		return config, fmt.Errorf("invalid p95_window in %s: must not be negative", DirectoryConfigFileName)
+	}
+	for _, path := range config.GraphQLPaths {
+		if !strings.HasPrefix(path, "/") {
+			return config, fmt.Errorf("invalid graphql_paths in %s: %q must start with /", DirectoryConfigFileName, path)
+		}
+	}
+	if config.Retention < 0 {
+		return config, fmt.Errorf("invalid retention in %s: must not be negative", DirectoryConfigFileName)
+	}
+	if err := validateNewmanArgs(config.NewmanArgs); err != nil {
+		return config, fmt.Errorf("invalid newman_args in %s: %w", DirectoryConfigFileName, err)
+	}
+	for _, pattern := range config.ExpectedFailures {
+		if pattern == "" {
+			return config, fmt.Errorf("invalid expected_failures in %s: entries must be non-empty", DirectoryConfigFileName)
+		}
+	}
+
+	if err := config.Priority.validate(); err != nil {
+		return config, fmt.Errorf("invalid priority in %s: %w", DirectoryConfigFileName, err)
+	}
+	for name, priority := range config.Priorities {
+		if err := priority.validate(); err != nil {
+			return config, fmt.Errorf("invalid priority for %s in %s: %w", name, DirectoryConfigFileName, err)
+		}
+	}
+
+	for name, group := range config.HostGroups {
+		if strings.TrimSpace(group) == "" {
+			return config, fmt.Errorf("invalid host group for %s in %s: must be non-empty", name, DirectoryConfigFileName)
+		}
+	}
+
+	for key := range config.Shared {
+		if strings.TrimSpace(key) == "" {
+			return config, fmt.Errorf("invalid shared in %s: variable names must be non-empty", DirectoryConfigFileName)
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range config.Environments {
+		if name == "" {
+			return config, fmt.Errorf("invalid environments in %s: entries must be non-empty", DirectoryConfigFileName)
+		}
+		if selected[strings.ToLower(name)] {
+			return config, fmt.Errorf("invalid environments in %s: %q is listed more than once", DirectoryConfigFileName, name)
+		}
+		selected[strings.ToLower(name)] = true
+	}
+	if len(config.Environments) > 0 {
+		available, err := environmentNames(subdirPath)
+		if err != nil {
+			return config, err
+		}
+		for _, name := range config.Environments {
+			if !available[strings.ToLower(name)] {
+				return config, fmt.Errorf("invalid environments in %s: %q has no environment file in the directory", DirectoryConfigFileName, name)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range config.Order {
+		if name == "" {
+			return config, fmt.Errorf("invalid order in %s: entries must be non-empty", DirectoryConfigFileName)
+		}
+		if seen[name] {
+			return config, fmt.Errorf("invalid order in %s: %q is listed more than once", DirectoryConfigFileName, name)
+		}
+		seen[name] = true
+	}
+
+	return config, nil
+}
+
+// validate checks that an owner rule has a pattern, an owner and HTTP(S)
+// destination URLs
+func (r OwnerRule) validate() error {
+	if r.Pattern == "" || r.Owner == "" {
+		return fmt.Errorf("pattern and owner must be non-empty")
+	}
+	for _, raw := range []string{r.SlackWebhookURL, r.WebhookURL} {
+		if raw == "" {
+			continue
+		}
+		parsed, err := url.Parse(raw)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("owner %q: webhook URLs must be absolute http or https URLs", r.Owner)
+		}
+	}
+	return nil
+}
+
+// validate checks that auth has a supported type and the credentials it needs
+func (a AuthConfig) validate() error {
+	switch a.Type {
+	case "bearer":
+		if a.Token == "" {
+			return fmt.Errorf("bearer auth requires a token")
+		}
+	case "basic":
+		if a.Username == "" || a.Password == "" {
+			return fmt.Errorf("basic auth requires a username and password")
+		}
+	default:
+		return fmt.Errorf("type must be bearer or basic, got %q", a.Type)
+	}
+	return nil
+}
+
+// validate checks that a remote source has a file-safe name and an HTTP(S) URL
+func (s RemoteSource) validate() error {
+	if s.Name == "" || strings.ContainsAny(s.Name, `/\`) || s.Name == "." || s.Name == ".." {
+		return fmt.Errorf("name %q must be non-empty and must not contain path separators", s.Name)
+	}
+	parsed, err := url.Parse(s.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("source %q: url must be an absolute http or https URL", s.Name)
+	}
+	return nil
+}
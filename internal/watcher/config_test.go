@@ -0,0 +1,46 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDirectoryConfigEnvironments(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "listed files", yaml: "environments: [prod, Staging]\n"},
+		{name: "unknown environment", yaml: "environments: [prod, qa]\n", wantErr: `"qa" has no environment file`},
+		{name: "shared file", yaml: "environments: [shared]\n", wantErr: `"shared" has no environment file`},
+		{name: "listed twice", yaml: "environments: [prod, PROD]\n", wantErr: "listed more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir,
+				"prod.postman_environment.json",
+				"staging.postman_environment.json",
+				"shared.postman_environment.json",
+			)
+			if err := os.WriteFile(filepath.Join(dir, DirectoryConfigFileName), []byte(tt.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := loadDirectoryConfig(dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("loadDirectoryConfig() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("loadDirectoryConfig() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
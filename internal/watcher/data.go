@@ -0,0 +1,107 @@
+package watcher
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dataFileSuffix ends the name of every iteration data file
+const dataFileSuffix = ".data.json"
+
+// isDataFile reports whether filename is an iteration data file
+func isDataFile(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), dataFileSuffix)
+}
+
+// collectionBaseName returns a collection file's name without its
+// .postman_collection.json, or plain .json, extension and any marker
+// disabling it
+func collectionBaseName(fileName string) string {
+	fileName = EnabledFileName(fileName)
+	if name, ok := strings.CutSuffix(fileName, ".postman_collection.json"); ok {
+		return name
+	}
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+}
+
+// DataVariant returns the variant a data file runs its collection as: empty
+// for {collection}.data.json, and the part between the collection name and
+// .data.json for {collection}.{variant}.data.json. An empty dataPath has no
+// variant.
+func DataVariant(collectionPath, dataPath string) string {
+	if dataPath == "" {
+		return ""
+	}
+	stem := dataStem(filepath.Base(dataPath))
+	base := collectionBaseName(filepath.Base(collectionPath))
+	if strings.EqualFold(stem, base) {
+		return ""
+	}
+	if len(stem) > len(base) && strings.EqualFold(stem[:len(base)+1], base+".") {
+		return stem[len(base)+1:]
+	}
+	return stem
+}
+
+// dataStem returns a data file's name without its .data.json suffix
+func dataStem(fileName string) string {
+	if isDataFile(fileName) {
+		return fileName[:len(fileName)-len(dataFileSuffix)]
+	}
+	return fileName
+}
+
+// dataFile is an iteration data file found next to collections
+type dataFile struct {
+	name     string
+	fullPath string
+}
+
+// expandDataFiles replaces each collection that has data files with one copy
+// per data file, so the collection runs once per data file. A data file
+// belongs to the collection with the longest name it starts with, compared
+// case-insensitively; data files matching no collection are warned about.
+func expandDataFiles(collections []CollectionFile, dataFiles []dataFile, dir string) []CollectionFile {
+	if len(dataFiles) == 0 {
+		return collections
+	}
+
+	byCollection := make(map[int][]dataFile)
+	for _, data := range dataFiles {
+		stem := strings.ToLower(dataStem(data.name))
+		match := -1
+		for i, col := range collections {
+			base := strings.ToLower(collectionBaseName(col.Name))
+			if stem != base && !strings.HasPrefix(stem, base+".") {
+				continue
+			}
+			if match < 0 || len(base) > len(collectionBaseName(collections[match].Name)) {
+				match = i
+			}
+		}
+		if match < 0 {
+			log.Printf("Warning: data file %s in %s matches no collection", data.name, dir)
+			continue
+		}
+		byCollection[match] = append(byCollection[match], data)
+	}
+
+	expanded := make([]CollectionFile, 0, len(collections)+len(dataFiles))
+	for i, col := range collections {
+		files := byCollection[i]
+		if len(files) == 0 {
+			expanded = append(expanded, col)
+			continue
+		}
+		sort.Slice(files, func(a, b int) bool { return files[a].name < files[b].name })
+		for _, data := range files {
+			variant := col
+			variant.DataFile = data.fullPath
+			variant.Variant = DataVariant(col.FullPath, data.fullPath)
+			expanded = append(expanded, variant)
+		}
+	}
+	return expanded
+}
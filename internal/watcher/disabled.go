@@ -0,0 +1,51 @@
+package watcher
+
+import (
+	"log"
+	"strings"
+)
+
+const (
+	// disabledPrefix starts the name of a collection file that isn't run
+	disabledPrefix = "_"
+	// disabledSuffix ends the name of a collection file that isn't run
+	disabledSuffix = ".disabled.json"
+)
+
+// isDisabledFile reports whether a collection file is named to be disabled:
+// _orders.postman_collection.json or orders.postman_collection.disabled.json
+func isDisabledFile(fileName string) bool {
+	return strings.HasPrefix(fileName, disabledPrefix) || strings.HasSuffix(strings.ToLower(fileName), disabledSuffix)
+}
+
+// EnabledFileName returns a collection file's name without the prefix or
+// suffix disabling it, so renaming a collection to disable it keeps its
+// composite key and history
+func EnabledFileName(fileName string) string {
+	name := strings.TrimPrefix(fileName, disabledPrefix)
+	if strings.HasSuffix(strings.ToLower(name), disabledSuffix) {
+		name = name[:len(name)-len(disabledSuffix)] + ".json"
+	}
+	return name
+}
+
+// dropShadowedDisabled leaves out disabled collection files whose enabled
+// name is also on disk, since both would have the same composite key
+func dropShadowedDisabled(collections []CollectionFile, dir string) []CollectionFile {
+	enabled := make(map[string]bool, len(collections))
+	for _, col := range collections {
+		if !col.Disabled {
+			enabled[col.Name] = true
+		}
+	}
+
+	kept := collections[:0]
+	for _, col := range collections {
+		if col.Disabled && enabled[EnabledFileName(col.Name)] {
+			log.Printf("Warning: ignoring disabled collection %s in %s: %s is also present", col.Name, dir, EnabledFileName(col.Name))
+			continue
+		}
+		kept = append(kept, col)
+	}
+	return kept
+}
@@ -0,0 +1,77 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsDisabledFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		disabled    bool
+		enabledName string
+	}{
+		{"orders.postman_collection.json", false, "orders.postman_collection.json"},
+		{"_orders.postman_collection.json", true, "orders.postman_collection.json"},
+		{"orders.postman_collection.disabled.json", true, "orders.postman_collection.json"},
+		{"orders.postman_collection.DISABLED.json", true, "orders.postman_collection.json"},
+		{"my_orders.postman_collection.json", false, "my_orders.postman_collection.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDisabledFile(tt.name); got != tt.disabled {
+				t.Errorf("isDisabledFile(%q) = %v, want %v", tt.name, got, tt.disabled)
+			}
+			if got := EnabledFileName(tt.name); got != tt.enabledName {
+				t.Errorf("EnabledFileName(%q) = %q, want %q", tt.name, got, tt.enabledName)
+			}
+		})
+	}
+}
+
+func TestScanGroupsListsDisabledCollections(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "shop")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		"orders.postman_collection.json",
+		"_legacy.postman_collection.json",
+		"cart.postman_collection.disabled.json",
+		// Shadowed by the enabled orders collection, which has the same key
+		"_orders.postman_collection.json",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(`{}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := NewCollectionWatcher(root).ScanGroups()
+	if err != nil {
+		t.Fatalf("ScanGroups() error = %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+
+	got := make(map[string]bool)
+	for _, col := range groups[0].Collections {
+		got[col.Name] = col.Disabled
+	}
+	want := map[string]bool{
+		"orders.postman_collection.json":        false,
+		"_legacy.postman_collection.json":       true,
+		"cart.postman_collection.disabled.json": true,
+	}
+	if len(got) != len(want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+	for name, disabled := range want {
+		if listed, ok := got[name]; !ok || listed != disabled {
+			t.Errorf("%s: listed %v (disabled %v), want disabled %v", name, ok, listed, disabled)
+		}
+	}
+}
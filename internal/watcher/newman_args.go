@@ -0,0 +1,78 @@
+package watcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// newmanArgValue describes the value a passthrough Newman flag takes
+type newmanArgValue int
+
+const (
+	argNoValue newmanArgValue = iota
+	argNumber
+	argColor
+	argName
+)
+
+// newmanArgs allowlists the Newman run flags scout.yaml may pass through.
+// Flags that read or write files, or that Scout sets itself (environment,
+// reporters, data files, request delay), are deliberately left out.
+var newmanArgs = map[string]newmanArgValue{
+	"--bail":             argNoValue,
+	"--color":            argColor,
+	"--disable-unicode":  argNoValue,
+	"--folder":           argName,
+	"--ignore-redirects": argNoValue,
+	"--insecure":         argNoValue,
+	"-k":                 argNoValue,
+	"--iteration-count":  argNumber,
+	"-n":                 argNumber,
+	"--timeout":          argNumber,
+	"--timeout-request":  argNumber,
+	"--timeout-script":   argNumber,
+	"--verbose":          argNoValue,
+}
+
+// unsafeArgChars are shell metacharacters rejected in any passthrough argument
+const unsafeArgChars = "`$;&|<>(){}[]\\!*?~'\"\n\r\t"
+
+// validateNewmanArgs checks that args are allowlisted flags, each followed by
+// a value when it takes one, free of shell metacharacters and path traversal
+func validateNewmanArgs(args []string) error {
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		kind, ok := newmanArgs[flag]
+		if !ok {
+			return fmt.Errorf("%q is not a supported Newman flag", flag)
+		}
+		if kind == argNoValue {
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return fmt.Errorf("%s requires a value", flag)
+		}
+		i++
+		value := args[i]
+		if strings.ContainsAny(value, unsafeArgChars) || strings.Contains(value, "..") {
+			return fmt.Errorf("value %q of %s contains shell metacharacters or path traversal", value, flag)
+		}
+		switch kind {
+		case argNumber:
+			if n, err := strconv.Atoi(value); err != nil || n < 0 {
+				return fmt.Errorf("%s requires a non-negative integer, got %q", flag, value)
+			}
+		case argColor:
+			if value != "on" && value != "off" && value != "auto" {
+				return fmt.Errorf("%s must be on, off or auto, got %q", flag, value)
+			}
+		case argName:
+			if strings.TrimSpace(value) == "" || strings.HasPrefix(value, "-") {
+				return fmt.Errorf("%s requires a name, got %q", flag, value)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package watcher
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// volumeDataLink is the symlink through which a Kubernetes ConfigMap or
+// Secret volume exposes its current files. The kubelet updates a volume by
+// writing the new files to a fresh ..-prefixed timestamped directory and
+// atomically swapping this link to it; the visible files are symlinks
+// through it.
+const volumeDataLink = "..data"
+
+// maxVolumeScans bounds how often a directory is scanned when its volume
+// keeps being updated mid-scan
+const maxVolumeScans = 3
+
+// volumeUpdateWait is how long to let the kubelet finish updating a volume
+// before scanning it again
+const volumeUpdateWait = 100 * time.Millisecond
+
+// isVolumeInternal reports whether name is one of a Kubernetes volume's
+// internal entries, which are never collections or groups
+func isVolumeInternal(name string) bool {
+	return strings.HasPrefix(name, "..")
+}
+
+// volumeData resolves a directory's ..data link to the directory holding its
+// current files, reporting false when dir isn't a Kubernetes volume
+func volumeData(dir string) (string, bool) {
+	data, err := filepath.EvalSymlinks(filepath.Join(dir, volumeDataLink))
+	if err != nil {
+		return "", false
+	}
+	return data, true
+}
+
+// volumeLinked reports whether every file in a volume's data directory has
+// its visible link in dir. The kubelet adds the links of new files just after
+// swapping ..data, so a missing link means an update is still in progress.
+func volumeLinked(dir, data string) bool {
+	entries, err := os.ReadDir(data)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if isVolumeInternal(entry.Name()) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(dir, entry.Name())); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// scanVolume calls scan with the directory dir's files are read from. For a
+// Kubernetes volume that is its current data directory, and the scan is
+// repeated if the volume was mid-update or is swapped before it returns, so
+// one scan never mixes the files of two updates; a volume that never settles
+// is an error rather than a partial scan. Other directories are scanned as
+// they are.
+func scanVolume(dir string, scan func(dataDir string) error) error {
+	for attempt := 1; ; attempt++ {
+		data, ok := volumeData(dir)
+		if !ok {
+			return scan(dir)
+		}
+		settled := volumeLinked(dir, data)
+		err := scan(data)
+		if current, _ := volumeData(dir); settled && current == data {
+			return err
+		}
+		if attempt == maxVolumeScans {
+			return fmt.Errorf("volume kept changing over %d scans", maxVolumeScans)
+		}
+		log.Printf("Volume %s was updated while it was scanned, scanning it again", dir)
+		time.Sleep(volumeUpdateWait)
+	}
+}
+
+// isDirEntry reports whether entry is a directory, following symlinks so a
+// linked directory counts as one
+func isDirEntry(dir string, entry os.DirEntry) bool {
+	if entry.Type()&os.ModeSymlink == 0 {
+		return entry.IsDir()
+	}
+	info, err := os.Stat(filepath.Join(dir, entry.Name()))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,173 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeVolumeUpdate writes files into a new timestamped directory of the
+// volume at dir, the way the kubelet stages an update
+func writeVolumeUpdate(t *testing.T, dir, stamp string, files map[string]string) {
+	t.Helper()
+	data := filepath.Join(dir, stamp)
+	if err := os.Mkdir(data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(data, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// swapVolume atomically points the volume's ..data link at stamp by renaming
+// a fresh link over it
+func swapVolume(t *testing.T, dir, stamp string) {
+	t.Helper()
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(stamp, tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, volumeDataLink)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// linkVolumeFiles adds the visible links through ..data for names
+func linkVolumeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(volumeDataLink, name), link); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readScan reads every non-internal file of dataDir into name=content pairs
+func readScan(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	var seen []string
+	for _, entry := range entries {
+		if isVolumeInternal(entry.Name()) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dataDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		seen = append(seen, entry.Name()+"="+string(content))
+	}
+	sort.Strings(seen)
+	return seen, nil
+}
+
+func TestScanVolumePlainDirectory(t *testing.T) {
+	dir := t.TempDir()
+	var scanned []string
+	err := scanVolume(dir, func(dataDir string) error {
+		scanned = append(scanned, dataDir)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scanVolume() error = %v", err)
+	}
+	if len(scanned) != 1 || scanned[0] != dir {
+		t.Errorf("scanned %q, want only %q", scanned, dir)
+	}
+}
+
+func TestScanVolumeSwapDuringScan(t *testing.T) {
+	dir := t.TempDir()
+	writeVolumeUpdate(t, dir, "..2026_01_01", map[string]string{"a.json": "v1", "b.json": "v1"})
+	swapVolume(t, dir, "..2026_01_01")
+	linkVolumeFiles(t, dir, "a.json", "b.json")
+
+	var last []string
+	scans := 0
+	err := scanVolume(dir, func(dataDir string) error {
+		scans++
+		if scans == 1 {
+			// The kubelet swaps in an update while the first scan is reading
+			writeVolumeUpdate(t, dir, "..2026_01_02", map[string]string{"a.json": "v2", "b.json": "v2"})
+			swapVolume(t, dir, "..2026_01_02")
+		}
+		seen, err := readScan(dataDir)
+		last = seen
+		return err
+	})
+	if err != nil {
+		t.Fatalf("scanVolume() error = %v", err)
+	}
+	if scans != 2 {
+		t.Errorf("scanned %d times, want 2", scans)
+	}
+	want := []string{"a.json=v2", "b.json=v2"}
+	if strings.Join(last, ",") != strings.Join(want, ",") {
+		t.Errorf("final scan read %q, want %q", last, want)
+	}
+}
+
+func TestScanVolumeWaitsForNewLinks(t *testing.T) {
+	dir := t.TempDir()
+	writeVolumeUpdate(t, dir, "..2026_01_01", map[string]string{"a.json": "v1"})
+	swapVolume(t, dir, "..2026_01_01")
+	linkVolumeFiles(t, dir, "a.json")
+
+	// The update adding b.json is swapped in before its visible link exists
+	writeVolumeUpdate(t, dir, "..2026_01_02", map[string]string{"a.json": "v2", "b.json": "v2"})
+	swapVolume(t, dir, "..2026_01_02")
+
+	var last []string
+	scans := 0
+	err := scanVolume(dir, func(dataDir string) error {
+		scans++
+		if scans == 1 {
+			linkVolumeFiles(t, dir, "b.json")
+		}
+		seen, err := readScan(dataDir)
+		last = seen
+		return err
+	})
+	if err != nil {
+		t.Fatalf("scanVolume() error = %v", err)
+	}
+	if scans != 2 {
+		t.Errorf("scanned %d times, want 2", scans)
+	}
+	want := []string{"a.json=v2", "b.json=v2"}
+	if strings.Join(last, ",") != strings.Join(want, ",") {
+		t.Errorf("final scan read %q, want %q", last, want)
+	}
+}
+
+func TestScanVolumeNeverSettles(t *testing.T) {
+	dir := t.TempDir()
+	writeVolumeUpdate(t, dir, "..2026_01_00", map[string]string{"a.json": "v0"})
+	swapVolume(t, dir, "..2026_01_00")
+	linkVolumeFiles(t, dir, "a.json")
+
+	scans := 0
+	err := scanVolume(dir, func(dataDir string) error {
+		scans++
+		stamp := "..2026_01_0" + string(rune('0'+scans))
+		writeVolumeUpdate(t, dir, stamp, map[string]string{"a.json": stamp})
+		swapVolume(t, dir, stamp)
+		return nil
+	})
+	if err == nil {
+		t.Fatal("scanVolume() succeeded on a volume swapped during every scan")
+	}
+	if scans != maxVolumeScans {
+		t.Errorf("scanned %d times, want %d", scans, maxVolumeScans)
+	}
+}
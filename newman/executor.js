@@ -1,14 +1,539 @@
 #!/usr/bin/env node
 
+const crypto = require('crypto');
 const newman = require('newman');
 const path = require('path');
 
+// Report toolchain versions and exit when invoked with --version
+if (process.argv[2] === '--version') {
+  console.log(JSON.stringify({
+    node: process.version,
+    newman: require('newman/package.json').version
+  }));
+  process.exit(0);
+}
+
+// Print the result between markers so Scout can find it among any other
+// output that ends up on stdout
+function emitResult(result) {
+  console.log('---SCOUT-RESULT-BEGIN---');
+  console.log(JSON.stringify(result, null, 2));
+  console.log('---SCOUT-RESULT-END---');
+}
+
+// Variable a collection can set (e.g. from a /version response) to record the
+// application version it tested
+const TESTED_VERSION_VARIABLE = 'scout_tested_version';
+
+// Read the tested version from the run's environment, globals or collection
+// variables, in that order
+function readTestedVersion(summary) {
+  const scopes = [summary.environment, summary.globals, summary.collection && summary.collection.variables];
+  for (const scope of scopes) {
+    if (!scope || typeof scope.get !== 'function') continue;
+    const value = scope.get(TESTED_VERSION_VARIABLE);
+    if (value !== undefined && value !== null && String(value).trim() !== '') {
+      return String(value).trim();
+    }
+  }
+  return null;
+}
+
+// Longest redirect Location reported
+const MAX_LOCATION_LENGTH = 2048;
+
+// Find a header's value in either a list of {key, value} pairs or a plain
+// object, case-insensitively
+function headerValue(headers, name) {
+  if (!headers) return '';
+  const lower = name.toLowerCase();
+  if (Array.isArray(headers)) {
+    const header = headers.find(h => h && typeof h.key === 'string' && h.key.toLowerCase() === lower);
+    return header ? String(header.value) : '';
+  }
+  for (const key of Object.keys(headers)) {
+    if (key.toLowerCase() === lower) return String(headers[key]);
+  }
+  return '';
+}
+
+// Report the redirects followed while sending a request, from the runtime's
+// execution history: each 3xx hop's status code and Location. Requests that
+// weren't redirected report an empty chain.
+function readRedirects(history) {
+  const hops = history && history.execution && Array.isArray(history.execution.data) ? history.execution.data : [];
+  const redirects = [];
+  hops.forEach(hop => {
+    const response = hop && hop.response;
+    const statusCode = response && (response.statusCode || response.code);
+    if (!statusCode || statusCode < 300 || statusCode >= 400) return;
+
+    let location = headerValue(response.headers, 'location');
+    if (location.length > MAX_LOCATION_LENGTH) {
+      location = location.substring(0, MAX_LOCATION_LENGTH) + '...';
+    }
+    redirects.push({ statusCode: statusCode, location: location });
+  });
+  return redirects;
+}
+
+// Break a request's response time down into phases, in milliseconds, from
+// the timing offsets of its final hop in the runtime's execution history.
+// Offsets are only present for phases that happened, so DNS, connect and TLS
+// are null on a reused connection and TLS is null over plain HTTP.
+function readTimings(history) {
+  const hops = history && history.execution && Array.isArray(history.execution.data) ? history.execution.data : [];
+  const last = hops[hops.length - 1];
+  const offset = last && last.timings && last.timings.offset;
+  if (!offset) return null;
+
+  const phase = (from, to) => {
+    if (typeof from !== 'number' || typeof to !== 'number' || to < from) return null;
+    return Math.round((to - from) * 100) / 100;
+  };
+  const connected = [offset.secureConnect, offset.connect, offset.socket].find(v => typeof v === 'number');
+  return {
+    dns: phase(offset.socket, offset.lookup),
+    connect: phase(typeof offset.lookup === 'number' ? offset.lookup : offset.socket, offset.connect),
+    tls: phase(offset.connect, offset.secureConnect),
+    firstByte: phase(connected, offset.response),
+    download: phase(offset.response, offset.end)
+  };
+}
+
+// Failed test recorded for each GraphQL response carrying errors; Scout's
+// storage.GraphQLTestName must match
+const GRAPHQL_TEST_NAME = '[scout] GraphQL response has no errors';
+
+// Most GraphQL error messages reported per response
+const MAX_GRAPHQL_ERRORS = 10;
+
+// Content types marking a request or response as GraphQL
+const GRAPHQL_CONTENT_TYPE_PATTERN = /^application\/graphql/i;
+
+// Whether a request is GraphQL: it has a Postman GraphQL body, a GraphQL
+// content type on the request or response, or a URL path listed in the
+// directory's scout.yaml graphql_paths
+function isGraphQLRequest(request, response, graphqlPaths) {
+  if (request && request.body && request.body.mode === 'graphql') return true;
+
+  const contentType = headers => headers && typeof headers.get === 'function' ? String(headers.get('Content-Type') || '') : '';
+  if (GRAPHQL_CONTENT_TYPE_PATTERN.test(contentType(request && request.headers)) ||
+      GRAPHQL_CONTENT_TYPE_PATTERN.test(contentType(response && response.headers))) {
+    return true;
+  }
+
+  const url = request && request.url;
+  return graphqlPaths.size > 0 && !!url && typeof url.getPath === 'function' && graphqlPaths.has(url.getPath());
+}
+
+// Read the messages of a GraphQL response's top-level errors array. A
+// response that isn't JSON or has no errors reports none.
+function readGraphQLErrors(response) {
+  let body;
+  try {
+    body = JSON.parse(response.text());
+  } catch (e) {
+    return [];
+  }
+  if (!body || !Array.isArray(body.errors)) return [];
+
+  const messages = body.errors.slice(0, MAX_GRAPHQL_ERRORS).map(error => {
+    const message = error && typeof error.message === 'string' ? error.message : renderDetailValue(error);
+    return message.length > MAX_DETAIL_VALUE_LENGTH ? message.substring(0, MAX_DETAIL_VALUE_LENGTH) + '...' : message;
+  });
+  if (body.errors.length > MAX_GRAPHQL_ERRORS) {
+    messages.push(`... and ${body.errors.length - MAX_GRAPHQL_ERRORS} more`);
+  }
+  return messages;
+}
+
+// Longest normalized response body reported with a snapshot
+const MAX_SNAPSHOT_BODY_LENGTH = 64 * 1024;
+
+// Newman run options set by each passthrough flag, mirroring the allowlist in
+// Scout's scout.yaml validation; a null value marks a flag without a value
+const NEWMAN_ARG_OPTIONS = {
+  '--bail': ['bail', null],
+  '--color': ['color', String],
+  '--disable-unicode': ['disableUnicode', null],
+  '--folder': ['folder', String],
+  '--ignore-redirects': ['ignoreRedirects', null],
+  '--insecure': ['insecure', null],
+  '-k': ['insecure', null],
+  '--iteration-count': ['iterationCount', Number],
+  '-n': ['iterationCount', Number],
+  '--timeout': ['timeout', Number],
+  '--timeout-request': ['timeoutRequest', Number],
+  '--timeout-script': ['timeoutScript', Number],
+  '--verbose': ['verbose', null]
+};
+
+// Set the run options of passthrough Newman flags; --folder may repeat
+function applyNewmanArgs(args, runOptions) {
+  for (let i = 0; i < args.length; i++) {
+    const spec = NEWMAN_ARG_OPTIONS[args[i]];
+    if (!spec) {
+      console.error(`[WARN] Ignoring unsupported Newman flag ${args[i]}`);
+      continue;
+    }
+    const [option, parse] = spec;
+    if (!parse) {
+      runOptions[option] = true;
+      continue;
+    }
+    if (i + 1 >= args.length) {
+      break;
+    }
+    const value = parse(args[++i]);
+    if (option === 'folder') {
+      runOptions.folder = (runOptions.folder || []).concat(value);
+    } else {
+      runOptions[option] = value;
+    }
+  }
+}
+
+// Turn a request name glob into a regular expression; * matches any run of
+// characters and ? a single character
+function globPattern(glob) {
+  const source = glob.split('').map(c => {
+    if (c === '*') return '.*';
+    if (c === '?') return '.';
+    return c.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+  }).join('');
+  return new RegExp('^' + source + '$');
+}
+
+// Split a JSON path such as $.items[*].updatedAt into its segments, with *
+// matching every key or index
+function parseSnapshotPath(jsonPath) {
+  const segments = [];
+  const pattern = /\.([^.\[\]]+)|\[(\d+|\*)\]/g;
+  const rest = jsonPath.replace(/^\$/, '');
+  let match;
+  while ((match = pattern.exec(rest)) !== null) {
+    segments.push(match[1] !== undefined ? match[1] : match[2]);
+  }
+  return segments;
+}
+
+// Remove the value at a path's segments from a parsed body. Removed array
+// elements become null so the remaining elements keep their positions.
+function removeSnapshotPath(node, segments) {
+  if (node === null || typeof node !== 'object' || segments.length === 0) return;
+  const [segment, ...rest] = segments;
+  const keys = segment === '*' ? Object.keys(node) : [segment];
+  keys.forEach(key => {
+    if (!Object.prototype.hasOwnProperty.call(node, key)) return;
+    if (rest.length > 0) {
+      removeSnapshotPath(node[key], rest);
+    } else if (Array.isArray(node)) {
+      node[key] = null;
+    } else {
+      delete node[key];
+    }
+  });
+}
+
+// Render JSON with object keys sorted, so key order doesn't change the hash
+function canonicalJSON(value) {
+  if (Array.isArray(value)) {
+    return '[' + value.map(canonicalJSON).join(',') + ']';
+  }
+  if (value !== null && typeof value === 'object') {
+    return '{' + Object.keys(value).sort().map(key => JSON.stringify(key) + ':' + canonicalJSON(value[key])).join(',') + '}';
+  }
+  return JSON.stringify(value === undefined ? null : value);
+}
+
+// Hash a response body for change detection. JSON bodies are normalized
+// first: ignored paths removed and keys sorted. Other bodies are hashed as
+// they are. The normalized body is reported too when bodies are stored,
+// truncated and with secrets masked.
+function snapshotResponse(response, ignorePaths, storeBody, secretValues) {
+  let text = response.text();
+  try {
+    const body = JSON.parse(text);
+    ignorePaths.forEach(segments => removeSnapshotPath(body, segments));
+    text = canonicalJSON(body);
+  } catch (e) {
+    // Not JSON, so there are no fields to ignore
+  }
+
+  const snapshot = {
+    hash: crypto.createHash('sha256').update(text).digest('hex'),
+    body: null
+  };
+  if (storeBody) {
+    let body = text.length > MAX_SNAPSHOT_BODY_LENGTH ? text.substring(0, MAX_SNAPSHOT_BODY_LENGTH) + '...' : text;
+    secretValues.forEach(secret => {
+      body = body.split(secret).join(MASKED_VALUE);
+    });
+    snapshot.body = body;
+  }
+  return snapshot;
+}
+
+// Collection variables whose names match are reported masked
+const SENSITIVE_VARIABLE_PATTERN = /token|secret|passw|api[-_]?key|auth|credential|private|cookie|session/i;
+
+// Replaces masked variable values
+const MASKED_VALUE = '****';
+
+// Longest collection variable value reported
+const MAX_VARIABLE_VALUE_LENGTH = 1024;
+
+// Mask a collection variable's value for reporting: entirely when the name
+// looks sensitive or the variable is of type secret, otherwise wherever it
+// contains an injected secret
+function maskVariableValue(variable, secretValues) {
+  let value = variable.value === undefined || variable.value === null ? '' : String(variable.value);
+  if (variable.type === 'secret' || SENSITIVE_VARIABLE_PATTERN.test(variable.key)) {
+    return MASKED_VALUE;
+  }
+  secretValues.forEach(secret => {
+    value = value.split(secret).join(MASKED_VALUE);
+  });
+  if (value.length > MAX_VARIABLE_VALUE_LENGTH) {
+    value = value.substring(0, MAX_VARIABLE_VALUE_LENGTH) + '...';
+  }
+  return value;
+}
+
+// Report the collection's variables as resolved at the end of the run, masked.
+// A variable also defined in the environment (or by --env-var) is marked
+// overridden, since requests saw the environment's value instead.
+function readCollectionVariables(summary, secretValues) {
+  const variables = [];
+  const scope = summary.collection && summary.collection.variables;
+  if (!scope || typeof scope.each !== 'function') return variables;
+
+  scope.each(variable => {
+    if (!variable || !variable.key || variable.disabled) return;
+    variables.push({
+      key: variable.key,
+      value: maskVariableValue(variable, secretValues),
+      overridden: Boolean(summary.environment && summary.environment.has(variable.key))
+    });
+  });
+  return variables;
+}
+
+// Report the collection's variables as the run starts, masked like those at
+// the end, so a replay can start from the same inputs. overriddenKeys are the
+// names the environment and --env-var define.
+function readInitialVariables(collection, overriddenKeys, secretValues) {
+  if (!Array.isArray(collection.variable)) return [];
+  return collection.variable
+    .filter(variable => variable && variable.key && !variable.disabled)
+    .map(variable => ({
+      key: variable.key,
+      value: maskVariableValue(variable, secretValues),
+      overridden: overriddenKeys.has(variable.key)
+    }));
+}
+
+// Set collection variables, replacing the collection's own value of each
+function applyCollectionVariables(collection, variables) {
+  if (!Array.isArray(collection.variable)) {
+    collection.variable = [];
+  }
+  variables.forEach(({ key, value }) => {
+    const existing = collection.variable.find(variable => variable && variable.key === key);
+    if (existing) {
+      existing.value = value;
+      existing.disabled = false;
+    } else {
+      collection.variable.push({ key: key, value: value, type: 'string' });
+    }
+  });
+}
+
+// Longest rendering of an expected or actual value kept in assertion detail
+const MAX_DETAIL_VALUE_LENGTH = 1024;
+
+// Render a compared value as JSON, truncated so a whole response body
+// doesn't end up in the database
+function renderDetailValue(value) {
+  let text;
+  try {
+    text = JSON.stringify(value);
+  } catch (e) {
+    text = undefined;
+  }
+  if (text === undefined) {
+    text = String(value);
+  }
+  if (text.length > MAX_DETAIL_VALUE_LENGTH) {
+    text = text.substring(0, MAX_DETAIL_VALUE_LENGTH) + '...';
+  }
+  return text;
+}
+
+// Parse the violations out of a JSON Schema assertion failure, e.g.
+// "expected data to satisfy schema but found following errors: data.id should be integer"
+function parseSchemaErrors(message) {
+  const marker = 'found following errors:';
+  const start = message.indexOf(marker);
+  if (start < 0) return [];
+
+  const errors = [];
+  message.substring(start + marker.length).split(/,\s+(?=data)|\n/).forEach(part => {
+    const match = part.trim().match(/^(data\S*)\s+(.+)$/);
+    if (match) {
+      errors.push({ path: match[1], message: match[2] });
+    }
+  });
+  return errors;
+}
+
+// Extract structured detail from a failed assertion: the expected and actual
+// values chai reports, a path set by a custom assertion, and JSON Schema
+// violations. Returns null when the error carries none of these.
+function assertionDetail(err) {
+  const detail = { path: '', expected: null, actual: null, schemaErrors: [] };
+  let found = false;
+
+  if (typeof err.path === 'string' && err.path !== '') {
+    detail.path = err.path;
+    found = true;
+  }
+  if (err.showDiff !== false && 'expected' in err && 'actual' in err) {
+    detail.expected = renderDetailValue(err.expected);
+    detail.actual = renderDetailValue(err.actual);
+    found = true;
+  }
+  detail.schemaErrors = parseSchemaErrors(err.message || '');
+  if (detail.schemaErrors.length > 0) {
+    found = true;
+    if (!detail.path) {
+      detail.path = detail.schemaErrors[0].path;
+    }
+  }
+
+  return found ? detail : null;
+}
+
+// Build a collection auth object from SCOUT_AUTH, returning it with the
+// credentials to mask, or null when no auth is injected
+function readInjectedAuth() {
+  const raw = process.env.SCOUT_AUTH;
+  delete process.env.SCOUT_AUTH;
+  if (!raw) return null;
+
+  let config;
+  try {
+    config = JSON.parse(raw);
+  } catch (e) {
+    console.error('[WARN] Ignoring malformed SCOUT_AUTH: ' + e.message);
+    return null;
+  }
+
+  switch (config.type) {
+    case 'bearer':
+      return {
+        auth: { type: 'bearer', bearer: [{ key: 'token', value: config.token || '', type: 'string' }] },
+        secrets: [config.token || '']
+      };
+    case 'basic':
+      return {
+        auth: {
+          type: 'basic',
+          basic: [
+            { key: 'username', value: config.username || '', type: 'string' },
+            { key: 'password', value: config.password || '', type: 'string' }
+          ]
+        },
+        secrets: [config.username || '', config.password || '']
+      };
+    default:
+      console.error(`[WARN] Ignoring unsupported auth type: ${config.type}`);
+      return null;
+  }
+}
+
+// Remove the auth saved on folders and requests so they inherit the injected
+// collection auth, returning how many were changed. Items marked noauth carry
+// no credentials and keep sending none, like a public login endpoint.
+function inheritInjectedAuth(items) {
+  if (!Array.isArray(items)) return 0;
+  let replaced = 0;
+  const inherit = owner => {
+    if (owner && owner.auth && owner.auth.type !== 'noauth') {
+      delete owner.auth;
+      replaced++;
+    }
+  };
+  items.forEach(item => {
+    if (!item) return;
+    inherit(item);
+    if (item.request && typeof item.request === 'object') {
+      inherit(item.request);
+    }
+    replaced += inheritInjectedAuth(item.item);
+  });
+  return replaced;
+}
+
 // Get collection path and optional environment path from command line arguments
 const collectionPath = process.argv[2];
 const environmentPath = process.argv[3]; // Optional
 const directoryName = process.argv[4]; // Optional - directory name for secret injection
 const environmentName = process.argv[5]; // Optional - environment name for secret injection
 
+// Optional per-run overrides passed as trailing "--env-var key=value" pairs,
+// collection variables a replay starts from as "--collection-var key=value",
+// response headers to capture as "--capture-header name" pairs, URL paths of
+// GraphQL endpoints as "--graphql-path path" pairs, request name globs to
+// snapshot as "--snapshot-request glob" pairs with JSON paths their
+// snapshots ignore as "--snapshot-ignore path" pairs and "--snapshot-body"
+// to report bodies, the pause between requests as "--delay-request ms", a
+// JSON data file to iterate over as "--iteration-data path", and extra
+// Newman flags from scout.yaml one argument at a time as "--newman-arg arg"
+const overrideVars = [];
+const collectionVars = [];
+const captureHeaders = new Set();
+const graphqlPaths = new Set();
+const snapshotRequests = [];
+const snapshotIgnore = [];
+let snapshotBody = false;
+let delayRequest = 0;
+let iterationData = null;
+const newmanArgs = [];
+for (let i = 6; i < process.argv.length; i++) {
+  if (process.argv[i] === '--snapshot-request' && i + 1 < process.argv.length) {
+    snapshotRequests.push(globPattern(process.argv[++i]));
+  } else if (process.argv[i] === '--snapshot-ignore' && i + 1 < process.argv.length) {
+    snapshotIgnore.push(parseSnapshotPath(process.argv[++i]));
+  } else if (process.argv[i] === '--snapshot-body') {
+    snapshotBody = true;
+  } else if (process.argv[i] === '--capture-header' && i + 1 < process.argv.length) {
+    captureHeaders.add(process.argv[++i].toLowerCase());
+  } else if (process.argv[i] === '--graphql-path' && i + 1 < process.argv.length) {
+    graphqlPaths.add(process.argv[++i]);
+  } else if (process.argv[i] === '--delay-request' && i + 1 < process.argv.length) {
+    const delay = parseInt(process.argv[++i], 10);
+    if (delay > 0) {
+      delayRequest = delay;
+    }
+  } else if (process.argv[i] === '--iteration-data' && i + 1 < process.argv.length) {
+    iterationData = process.argv[++i];
+  } else if (process.argv[i] === '--newman-arg' && i + 1 < process.argv.length) {
+    newmanArgs.push(process.argv[++i]);
+  } else if ((process.argv[i] === '--env-var' || process.argv[i] === '--collection-var') && i + 1 < process.argv.length) {
+    const target = process.argv[i] === '--env-var' ? overrideVars : collectionVars;
+    const pair = process.argv[++i];
+    const separator = pair.indexOf('=');
+    if (separator > 0) {
+      target.push({
+        key: pair.substring(0, separator),
+        value: pair.substring(separator + 1)
+      });
+    }
+  }
+}
+
 // Log all non-null arguments
 console.error('[INFO] Executor arguments:');
 if (collectionPath) console.error(`[INFO]   collectionPath: ${collectionPath}`);
@@ -55,8 +580,10 @@ if (environmentPath) {
   }
 }
 
-// Scan for secret environment variables to inject
+// Scan for secret environment variables to inject; their values are masked
+// wherever they appear in reported collection variables
 const envVars = [];
+const secretValues = [];
 if (directoryName && environmentName) {
   const prefix = `${directoryName}_${environmentName}_`;
 
@@ -67,6 +594,9 @@ if (directoryName && environmentName) {
         key: strippedKey,
         value: process.env[key]
       });
+      if (process.env[key].length >= 4) {
+        secretValues.push(process.env[key]);
+      }
       console.error(`[INFO] Injecting secret: ${strippedKey} from env var: ${key}`);
     }
   }
@@ -76,6 +606,41 @@ if (directoryName && environmentName) {
   }
 }
 
+// Apply collection-level auth from the directory's scout.yaml, replacing any
+// auth saved in the collection, its folders or its requests. It arrives
+// through the environment so the credentials never appear on the command
+// line, and is masked like a secret.
+const injectedAuth = readInjectedAuth();
+if (injectedAuth) {
+  collectionData.auth = injectedAuth.auth;
+  const inherited = inheritInjectedAuth(collectionData.item);
+  if (inherited > 0) {
+    console.error(`[INFO] Replaced auth saved on ${inherited} folder(s) or request(s) with the injected auth`);
+  }
+  secretValues.push(...injectedAuth.secrets.filter(value => value.length >= 4));
+  console.error(`[INFO] Applying ${injectedAuth.auth.type} auth from scout.yaml`);
+}
+
+// Apply per-run overrides last so they win over injected secrets
+overrideVars.forEach(overrideVar => {
+  envVars.push(overrideVar);
+  console.error(`[INFO] Overriding variable for this run: ${overrideVar.key}`);
+});
+
+// A replay starts from the collection variables recorded for the original
+// run; scripts can still change them, and the environment still shadows them
+if (collectionVars.length > 0) {
+  applyCollectionVariables(collectionData, collectionVars);
+  console.error(`[INFO] Replaying ${collectionVars.length} recorded collection variable(s)`);
+}
+
+const overriddenKeys = new Set(envVars.map(envVar => envVar.key));
+if (environmentData && Array.isArray(environmentData.values)) {
+  environmentData.values
+    .filter(value => value && value.key && value.enabled !== false)
+    .forEach(value => overriddenKeys.add(value.key));
+}
+
 // Prepare result object
 const result = {
   collectionName: collectionName,
@@ -89,6 +654,11 @@ const result = {
   tests: [],
   executions: [],
   totalDurationMs: 0,
+  requestsTotal: null,
+  transferredBytes: null,
+  testedVersion: null,
+  collectionVariables: [],
+  initialVariables: readInitialVariables(collectionData, overriddenKeys, secretValues),
   error: null
 };
 
@@ -96,7 +666,10 @@ const result = {
 const runOptions = {
   collection: collectionData,
   reporters: [], // We'll handle reporting ourselves
-  insecure: true // Disable TLS/SSL certificate verification
+  insecure: true, // Disable TLS/SSL certificate verification
+  // Newman only records request timing phases when verbose; with no
+  // reporters it prints nothing more
+  verbose: true
 };
 
 // Add environment if provided
@@ -109,6 +682,20 @@ if (envVars.length > 0) {
   runOptions.envVar = envVars;
 }
 
+// Space requests to stay within the global request budget
+if (delayRequest > 0) {
+  runOptions.delayRequest = delayRequest;
+}
+
+// Run once per row of the data file
+if (iterationData) {
+  runOptions.iterationData = iterationData;
+}
+
+// Apply passthrough Newman flags; Scout validated them against the same
+// allowlist, so anything unknown here is skipped
+applyNewmanArgs(newmanArgs, runOptions);
+
 // Log the equivalent Newman CLI command for debugging
 let cliCommand = `newman run ${collectionPath}`;
 if (environmentPath) {
@@ -116,15 +703,24 @@ if (environmentPath) {
 }
 if (envVars.length > 0) {
   envVars.forEach(envVar => {
-    cliCommand += ` --env-var "${envVar.key}=${envVar.value}"`;
+    cliCommand += ` --env-var "${envVar.key}=****"`;
   });
 }
+if (delayRequest > 0) {
+  cliCommand += ` --delay-request ${delayRequest}`;
+}
+if (iterationData) {
+  cliCommand += ` --iteration-data ${iterationData}`;
+}
+if (newmanArgs.length > 0) {
+  cliCommand += ` ${newmanArgs.join(' ')}`;
+}
 console.error(`[INFO] Executing Newman command:\n${cliCommand}`);
 
 newman.run(runOptions, (err) => {
   if (err) {
     result.error = err.message;
-    console.log(JSON.stringify(result, null, 2));
+    emitResult(result);
     process.exit(1);
   }
 }).on('start', (err, args) => {
@@ -146,7 +742,12 @@ newman.run(runOptions, (err) => {
     status: 'unknown',
     statusCode: null,
     responseTime: null,
-    error: null
+    error: null,
+    headers: [],
+    redirects: readRedirects(args.history),
+    graphqlErrors: [],
+    snapshot: null,
+    timings: null
   };
 
   if (err) {
@@ -155,7 +756,44 @@ newman.run(runOptions, (err) => {
   } else if (args.response) {
     execution.statusCode = args.response.code;
     execution.responseTime = args.response.responseTime;
+    execution.timings = readTimings(args.history);
     execution.status = args.response.code >= 200 && args.response.code < 300 ? 'success' : 'failed';
+
+    // Capture allowlisted response headers (case-insensitive)
+    if (captureHeaders.size > 0 && args.response.headers) {
+      args.response.headers.each(header => {
+        if (header.key && captureHeaders.has(header.key.toLowerCase())) {
+          execution.headers.push({ name: header.key, value: String(header.value) });
+        }
+      });
+    }
+
+    // A GraphQL response can carry errors with a 200 status, so its
+    // errors fail the request like an HTTP error status and are recorded
+    // as a failed test
+    if (isGraphQLRequest(args.request, args.response, graphqlPaths)) {
+      execution.graphqlErrors = readGraphQLErrors(args.response);
+      if (execution.graphqlErrors.length > 0) {
+        execution.status = 'failed';
+        execution.error = 'GraphQL errors: ' + execution.graphqlErrors.join('; ');
+        result.tests.push({
+          name: GRAPHQL_TEST_NAME,
+          sequence: result.tests.length,
+          passed: false,
+          error: execution.error,
+          executionName: execution.name,
+          detail: null
+        });
+        result.summary.total++;
+        result.summary.failed++;
+      }
+    }
+
+    // Hash the body of requests flagged for snapshotting so Scout can tell
+    // when their content changes
+    if (snapshotRequests.some(pattern => pattern.test(execution.name))) {
+      execution.snapshot = snapshotResponse(args.response, snapshotIgnore, snapshotBody, secretValues);
+    }
   }
 
   result.executions.push(execution);
@@ -164,9 +802,11 @@ newman.run(runOptions, (err) => {
 
   const test = {
     name: args.assertion || 'Unknown Test',
+    sequence: result.tests.length,
     passed: !err,
     error: err ? err.message : null,
-    executionName: args.item?.name || 'unknown'
+    executionName: args.item?.name || 'unknown',
+    detail: err ? assertionDetail(err) : null
   };
 
   result.tests.push(test);
@@ -184,8 +824,18 @@ newman.run(runOptions, (err) => {
 
   if (summary) {
     result.totalDurationMs = summary.run.timings.completed - summary.run.timings.started;
+
+    // Older Newman versions may not report these; they stay null
+    if (summary.run.stats && summary.run.stats.requests) {
+      result.requestsTotal = summary.run.stats.requests.total;
+    }
+    if (summary.run.transfers && typeof summary.run.transfers.responseTotal === 'number') {
+      result.transferredBytes = summary.run.transfers.responseTotal;
+    }
+    result.testedVersion = readTestedVersion(summary);
+    result.collectionVariables = readCollectionVariables(summary, secretValues);
   }
 
   // Output the final result as JSON
-  console.log(JSON.stringify(result, null, 2));
+  emitResult(result);
 });